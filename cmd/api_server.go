@@ -1,9 +1,12 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"net/http"
+	"os"
+	"time"
 
 	"github.com/Depado/ginprom"
 	"github.com/aurowora/compress"
@@ -19,9 +22,9 @@ import (
 	hc_config "github.com/tavsec/gin-healthcheck/config"
 )
 
-func ApiServer(dbPath string, port int, fullRefresh, debug bool) error {
+func ApiServer(ctx context.Context, dbPath string, port int, fullRefresh, debug bool) error {
 
-	client, repo, err := bootstrap(dbPath, fullRefresh, debug)
+	client, repo, err := bootstrap(ctx, dbPath, fullRefresh, debug)
 	if err != nil {
 		return err
 	}
@@ -31,9 +34,19 @@ func ApiServer(dbPath string, port int, fullRefresh, debug bool) error {
 		}
 	}()
 
-	if _, err := internal.StartCron(client, repo); err != nil {
+	sources, err := bootstrapSources()
+	if err != nil {
+		return err
+	}
+
+	scheduler, err := internal.StartCron(ctx, append([]internal.DataSource{client}, sources...), repo)
+	if err != nil {
 		return fmt.Errorf("failed to start CRON jobs: %w", err)
 	}
+	defer func() {
+		log.Println("Waiting for running CRON jobs to finish...")
+		<-scheduler.Stop().Done()
+	}()
 
 	r := gin.New()
 
@@ -58,6 +71,7 @@ func ApiServer(dbPath string, port int, fullRefresh, debug bool) error {
 
 	err = healthcheck.New(r, hc_config.DefaultConfig(), []checks.Check{
 		repo.Check(),
+		client.Check(),
 	})
 	if err != nil {
 		return fmt.Errorf("failed to initialize healthcheck: %v", err)
@@ -71,15 +85,50 @@ func ApiServer(dbPath string, port int, fullRefresh, debug bool) error {
 		c.JSON(http.StatusMethodNotAllowed, gin.H{"error": "Method not allowed"})
 	})
 
+	r.GET("/v1/fuel-types", routes.FuelTypes(repo))
+
 	v1 := r.Group("/v1/fuel-prices")
 	v1.GET("/search", routes.Search(repo, client))
+	v1.GET("/nearby", routes.Nearby(repo, client))
 	v1.GET("/history/:node_id/:fuel_type", routes.PriceHistory(repo, client))
+	v1.GET("/stations/activity", routes.StationActivity(repo))
+	v1.GET("/stations/coordinates", routes.CoordinateIssues(repo))
+	v1.GET("/stations/:node_id/changes", routes.StationChanges(repo))
 	v1.GET("/stats/snapshot", routes.SnapshotStats(repo))
 	v1.GET("/stats/distribution", routes.DistributionStats(repo))
+	v1.GET("/bounds", routes.PriceBounds(repo))
+	v1.GET("/anomalies", routes.Anomalies(repo))
+
+	adminToken := os.Getenv("FUEL_PRICES_ADMIN_TOKEN")
+	if adminToken == "" {
+		log.Println("WARNING: FUEL_PRICES_ADMIN_TOKEN is not set, admin endpoints are disabled")
+	}
+
+	admin := v1.Group("/admin", routes.AdminAuth(adminToken))
+	admin.GET("/schema-drift", routes.SchemaDrift(client))
+	admin.GET("/quarantine", routes.QuarantinedPrices(repo))
+	admin.POST("/quarantine/:id/approve", routes.ReviewQuarantinedPrice(repo, true))
+	admin.POST("/quarantine/:id/reject", routes.ReviewQuarantinedPrice(repo, false))
+	admin.GET("/imports", routes.ImportRuns(repo))
+	admin.GET("/imports/:id", routes.ImportRun(repo))
+
+	srv := &http.Server{
+		Addr:    fmt.Sprintf(":%d", port),
+		Handler: r,
+	}
+
+	go func() {
+		<-ctx.Done()
+		log.Println("Shutting down HTTP API Server...")
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		if err := srv.Shutdown(shutdownCtx); err != nil {
+			log.Printf("failed to shut down HTTP API Server: %v", err)
+		}
+	}()
 
-	addr := fmt.Sprintf(":%d", port)
 	log.Printf("Starting HTTP API Server on port %d...", port)
-	if err := r.Run(addr); err != nil && err != http.ErrServerClosed {
+	if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
 		return fmt.Errorf("HTTP API Server failed to start on port %d: %v", port, err)
 	}
 
@@ -1,6 +1,7 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"os"
@@ -15,12 +16,53 @@ import (
 	"github.com/rm-hull/fuel-prices-api/internal"
 	"github.com/rm-hull/fuel-prices-api/internal/brands"
 	"github.com/rm-hull/fuel-prices-api/internal/metrics"
+	_ "github.com/rm-hull/fuel-prices-api/internal/sources"
 )
 
-// bootstrap initialises shared resources used by both the API server and import
-// commands. It returns the authenticated client, a repository, and an error
-// if something failed during startup.
-func bootstrap(dbPath string, fullRefresh, debug bool) (internal.FuelPricesClient, internal.FuelPricesRepository, error) {
+// bootstrap initialises the shared resources used by the API server. It returns
+// the GOV.UK client, a repository, and an error if something failed during
+// startup. Failing to authenticate is not an error: the client starts in
+// degraded mode instead.
+func bootstrap(ctx context.Context, dbPath string, fullRefresh, debug bool) (internal.FuelPricesClient, internal.FuelPricesRepository, error) {
+	repo, err := bootstrapRepository(dbPath, debug)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if fullRefresh {
+		log.Println("Full refresh requested, resetting fetch watermarks")
+		if err := repo.ResetFetchWatermarks(); err != nil {
+			_ = repo.Close()
+			return nil, nil, err
+		}
+	}
+
+	clientId := os.Getenv("CLIENT_ID")
+	clientSecret := os.Getenv("CLIENT_SECRET")
+
+	client, err := internal.StartFuelPricesClient(ctx, clientId, clientSecret, fullRefresh, repo)
+	if err != nil {
+		_ = repo.Close()
+		return nil, nil, fmt.Errorf("failed to create GOV.UK client: %w", err)
+	}
+
+	return client, repo, nil
+}
+
+// bootstrapSources creates the additional data sources configured in the JSON
+// file named by FUEL_PRICES_SOURCES, if any. It must be called after the
+// environment has been loaded by bootstrapRepository.
+func bootstrapSources() ([]internal.DataSource, error) {
+	path := os.Getenv("FUEL_PRICES_SOURCES")
+	if path == "" {
+		return nil, nil
+	}
+	return internal.LoadDataSources(path)
+}
+
+// bootstrapRepository initialises the environment, error reporting and a
+// migrated repository, for commands which never talk to the GOV.UK API.
+func bootstrapRepository(dbPath string, debug bool) (internal.FuelPricesRepository, error) {
 	if err := godotenv.Load(); err != nil {
 		log.Println("No .env file found")
 	}
@@ -37,7 +79,7 @@ func bootstrap(dbPath string, fullRefresh, debug bool) (internal.FuelPricesClien
 		EnableLogs:  true,
 	})
 	if err != nil {
-		return nil, nil, fmt.Errorf("sentry initialization failed: %w", err)
+		return nil, fmt.Errorf("sentry initialization failed: %w", err)
 	}
 	defer sentry.Flush(2 * time.Second)
 
@@ -45,32 +87,48 @@ func bootstrap(dbPath string, fullRefresh, debug bool) (internal.FuelPricesClien
 	godx.EnvironmentVars()
 	godx.UserInfo()
 
-	clientId := os.Getenv("CLIENT_ID")
-	clientSecret := os.Getenv("CLIENT_SECRET")
-
-	client, err := internal.NewFuelPricesClient(clientId, clientSecret, fullRefresh)
-	if err != nil {
-		return nil, nil, fmt.Errorf("GOV.UK authentication failed: %w", err)
-	}
-
 	db, err := internal.Connect(dbPath)
 	if err != nil {
-		return nil, nil, fmt.Errorf("failed to initialize database: %w", err)
+		return nil, fmt.Errorf("failed to initialize database: %w", err)
 	}
 
 	if err := internal.Migrate("migrations", dbPath); err != nil {
 		_ = db.Close()
-		return nil, nil, fmt.Errorf("failed to migrate SQL: %w", err)
+		return nil, fmt.Errorf("failed to migrate SQL: %w", err)
 	}
 
 	retailers, err := brands.GetRetailersMap()
 	if err != nil {
-		return nil, nil, fmt.Errorf("failed to load retailers: %w", err)
+		return nil, fmt.Errorf("failed to load retailers: %w", err)
 	}
 
 	repo := internal.NewFuelPricesRepository(db, &retailers)
+
+	boundsConfig, err := internal.LoadBoundsConfig()
+	if err != nil {
+		_ = repo.Close()
+		return nil, err
+	}
+	if err := repo.ConfigurePriceBounds(boundsConfig); err != nil {
+		_ = repo.Close()
+		return nil, fmt.Errorf("failed to configure price bounds: %w", err)
+	}
+
+	anomalyConfig, err := internal.LoadAnomalyConfig()
+	if err != nil {
+		_ = repo.Close()
+		return nil, err
+	}
+	repo.ConfigureAnomalyDetection(anomalyConfig)
+
+	coordinateConfig, err := internal.LoadCoordinateConfig()
+	if err != nil {
+		_ = repo.Close()
+		return nil, err
+	}
+	repo.ConfigureCoordinateValidation(coordinateConfig)
 	metrics.RegisterFuelSnapshotCollector(prometheus.DefaultRegisterer, repo.SnapshotStats)
 	metrics.RegisterFuelDistributionCollector(prometheus.DefaultRegisterer, repo.DistributionStats)
 
-	return client, repo, nil
+	return repo, nil
 }
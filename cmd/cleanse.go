@@ -0,0 +1,51 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"text/tabwriter"
+
+	"github.com/rm-hull/fuel-prices-api/internal/models"
+)
+
+// Cleanse corrects or deletes implausible historical prices already in the
+// database, using the same rules and price bounds as on import.
+func Cleanse(ctx context.Context, dbPath string, dryRun bool) error {
+	repo, err := bootstrapRepository(dbPath, false)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := repo.Close(); err != nil {
+			log.Printf("failed to close repository: %v", err)
+		}
+	}()
+
+	report, err := repo.Cleanse(ctx, dryRun)
+	if err != nil {
+		return err
+	}
+
+	return printCleanseReport(os.Stdout, report)
+}
+
+func printCleanseReport(out io.Writer, report *models.CleanseReport) error {
+	if report.DryRun {
+		_, _ = fmt.Fprint(out, "Dry run: no changes have been written\n\n")
+	} else {
+		_, _ = fmt.Fprintf(out, "Cleanse run %s: changes are recorded in the cleanse_audit table\n\n", report.RunId)
+	}
+
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(w, "Rule\tUpdated\tDeleted\tDescription")
+	for _, rule := range report.Rules {
+		_, _ = fmt.Fprintf(w, "%s\t%d\t%d\t%s\n", rule.Rule, rule.Updated, rule.Deleted, rule.Description)
+	}
+	_, _ = fmt.Fprintln(w)
+	_, _ = fmt.Fprintf(w, "Prices examined\t%d\n", report.Examined)
+
+	return w.Flush()
+}
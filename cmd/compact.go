@@ -0,0 +1,46 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"text/tabwriter"
+
+	"github.com/rm-hull/fuel-prices-api/internal/models"
+)
+
+// Compact converts the stored price history into one row per change of
+// price, as it is now recorded on import.
+func Compact(ctx context.Context, dbPath string, dryRun bool) error {
+	repo, err := bootstrapRepository(dbPath, false)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := repo.Close(); err != nil {
+			log.Printf("failed to close repository: %v", err)
+		}
+	}()
+
+	report, err := repo.Compact(ctx, dryRun)
+	if err != nil {
+		return err
+	}
+
+	return printCompactReport(os.Stdout, report)
+}
+
+func printCompactReport(out io.Writer, report *models.CompactReport) error {
+	if report.DryRun {
+		_, _ = fmt.Fprint(out, "Dry run: no changes have been written\n\n")
+	}
+
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintf(w, "Prices examined\t%d\n", report.Examined)
+	_, _ = fmt.Fprintf(w, "Price changes kept\t%d\n", report.Kept)
+	_, _ = fmt.Fprintf(w, "Repeated prices removed\t%d\n", report.Removed)
+
+	return w.Flush()
+}
@@ -1,13 +1,24 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
+	"io"
 	"log"
+	"os"
+	"slices"
+	"text/tabwriter"
+
+	"github.com/rm-hull/fuel-prices-api/internal"
+	"github.com/rm-hull/fuel-prices-api/internal/models"
 )
 
-func Import(dbPath string) error {
+// Import fetches from every data source, or only the named one, once. With
+// dryRun set, nothing is written: each batch is diffed against the database
+// instead, and a summary is printed at the end.
+func Import(ctx context.Context, dbPath, sourceName string, dryRun bool) error {
 
-	client, repo, err := bootstrap(dbPath, true, true)
+	repo, err := bootstrapRepository(dbPath, true)
 	if err != nil {
 		return err
 	}
@@ -17,17 +28,108 @@ func Import(dbPath string) error {
 		}
 	}()
 
-	numPFS, dropped, err := client.GetFillingStations(repo.InsertPFS)
+	sources, err := bootstrapSources()
 	if err != nil {
-		return fmt.Errorf("failed to fetch filling stations: %w", err)
+		return err
+	}
+
+	if sourceName == "" || sourceName == models.SourceFuelFinder {
+		client, err := importClient(ctx, repo, dryRun)
+		if err != nil {
+			return err
+		}
+		sources = append([]internal.DataSource{client}, sources...)
+	}
+
+	if sourceName != "" {
+		sources = slices.DeleteFunc(sources, func(source internal.DataSource) bool {
+			return source.Name() != sourceName
+		})
+		if len(sources) == 0 {
+			return fmt.Errorf("unknown data source: %s", sourceName)
+		}
 	}
-	log.Printf("imported %d filling stations (dropped: %d)", numPFS, dropped)
 
-	numPrices, dropped, err := client.GetFuelPrices(repo.InsertPrices)
+	threshold, err := internal.LoadInactiveThreshold()
 	if err != nil {
-		return fmt.Errorf("failed to fetch fuel prices: %w", err)
+		return err
+	}
+
+	diff := &models.ImportDiff{}
+	for _, source := range sources {
+		if dryRun {
+			if _, _, err := source.GetFillingStations(ctx, internal.DryRun(diff, repo.DiffPFS)); err != nil {
+				return fmt.Errorf("failed to fetch filling stations from %s: %w", source.Name(), err)
+			}
+			if _, _, err := source.GetFuelPrices(ctx, internal.DryRun(diff, repo.DiffPrices)); err != nil {
+				return fmt.Errorf("failed to fetch fuel prices from %s: %w", source.Name(), err)
+			}
+			continue
+		}
+
+		source := internal.TrackImports(source, repo, models.ImportTriggerCommand)
+		numPFS, dropped, err := internal.RefreshStations(ctx, source, repo, threshold)
+		if err != nil {
+			return fmt.Errorf("failed to fetch filling stations from %s: %w", source.Name(), err)
+		}
+		log.Printf("imported %d filling stations from %s (dropped: %d)", numPFS, source.Name(), dropped)
+
+		numPrices, dropped, err := source.GetFuelPrices(ctx, repo.InsertPrices)
+		if err != nil {
+			return fmt.Errorf("failed to fetch fuel prices from %s: %w", source.Name(), err)
+		}
+		log.Printf("imported %d fuel prices from %s (dropped: %d)", numPrices, source.Name(), dropped)
 	}
-	log.Printf("imported %d fuel prices (dropped: %d)", numPrices, dropped)
 
+	if dryRun {
+		return printImportDiff(os.Stdout, diff)
+	}
 	return nil
 }
+
+// importClient creates a GOV.UK client which always fetches everything. A
+// real import starts by resetting the fetch watermarks, and records new ones
+// as it goes; a dry run does neither, so its client has no watermark store.
+func importClient(ctx context.Context, repo internal.FuelPricesRepository, dryRun bool) (internal.FuelPricesClient, error) {
+	var watermarks internal.WatermarkStore
+	if !dryRun {
+		if err := repo.ResetFetchWatermarks(); err != nil {
+			return nil, err
+		}
+		watermarks = repo
+	}
+
+	client, err := internal.NewFuelPricesClient(ctx, os.Getenv("CLIENT_ID"), os.Getenv("CLIENT_SECRET"), true, watermarks)
+	if err != nil {
+		return nil, fmt.Errorf("GOV.UK authentication failed: %w", err)
+	}
+	return client, nil
+}
+
+func printImportDiff(out io.Writer, diff *models.ImportDiff) error {
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	row := func(label string, value int) {
+		_, _ = fmt.Fprintf(w, "%s\t%d\n", label, value)
+	}
+
+	_, _ = fmt.Fprint(out, "Dry run: no changes have been written\n\n")
+	row("New stations", diff.NewStations)
+	row("Changed stations", diff.ChangedStations)
+	attrs := make([]string, 0, len(diff.ChangedAttributes))
+	for attr := range diff.ChangedAttributes {
+		attrs = append(attrs, attr)
+	}
+	slices.Sort(attrs)
+	for _, attr := range attrs {
+		row("  "+attr, diff.ChangedAttributes[attr])
+	}
+	row("Unchanged stations", diff.UnchangedStations)
+	_, _ = fmt.Fprintln(w)
+	row("New price points", diff.NewPrices)
+	row("Changed price points", diff.ChangedPrices)
+	row("Unchanged price points", diff.UnchangedPrices)
+	row("Corrected prices", diff.CorrectedPrices)
+	row("Dropped prices", diff.DroppedPrices)
+
+	return w.Flush()
+}
@@ -0,0 +1,60 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/rm-hull/fuel-prices-api/internal"
+	"github.com/rm-hull/fuel-prices-api/internal/cma"
+)
+
+// ImportCMA imports retailer feeds in the CMA open-data format from local
+// files. It never talks to the GOV.UK API.
+func ImportCMA(ctx context.Context, dbPath, path string) error {
+	files, err := cma.Files(path)
+	if err != nil {
+		return fmt.Errorf("failed to find CMA feeds in %s: %w", path, err)
+	}
+	if len(files) == 0 {
+		return fmt.Errorf("no CMA feeds found in %s", path)
+	}
+
+	repo, err := bootstrapRepository(dbPath, false)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := repo.Close(); err != nil {
+			log.Printf("failed to close repository: %v", err)
+		}
+	}()
+
+	for _, file := range files {
+		feed, err := cma.Load(file)
+		if err != nil {
+			return err
+		}
+
+		source := cma.Source(file)
+		stations, prices, err := feed.ToModels(source)
+		if err != nil {
+			return fmt.Errorf("failed to map %s: %w", file, err)
+		}
+
+		numPFS, _, err := repo.InsertPFS(ctx, stations)
+		if err != nil {
+			return fmt.Errorf("failed to import filling stations from %s: %w", file, err)
+		}
+
+		info := internal.BatchInfo{Path: file, Number: 1, FetchedAt: time.Now()}
+		numPrices, dropped, err := repo.InsertPrices(internal.WithBatchInfo(ctx, info), prices)
+		if err != nil {
+			return fmt.Errorf("failed to import fuel prices from %s: %w", file, err)
+		}
+		log.Printf("imported %d filling stations and %d fuel prices (dropped: %d) from %s as %s", numPFS, numPrices, dropped, file, source)
+	}
+
+	return nil
+}
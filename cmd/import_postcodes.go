@@ -0,0 +1,73 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/rm-hull/fuel-prices-api/internal"
+	"github.com/rm-hull/fuel-prices-api/internal/models"
+)
+
+const postcodeBatchSize = 10_000
+
+// ImportPostcodes loads postcode centroids from the ONS Postcode Directory,
+// or any CSV with postcode, latitude and longitude columns, which station
+// coordinates are then validated against as they are imported.
+func ImportPostcodes(ctx context.Context, dbPath, path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open %s: %w", path, err)
+	}
+	defer func() {
+		if err := file.Close(); err != nil {
+			log.Printf("failed to close %s: %v", path, err)
+		}
+	}()
+
+	repo, err := bootstrapRepository(dbPath, false)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := repo.Close(); err != nil {
+			log.Printf("failed to close repository: %v", err)
+		}
+	}()
+
+	total, skipped := 0, 0
+	batch := make([]models.PostcodeCentroid, 0, postcodeBatchSize)
+	flush := func() error {
+		count, err := repo.InsertPostcodeCentroids(ctx, batch)
+		if err != nil {
+			return err
+		}
+		total += count
+		batch = batch[:0]
+		return nil
+	}
+
+	for record := range internal.ParseCSV(file, true, models.PostcodeCentroidFromCSV) {
+		if record.Error != nil {
+			return fmt.Errorf("failed to read %s: %w", path, record.Error)
+		}
+		if record.Value == nil {
+			skipped++
+			continue
+		}
+
+		batch = append(batch, *record.Value)
+		if len(batch) == postcodeBatchSize {
+			if err := flush(); err != nil {
+				return err
+			}
+		}
+	}
+	if err := flush(); err != nil {
+		return err
+	}
+
+	log.Printf("imported %d postcode centroids from %s (skipped %d without coordinates)", total, path, skipped)
+	return nil
+}
@@ -0,0 +1,48 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/rm-hull/fuel-prices-api/internal/mockupstream"
+)
+
+// MockUpstream serves an emulation of the GOV.UK Fuel Finder API, so that the
+// API server and import commands can be run locally against it by setting
+// FUEL_PRICES_API_BASE_URL. If no fixture file is given, numStations
+// stations are generated instead.
+func MockUpstream(ctx context.Context, port int, fixturePath string, numStations int, cfg mockupstream.Config) error {
+	var fixture *mockupstream.Fixture
+	if fixturePath != "" {
+		var err error
+		if fixture, err = mockupstream.LoadFixture(fixturePath); err != nil {
+			return err
+		}
+	} else {
+		fixture = mockupstream.Generate(numStations, cfg.Seed, time.Now())
+	}
+	log.Printf("Serving %d filling stations in batches of %d", len(fixture.Stations), cfg.BatchSize)
+
+	srv := &http.Server{
+		Addr:    fmt.Sprintf(":%d", port),
+		Handler: mockupstream.New(cfg, fixture).Handler(),
+	}
+
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := srv.Shutdown(shutdownCtx); err != nil {
+			log.Printf("failed to shut down mock upstream: %v", err)
+		}
+	}()
+
+	log.Printf("Starting mock upstream on port %d, set FUEL_PRICES_API_BASE_URL=http://localhost:%d to use it", port, port)
+	if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		return fmt.Errorf("mock upstream failed to start on port %d: %v", port, err)
+	}
+	return nil
+}
@@ -0,0 +1,23 @@
+package cmd
+
+import (
+	"context"
+	"log"
+
+	"github.com/rm-hull/fuel-prices-api/internal"
+)
+
+func Replay(ctx context.Context, dbPath, fromDir string) error {
+
+	repo, err := bootstrapRepository(dbPath, false)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := repo.Close(); err != nil {
+			log.Printf("failed to close repository: %v", err)
+		}
+	}()
+
+	return internal.Replay(ctx, fromDir, repo)
+}
@@ -0,0 +1,158 @@
+package archive
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"iter"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const fileSuffix = ".ndjson.gz"
+
+// maxRecordSize bounds a single NDJSON line, i.e. one raw upstream batch.
+const maxRecordSize = 64 * 1024 * 1024
+
+// Record is a single raw upstream batch response, as written to the archive.
+type Record struct {
+	Path        string          `json:"path"`
+	BatchNumber int             `json:"batch_number"`
+	FetchedAt   time.Time       `json:"fetched_at"`
+	Body        json.RawMessage `json:"body"`
+}
+
+// Writer appends raw batch responses for a single fetch run to a gzipped
+// NDJSON file, laid out as <dir>/<yyyy-mm-dd>/<path>.<timestamp>.ndjson.gz
+type Writer struct {
+	mu   sync.Mutex
+	path string
+	file *os.File
+	gz   *gzip.Writer
+	enc  *json.Encoder
+}
+
+func Create(dir, path string, started time.Time) (*Writer, error) {
+	started = started.UTC()
+	dayDir := filepath.Join(dir, started.Format(time.DateOnly))
+	if err := os.MkdirAll(dayDir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create archive directory %s: %w", dayDir, err)
+	}
+
+	filename := filepath.Join(dayDir, fileName(path, started))
+	file, err := os.OpenFile(filename, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create archive file %s: %w", filename, err)
+	}
+
+	gz := gzip.NewWriter(file)
+	log.Printf("Archiving raw %s responses to %s", path, filename)
+	return &Writer{
+		path: path,
+		file: file,
+		gz:   gz,
+		enc:  json.NewEncoder(gz),
+	}, nil
+}
+
+func (w *Writer) Write(batchNo int, body []byte) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return w.enc.Encode(Record{
+		Path:        w.path,
+		BatchNumber: batchNo,
+		FetchedAt:   time.Now().UTC(),
+		Body:        body,
+	})
+}
+
+func (w *Writer) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if err := w.gz.Close(); err != nil {
+		_ = w.file.Close()
+		return fmt.Errorf("failed to flush archive: %w", err)
+	}
+	return w.file.Close()
+}
+
+// Files returns every archive file under dir for the given upstream path, in
+// chronological order.
+func Files(dir, path string) ([]string, error) {
+	prefix := sanitise(path) + "."
+	files := make([]string, 0)
+
+	err := filepath.WalkDir(dir, func(filename string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		name := d.Name()
+		if !d.IsDir() && strings.HasPrefix(name, prefix) && strings.HasSuffix(name, fileSuffix) {
+			files = append(files, filename)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan archive directory %s: %w", dir, err)
+	}
+
+	sort.Strings(files)
+	return files, nil
+}
+
+// Read yields the records in an archive file in the order they were written.
+func Read(filename string) iter.Seq2[Record, error] {
+	return func(yield func(Record, error) bool) {
+		file, err := os.Open(filename)
+		if err != nil {
+			yield(Record{}, fmt.Errorf("failed to open archive %s: %w", filename, err))
+			return
+		}
+		defer func() {
+			if err := file.Close(); err != nil {
+				log.Printf("failed to close archive %s: %v", filename, err)
+			}
+		}()
+
+		gz, err := gzip.NewReader(file)
+		if err != nil {
+			yield(Record{}, fmt.Errorf("failed to decompress archive %s: %w", filename, err))
+			return
+		}
+
+		scanner := bufio.NewScanner(gz)
+		scanner.Buffer(make([]byte, 0, 1024*1024), maxRecordSize)
+		lineNum := 0
+		for scanner.Scan() {
+			lineNum++
+			var record Record
+			if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+				yield(Record{}, fmt.Errorf("failed to parse %s line %d: %w", filename, lineNum, err))
+				return
+			}
+			if !yield(record, nil) {
+				return
+			}
+		}
+		if err := scanner.Err(); err != nil {
+			yield(Record{}, fmt.Errorf("failed to read archive %s: %w", filename, err))
+		}
+	}
+}
+
+func fileName(path string, started time.Time) string {
+	return sanitise(path) + "." + started.Format("20060102T150405Z") + fileSuffix
+}
+
+func sanitise(path string) string {
+	return strings.ReplaceAll(strings.Trim(path, "/"), "/", "_")
+}
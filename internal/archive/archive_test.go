@@ -0,0 +1,65 @@
+package archive
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteAndRead(t *testing.T) {
+	dir := t.TempDir()
+	started := time.Date(2026, 3, 1, 9, 30, 0, 0, time.UTC)
+
+	w, err := Create(dir, "pfs/fuel-prices", started)
+	require.NoError(t, err)
+	require.NoError(t, w.Write(1, []byte(`[{"node_id":"1"}]`)))
+	require.NoError(t, w.Write(2, []byte(`[{"node_id":"2"}]`)))
+	require.NoError(t, w.Close())
+
+	expected := filepath.Join(dir, "2026-03-01", "pfs_fuel-prices.20260301T093000Z.ndjson.gz")
+	_, err = os.Stat(expected)
+	require.NoError(t, err)
+
+	var records []Record
+	for record, err := range Read(expected) {
+		require.NoError(t, err)
+		records = append(records, record)
+	}
+
+	require.Len(t, records, 2)
+	assert.Equal(t, "pfs/fuel-prices", records[0].Path)
+	assert.Equal(t, 1, records[0].BatchNumber)
+	assert.JSONEq(t, `[{"node_id":"1"}]`, string(records[0].Body))
+	assert.Equal(t, 2, records[1].BatchNumber)
+}
+
+func TestFiles(t *testing.T) {
+	dir := t.TempDir()
+
+	for _, started := range []time.Time{
+		time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC),
+		time.Date(2026, 3, 1, 18, 0, 0, 0, time.UTC),
+		time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC),
+	} {
+		for _, path := range []string{"pfs", "pfs/fuel-prices"} {
+			w, err := Create(dir, path, started)
+			require.NoError(t, err)
+			require.NoError(t, w.Close())
+		}
+	}
+
+	files, err := Files(dir, "pfs")
+	require.NoError(t, err)
+	require.Len(t, files, 3)
+	assert.Equal(t, "pfs.20260301T090000Z.ndjson.gz", filepath.Base(files[0]))
+	assert.Equal(t, "pfs.20260301T180000Z.ndjson.gz", filepath.Base(files[1]))
+	assert.Equal(t, "pfs.20260302T090000Z.ndjson.gz", filepath.Base(files[2]))
+
+	files, err = Files(dir, "pfs/fuel-prices")
+	require.NoError(t, err)
+	assert.Len(t, files, 3)
+}
@@ -0,0 +1,45 @@
+package internal
+
+import (
+	"context"
+	"time"
+)
+
+// BatchInfo identifies the batch of records being handed to a BatchCallback,
+// so that anything derived from it can be traced back to where it came from.
+type BatchInfo struct {
+	Path      string
+	Number    int
+	FetchedAt time.Time
+
+	// Full is set when the batch is part of a complete refresh of the
+	// source, rather than only what changed since the last fetch.
+	Full bool
+}
+
+type batchInfoKey struct{}
+
+// WithBatchInfo returns a copy of ctx carrying info.
+func WithBatchInfo(ctx context.Context, info BatchInfo) context.Context {
+	return context.WithValue(ctx, batchInfoKey{}, info)
+}
+
+// BatchInfoFrom returns the batch info carried by ctx, if any.
+func BatchInfoFrom(ctx context.Context) (BatchInfo, bool) {
+	info, ok := ctx.Value(batchInfoKey{}).(BatchInfo)
+	return info, ok
+}
+
+type fullRefreshKey struct{}
+
+// WithFullRefresh returns a copy of ctx asking the source to fetch everything
+// rather than only what changed since its watermark, so that stations which
+// have gone from upstream can be noticed.
+func WithFullRefresh(ctx context.Context) context.Context {
+	return context.WithValue(ctx, fullRefreshKey{}, true)
+}
+
+func isFullRefresh(ctx context.Context) bool {
+	full, _ := ctx.Value(fullRefreshKey{}).(bool)
+	return full
+}
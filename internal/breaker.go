@@ -0,0 +1,150 @@
+package internal
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen is returned instead of making a request while the circuit
+// breaker is open.
+var ErrCircuitOpen = errors.New("circuit breaker is open")
+
+type breakerState int
+
+const (
+	breakerClosed breakerState = iota
+	breakerHalfOpen
+	breakerOpen
+)
+
+func (state breakerState) String() string {
+	switch state {
+	case breakerHalfOpen:
+		return "half-open"
+	case breakerOpen:
+		return "open"
+	default:
+		return "closed"
+	}
+}
+
+// circuitBreaker stops requests being made to an upstream which keeps
+// failing. It opens after FailureThreshold consecutive transient failures,
+// and rejects every request until Cooldown has passed. It then half-opens,
+// letting a single probe request through: if that succeeds the breaker
+// closes again, otherwise it re-opens for another cooldown. A nil breaker
+// lets everything through.
+type circuitBreaker struct {
+	FailureThreshold int
+	Cooldown         time.Duration
+
+	mu       sync.Mutex
+	state    breakerState
+	failures int
+	openedAt time.Time
+	probing  bool
+	now      func() time.Time
+	onChange func(breakerState)
+}
+
+func newCircuitBreaker(onChange func(breakerState)) *circuitBreaker {
+	return &circuitBreaker{
+		FailureThreshold: 5,
+		Cooldown:         5 * time.Minute,
+		now:              time.Now,
+		onChange:         onChange,
+	}
+}
+
+func (breaker *circuitBreaker) State() breakerState {
+	if breaker == nil {
+		return breakerClosed
+	}
+
+	breaker.mu.Lock()
+	defer breaker.mu.Unlock()
+
+	return breaker.state
+}
+
+// allow returns ErrCircuitOpen if a request should not be made right now.
+func (breaker *circuitBreaker) allow() error {
+	if breaker == nil {
+		return nil
+	}
+
+	breaker.mu.Lock()
+	defer breaker.mu.Unlock()
+
+	switch breaker.state {
+	case breakerOpen:
+		if breaker.now().Sub(breaker.openedAt) < breaker.Cooldown {
+			return ErrCircuitOpen
+		}
+		breaker.transition(breakerHalfOpen)
+		breaker.probing = true
+		return nil
+	case breakerHalfOpen:
+		if breaker.probing {
+			return ErrCircuitOpen
+		}
+		breaker.probing = true
+		return nil
+	default:
+		return nil
+	}
+}
+
+// record updates the breaker with the outcome of a request that allow let
+// through. Only transient failures count against the upstream: any other
+// response shows that it is up.
+func (breaker *circuitBreaker) record(err error) {
+	if breaker == nil {
+		return
+	}
+
+	failed := false
+	if err != nil {
+		_, failed = retryReason(err)
+	}
+
+	breaker.mu.Lock()
+	defer breaker.mu.Unlock()
+
+	breaker.probing = false
+	if !failed {
+		breaker.failures = 0
+		breaker.transition(breakerClosed)
+		return
+	}
+
+	breaker.failures++
+	if breaker.state == breakerHalfOpen || breaker.failures >= breaker.FailureThreshold {
+		breaker.openedAt = breaker.now()
+		breaker.transition(breakerOpen)
+	}
+}
+
+// release gives up a request that allow let through without recording an
+// outcome, e.g. because it was cancelled by the caller.
+func (breaker *circuitBreaker) release() {
+	if breaker == nil {
+		return
+	}
+
+	breaker.mu.Lock()
+	defer breaker.mu.Unlock()
+
+	breaker.probing = false
+}
+
+func (breaker *circuitBreaker) transition(state breakerState) {
+	if breaker.state == state {
+		return
+	}
+	breaker.state = state
+	if breaker.onChange != nil {
+		breaker.onChange(state)
+	}
+}
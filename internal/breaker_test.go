@@ -0,0 +1,93 @@
+package internal
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCircuitBreaker_Transitions(t *testing.T) {
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	var changes []breakerState
+	breaker := newCircuitBreaker(func(state breakerState) { changes = append(changes, state) })
+	breaker.FailureThreshold = 2
+	breaker.Cooldown = time.Minute
+	breaker.now = func() time.Time { return now }
+
+	transient := &HTTPStatusError{StatusCode: http.StatusBadGateway}
+	notFound := &HTTPStatusError{StatusCode: http.StatusNotFound}
+
+	require.NoError(t, breaker.allow())
+	breaker.record(transient)
+	require.NoError(t, breaker.allow())
+	breaker.record(notFound) // upstream responded, so the failure count resets
+	assert.Equal(t, breakerClosed, breaker.State())
+
+	for range 2 {
+		require.NoError(t, breaker.allow())
+		breaker.record(transient)
+	}
+	assert.Equal(t, breakerOpen, breaker.State())
+	assert.ErrorIs(t, breaker.allow(), ErrCircuitOpen)
+
+	now = now.Add(time.Minute)
+	require.NoError(t, breaker.allow())
+	assert.Equal(t, breakerHalfOpen, breaker.State())
+	assert.ErrorIs(t, breaker.allow(), ErrCircuitOpen, "only a single probe is allowed")
+
+	breaker.record(errors.New("connection refused"))
+	assert.Equal(t, breakerOpen, breaker.State())
+	assert.ErrorIs(t, breaker.allow(), ErrCircuitOpen)
+
+	now = now.Add(time.Minute)
+	require.NoError(t, breaker.allow())
+	breaker.release()
+	require.NoError(t, breaker.allow(), "a released probe can be retried")
+	breaker.record(nil)
+	assert.Equal(t, breakerClosed, breaker.State())
+
+	assert.Equal(t, []breakerState{breakerOpen, breakerHalfOpen, breakerOpen, breakerHalfOpen, breakerClosed}, changes)
+}
+
+func TestCircuitBreaker_Nil(t *testing.T) {
+	var breaker *circuitBreaker
+	assert.NoError(t, breaker.allow())
+	breaker.record(errors.New("ignored"))
+	assert.Equal(t, breakerClosed, breaker.State())
+}
+
+func TestFetchBatched_CircuitBreakerStopsHammering(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	mgr := setupRetryingTestClient(t, server.URL)
+	mgr.retry.MaxAttempts = 10
+	mgr.breaker = newCircuitBreaker(func(state breakerState) {
+		mgr.metrics.RecordBreakerState(int(state))
+	})
+	mgr.breaker.FailureThreshold = 3
+
+	_, _, err := mgr.GetFuelPrices(t.Context(), nil)
+	require.ErrorIs(t, err, ErrCircuitOpen)
+	assert.Equal(t, int32(3), requests.Load())
+	assert.Equal(t, 2.0, testutil.ToFloat64(mgr.metrics.BreakerState))
+
+	_, _, err = mgr.GetFillingStations(t.Context(), nil)
+	require.ErrorIs(t, err, ErrCircuitOpen)
+	assert.Equal(t, int32(3), requests.Load(), "no further requests while open")
+
+	assert.True(t, mgr.Check().Pass())
+	assert.Contains(t, mgr.Check().Name(), "breaker: open")
+	assert.Contains(t, mgr.Check().Name(), "last fetch: never")
+}
@@ -0,0 +1,89 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+)
+
+// ErrUpstreamUnavailable is returned by fetches made while the client is
+// running in degraded mode, i.e. it has not yet authenticated with upstream.
+var ErrUpstreamUnavailable = errors.New("upstream unavailable")
+
+var errMissingCredentials = errors.New("no client credentials configured")
+
+// authRetryPolicy paces the background re-authentication attempts made while
+// the client is degraded. Only the delays are used.
+var authRetryPolicy = retryPolicy{
+	BaseDelay: 30 * time.Second,
+	MaxDelay:  15 * time.Minute,
+}
+
+// StartFuelPricesClient is like NewFuelPricesClient, but rather than failing
+// when upstream authentication does, it returns a client in degraded mode.
+// A degraded client refuses to fetch, and keeps retrying authentication in
+// the background until it succeeds or ctx is cancelled. Without credentials
+// the client stays degraded, which allows an existing database to be served
+// on its own.
+func StartFuelPricesClient(ctx context.Context, clientId, clientSecret string, fullRefresh bool, watermarks WatermarkStore) (FuelPricesClient, error) {
+	mgr, err := newFuelPricesManager(clientId, clientSecret, fullRefresh, watermarks)
+	if err != nil {
+		return nil, err
+	}
+
+	if clientId == "" || clientSecret == "" {
+		mgr.setAuthErr(errMissingCredentials)
+		log.Printf("WARNING: %v, running in degraded mode without upstream fetches", errMissingCredentials)
+		return mgr, nil
+	}
+
+	if err := mgr.authenticate(ctx); err != nil {
+		mgr.setAuthErr(err)
+		log.Printf("WARNING: failed to authenticate, running in degraded mode: %v", err)
+		go mgr.retryAuthentication(ctx)
+	}
+
+	return mgr, nil
+}
+
+func (mgr *fuelPricesManager) retryAuthentication(ctx context.Context) {
+	for attempt := 1; ; attempt++ {
+		delay := authRetryPolicy.backoff(attempt)
+		log.Printf("Retrying authentication in %s (attempt %d)", delay, attempt)
+		if err := sleep(ctx, delay); err != nil {
+			return
+		}
+
+		err := mgr.authenticate(ctx)
+		if err == nil {
+			log.Printf("Recovered from degraded mode after %d attempts", attempt)
+			return
+		}
+		if ctx.Err() != nil {
+			return
+		}
+		mgr.setAuthErr(err)
+		log.Printf("WARNING: authentication retry failed: %v", err)
+	}
+}
+
+func (mgr *fuelPricesManager) setAuthErr(err error) {
+	mgr.mu.Lock()
+	defer mgr.mu.Unlock()
+
+	mgr.authErr = err
+}
+
+// upstreamErr returns a non-nil error wrapping ErrUpstreamUnavailable while
+// the client is degraded.
+func (mgr *fuelPricesManager) upstreamErr() error {
+	mgr.mu.RLock()
+	defer mgr.mu.RUnlock()
+
+	if mgr.authErr == nil {
+		return nil
+	}
+	return fmt.Errorf("%w: %v", ErrUpstreamUnavailable, mgr.authErr)
+}
@@ -0,0 +1,84 @@
+package internal
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/rm-hull/fuel-prices-api/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStartFuelPricesClient_MissingCredentials(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+	}))
+	defer server.Close()
+
+	t.Setenv("FUEL_PRICES_API_BASE_URL", server.URL)
+	lastFetch := time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+	store := &stubWatermarkStore{watermarks: map[string]time.Time{pricesPath: lastFetch}}
+
+	client, err := StartFuelPricesClient(t.Context(), "", "", false, store)
+	require.NoError(t, err)
+	assert.True(t, client.LastUpdated().Equal(lastFetch))
+
+	_, _, err = client.GetFuelPrices(t.Context(), nil)
+	assert.ErrorIs(t, err, ErrUpstreamUnavailable)
+	assert.True(t, client.Check().Pass())
+	assert.Contains(t, client.Check().Name(), "upstream: degraded")
+	assert.Zero(t, requests.Load())
+}
+
+func TestStartFuelPricesClient_RecoversInBackground(t *testing.T) {
+	defer func(policy retryPolicy) { authRetryPolicy = policy }(authRetryPolicy)
+	authRetryPolicy = retryPolicy{BaseDelay: time.Millisecond, MaxDelay: 5 * time.Millisecond}
+
+	var authAttempts atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/oauth/generate_access_token":
+			if authAttempts.Add(1) <= 3 {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			_ = json.NewEncoder(w).Encode(models.AuthResponse{
+				Success: true,
+				Data:    models.TokenData{AccessToken: "token", ExpiresIn: 3600},
+			})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	t.Setenv("FUEL_PRICES_API_BASE_URL", server.URL)
+
+	client, err := StartFuelPricesClient(t.Context(), "id", "secret", false, nil)
+	require.NoError(t, err)
+
+	assert.Eventually(t, func() bool {
+		return strings.HasPrefix(client.Check().Name(), "upstream: ok")
+	}, 5*time.Second, 5*time.Millisecond)
+
+	_, _, err = client.GetFuelPrices(t.Context(), nil)
+	assert.NoError(t, err)
+	assert.Equal(t, int32(4), authAttempts.Load())
+}
+
+func TestNewFuelPricesClient_FailsWithoutDegradedMode(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer server.Close()
+
+	t.Setenv("FUEL_PRICES_API_BASE_URL", server.URL)
+
+	_, err := NewFuelPricesClient(t.Context(), "id", "secret", false, nil)
+	assert.Error(t, err)
+}
@@ -2,6 +2,7 @@ package internal
 
 import (
 	"bytes"
+	"context"
 	"errors"
 	"fmt"
 	"io"
@@ -11,13 +12,16 @@ import (
 	"os"
 	"strconv"
 	"strings"
-
-	// neturl "net/url"
+	"sync"
 	"time"
 
 	"github.com/prometheus/client_golang/prometheus"
+	"github.com/rm-hull/fuel-prices-api/internal/archive"
 	"github.com/rm-hull/fuel-prices-api/internal/metrics"
 	"github.com/rm-hull/fuel-prices-api/internal/models"
+	"github.com/rm-hull/fuel-prices-api/internal/schema"
+	"github.com/tavsec/gin-healthcheck/checks"
+	"golang.org/x/sync/singleflight"
 )
 
 // HTTPStatusError is returned when the remote server responds with a non-2xx status.
@@ -48,14 +52,43 @@ func (e *HTTPStatusError) Error() string {
 	return fmt.Sprintf("unexpected http response (%s) from %s, body: %s", e.Status, e.URL, body)
 }
 
-type BatchCallback[T any] func([]T) (int, int, error)
+type BatchCallback[T any] func(context.Context, []T) (int, int, error)
 
 type FuelPricesClient interface {
-	GetFuelPrices(BatchCallback[models.ForecourtPrices]) (int, int, error)
-	GetFillingStations(BatchCallback[models.PetrolFillingStation]) (int, int, error)
+	DataSource
 	LastUpdated() *time.Time
+	Check() checks.Check
+	SchemaDrift() models.SchemaDriftReport
+}
+
+// WatermarkStore persists the time of the last successful fetch for each
+// upstream path, so that incremental fetches survive restarts. A watermark
+// only ever advances: saving an earlier time than the stored one, as an
+// overlapping fetch which started first but finished last would, is ignored.
+type WatermarkStore interface {
+	FetchWatermarks() (map[string]time.Time, error)
+	SaveFetchWatermark(path string, fetchedAt time.Time) error
 }
 
+const (
+	pfsPath    = "pfs"
+	pricesPath = "pfs/fuel-prices"
+)
+
+// schemaSpecs describe the records expected from each upstream path when
+// strict decoding is enabled.
+var schemaSpecs = map[string]schema.Spec{
+	pfsPath:    schema.SpecFor[models.PetrolFillingStation]("node_id", "location.latitude", "location.longitude"),
+	pricesPath: schema.SpecFor[models.ForecourtPrices]("node_id", "fuel_prices[].fuel_type"),
+}
+
+const (
+	// requestTimeout bounds a single HTTP round trip, including reading the body.
+	requestTimeout = 2 * time.Minute
+	// runTimeout bounds an entire batched fetch, including the batch callbacks.
+	runTimeout = 45 * time.Minute
+)
+
 type timeTracker struct {
 	started         time.Time
 	lastAuth        time.Time
@@ -63,80 +96,216 @@ type timeTracker struct {
 	lastPricesFetch time.Time
 }
 
+// fuelPricesManager is shared by the independently scheduled PFS and price
+// fetches, so the token and time tracking state is guarded by mu, and token
+// refreshes are funnelled through a single flight.
 type fuelPricesManager struct {
 	baseUrl     string
 	authReq     models.AuthRequest
+	mu          sync.RWMutex
 	tokenData   models.TokenData
 	timeTracker timeTracker
+	authErr     error
+	refresh     singleflight.Group
 	client      *http.Client
+	retry       retryPolicy
+	breaker     *circuitBreaker
+	watermarks  WatermarkStore
 	metrics     *metrics.ClientFetchMetrics
 	fullRefresh bool
+	runTimeout  time.Duration
+	concurrency int
+	archiveDir  string
+	drift       *schema.Tracker
+}
+
+func NewFuelPricesClient(ctx context.Context, clientId, clientSecret string, fullRefresh bool, watermarks WatermarkStore) (FuelPricesClient, error) {
+	mgr, err := newFuelPricesManager(clientId, clientSecret, fullRefresh, watermarks)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := mgr.authenticate(ctx); err != nil {
+		return nil, fmt.Errorf("failed to authenticate: %v", err)
+	}
+
+	return mgr, nil
 }
 
-func NewFuelPricesClient(clientId, clientSecret string, fullRefresh bool) (FuelPricesClient, error) {
+func newFuelPricesManager(clientId, clientSecret string, fullRefresh bool, watermarks WatermarkStore) (*fuelPricesManager, error) {
 	baseUrl := "https://www.fuel-finder.service.gov.uk/api/v1"
 	if envBaseUrl := os.Getenv("FUEL_PRICES_API_BASE_URL"); envBaseUrl != "" {
 		baseUrl = envBaseUrl
 	}
 
+	concurrency := 1
+	if envConcurrency := os.Getenv("FUEL_PRICES_FETCH_CONCURRENCY"); envConcurrency != "" {
+		n, err := strconv.Atoi(envConcurrency)
+		if err != nil || n < 1 {
+			return nil, fmt.Errorf("invalid FUEL_PRICES_FETCH_CONCURRENCY value: %q", envConcurrency)
+		}
+		concurrency = n
+	}
+
+	var drift *schema.Tracker
+	if envStrict := os.Getenv("FUEL_PRICES_STRICT_DECODING"); envStrict != "" {
+		strict, err := strconv.ParseBool(envStrict)
+		if err != nil {
+			return nil, fmt.Errorf("invalid FUEL_PRICES_STRICT_DECODING value: %q", envStrict)
+		}
+		if strict {
+			drift = schema.NewTracker()
+		}
+	}
+
 	mgr := &fuelPricesManager{
 		baseUrl: baseUrl,
 		timeTracker: timeTracker{
 			started: time.Now(),
 		},
 		fullRefresh: fullRefresh,
-		client:      &http.Client{},
+		runTimeout:  runTimeout,
+		concurrency: concurrency,
+		archiveDir:  os.Getenv("FUEL_PRICES_ARCHIVE_DIR"),
+		drift:       drift,
+		client:      &http.Client{Timeout: requestTimeout},
+		retry:       defaultRetryPolicy(),
+		watermarks:  watermarks,
 		authReq: models.AuthRequest{
 			ClientId:     clientId,
 			ClientSecret: clientSecret,
 		},
 		metrics: metrics.NewClientFetchMetrics(prometheus.DefaultRegisterer),
 	}
+	mgr.breaker = newCircuitBreaker(func(state breakerState) {
+		log.Printf("Upstream circuit breaker is now %s", state)
+		mgr.metrics.RecordBreakerState(int(state))
+	})
 
-	err := mgr.authenticate()
-	if err != nil {
-		return nil, fmt.Errorf("failed to authenticate: %v", err)
+	if err := mgr.loadWatermarks(); err != nil {
+		return nil, err
 	}
 
 	return mgr, nil
 }
 
+func (mgr *fuelPricesManager) loadWatermarks() error {
+	if mgr.watermarks == nil {
+		return nil
+	}
+
+	watermarks, err := mgr.watermarks.FetchWatermarks()
+	if err != nil {
+		return fmt.Errorf("failed to load fetch watermarks: %w", err)
+	}
+
+	mgr.setLastFetch(pfsPath, watermarks[pfsPath])
+	mgr.setLastFetch(pricesPath, watermarks[pricesPath])
+	for path, lastFetch := range watermarks {
+		log.Printf("Resuming incremental fetches for %s from %s", path, lastFetch.Format(time.RFC3339))
+	}
+	return nil
+}
+
+func (mgr *fuelPricesManager) Name() string {
+	return models.SourceFuelFinder
+}
+
 func (mgr *fuelPricesManager) LastUpdated() *time.Time {
-	if mgr.timeTracker.lastPricesFetch.IsZero() {
+	lastPricesFetch := mgr.lastFetch(pricesPath)
+	if lastPricesFetch.IsZero() {
 		return nil
 	}
-	return &mgr.timeTracker.lastPricesFetch
+	return &lastPricesFetch
 }
 
-func (mgr *fuelPricesManager) GetFuelPrices(callback BatchCallback[models.ForecourtPrices]) (int, int, error) {
-	decode := func(body io.ReadCloser, batchNo int) ([]models.ForecourtPrices, error) {
-		var resp []models.ForecourtPrices
-		decoder := json.NewDecoder(body)
-		if err := decoder.Decode(&resp); err != nil {
-			return nil, fmt.Errorf("failed to unmarshal response: %w", err)
-		}
-		return resp, nil
+func (mgr *fuelPricesManager) lastFetch(path string) time.Time {
+	mgr.mu.RLock()
+	defer mgr.mu.RUnlock()
+
+	if path == pfsPath {
+		return mgr.timeTracker.lastPfsFetch
 	}
+	return mgr.timeTracker.lastPricesFetch
+}
 
-	return fetchBatched(mgr, "pfs/fuel-prices", &mgr.timeTracker.lastPricesFetch, decode, callback)
+// setLastFetch advances the watermark for path, unless it is already later,
+// so that overlapping fetches finishing out of order cannot move it back.
+func (mgr *fuelPricesManager) setLastFetch(path string, fetchedAt time.Time) {
+	mgr.mu.Lock()
+	defer mgr.mu.Unlock()
+
+	lastFetch := &mgr.timeTracker.lastPricesFetch
+	if path == pfsPath {
+		lastFetch = &mgr.timeTracker.lastPfsFetch
+	}
+	if fetchedAt.After(*lastFetch) {
+		*lastFetch = fetchedAt
+	}
+}
+
+func (mgr *fuelPricesManager) accessToken() string {
+	mgr.mu.RLock()
+	defer mgr.mu.RUnlock()
+
+	return mgr.tokenData.AccessToken
 }
 
-func (mgr *fuelPricesManager) GetFillingStations(callback BatchCallback[models.PetrolFillingStation]) (int, int, error) {
-	decode := func(body io.ReadCloser, batchNo int) ([]models.PetrolFillingStation, error) {
-		var resp []models.PetrolFillingStation
-		decoder := json.NewDecoder(body)
-		if err := decoder.Decode(&resp); err != nil {
-			return nil, fmt.Errorf("failed to unmarshal response: %w", err)
+func (mgr *fuelPricesManager) SchemaDrift() models.SchemaDriftReport {
+	if mgr.drift == nil {
+		return models.SchemaDriftReport{Drift: []models.SchemaDrift{}}
+	}
+	return models.SchemaDriftReport{Enabled: true, Drift: mgr.drift.Report()}
+}
+
+// inspectSchema runs the strict decoding pass over a raw batch, if enabled.
+// It never fails the fetch: drift is only ever recorded and reported.
+func (mgr *fuelPricesManager) inspectSchema(path string, batchNo int, raw []byte) {
+	if mgr.drift == nil {
+		return
+	}
+
+	issues, err := schema.Inspect(raw, schemaSpecs[path])
+	if err != nil {
+		log.Printf("WARNING: failed to inspect schema of %s batch %d: %v", path, batchNo, err)
+		return
+	}
+	for issue, occurrences := range issues {
+		mgr.metrics.RecordSchemaDrift(path, string(issue.Kind), issue.Field, occurrences)
+	}
+	mgr.drift.Record(path, batchNo, issues)
+}
+
+func (mgr *fuelPricesManager) GetFuelPrices(ctx context.Context, callback BatchCallback[models.ForecourtPrices]) (int, int, error) {
+	return fetchBatched(ctx, mgr, pricesPath, decodeBatch[models.ForecourtPrices], callback)
+}
+
+func (mgr *fuelPricesManager) GetFillingStations(ctx context.Context, callback BatchCallback[models.PetrolFillingStation]) (int, int, error) {
+	return fetchBatched(ctx, mgr, pfsPath, decodeBatch[models.PetrolFillingStation], callback)
+}
+
+// decodeBatch decodes a single batch response. Upstream may either return a
+// bare JSON array, or an envelope carrying the batch metadata alongside the data.
+func decodeBatch[T any](raw []byte, batchNo int) ([]T, *models.MetaData, error) {
+	raw = bytes.TrimSpace(raw)
+	if len(raw) > 0 && raw[0] == '{' {
+		var resp models.BatchResponse[T]
+		if err := json.Unmarshal(raw, &resp); err != nil {
+			return nil, nil, fmt.Errorf("failed to unmarshal response: %w", err)
 		}
-		return resp, nil
+		return resp.Data, resp.MetaData, nil
 	}
 
-	return fetchBatched(mgr, "pfs", &mgr.timeTracker.lastPfsFetch, decode, callback)
+	var resp []T
+	if err := json.Unmarshal(raw, &resp); err != nil {
+		return nil, nil, fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+	return resp, nil, nil
 }
 
-func (mgr *fuelPricesManager) authenticate() error {
+func (mgr *fuelPricesManager) authenticate(ctx context.Context) error {
 	url := fmt.Sprintf("%s/oauth/generate_access_token", mgr.baseUrl)
-	body, err := mgr.post(url, "application/json", mgr.authReq)
+	body, err := mgr.post(ctx, url, "application/json", mgr.authReq, nil)
 	if err != nil {
 		return err
 	}
@@ -155,27 +324,34 @@ func (mgr *fuelPricesManager) authenticate() error {
 		return fmt.Errorf("authentication failed: %s", resp.Message)
 	}
 
+	mgr.mu.Lock()
 	mgr.tokenData = resp.Data
 	mgr.timeTracker.lastAuth = time.Now()
+	mgr.authErr = nil
+	mgr.mu.Unlock()
 	log.Printf("Authenticated successfully, token expires in %d seconds", resp.Data.ExpiresIn)
 
 	return nil
 }
 
-func (mgr *fuelPricesManager) tokenRefresh() error {
+func (mgr *fuelPricesManager) tokenRefresh(ctx context.Context) error {
 
+	mgr.mu.RLock()
 	tokenReq := models.TokenRefreshRequest{
 		ClientId:     mgr.authReq.ClientId,
 		RefreshToken: mgr.tokenData.RefreshToken,
 	}
+	mgr.mu.RUnlock()
 	url := fmt.Sprintf("%s/oauth/regenerate_access_token", mgr.baseUrl)
-	body, err := mgr.post(url, "application/json", tokenReq)
+	// A failed refresh falls back to re-authenticating, so there is no point
+	// spending retries on it: an empty budget disables them.
+	body, err := mgr.post(ctx, url, "application/json", tokenReq, &retryBudget{})
 	if err != nil {
 		var stErr *HTTPStatusError
 		if errors.As(err, &stErr) && stErr.StatusCode >= http.StatusInternalServerError {
 			log.Printf("Failed to refresh access token: %v", err)
 			log.Printf("Trying to recover from token refresh error response (HTTP %d)...", stErr.StatusCode)
-			return mgr.authenticate()
+			return mgr.authenticate(ctx)
 		}
 		return err
 	}
@@ -194,151 +370,356 @@ func (mgr *fuelPricesManager) tokenRefresh() error {
 		return fmt.Errorf("authentication failed: %s", resp.Message)
 	}
 
+	mgr.mu.Lock()
 	mgr.tokenData.AccessToken = resp.Data.AccessToken
 	mgr.tokenData.ExpiresIn = resp.Data.ExpiresIn
 	mgr.timeTracker.lastAuth = time.Now()
-	log.Printf("Token refresh completed successfully, token expires in %d seconds", mgr.tokenData.ExpiresIn)
+	mgr.mu.Unlock()
+	log.Printf("Token refresh completed successfully, token expires in %d seconds", resp.Data.ExpiresIn)
 
 	return nil
 }
 
-func (mgr *fuelPricesManager) checkTokenExpiry() error {
+func (mgr *fuelPricesManager) tokenExpiresSoon() bool {
+	mgr.mu.RLock()
+	defer mgr.mu.RUnlock()
+
 	expiryTime := mgr.timeTracker.lastAuth.Add(time.Duration(mgr.tokenData.ExpiresIn) * time.Second)
-	expiresSoon := time.Until(expiryTime) < 5*time.Minute
+	return time.Until(expiryTime) < 5*time.Minute
+}
+
+// checkTokenExpiry refreshes the access token if it is about to expire.
+// Concurrent callers share a single refresh, which is detached from any one
+// caller's context so that it cannot be cut short by another caller giving up.
+func (mgr *fuelPricesManager) checkTokenExpiry(ctx context.Context) error {
+	if !mgr.tokenExpiresSoon() {
+		return nil
+	}
 
-	if expiresSoon {
+	ch := mgr.refresh.DoChan("token", func() (any, error) {
+		// Another caller may have completed a refresh between our check and
+		// this flight starting.
+		if !mgr.tokenExpiresSoon() {
+			return nil, nil
+		}
 		log.Printf("Access token has either expired or is expiring soon, refreshing...")
-		if err := mgr.tokenRefresh(); err != nil {
-			return fmt.Errorf("failed to refresh token: %w", err)
+		return nil, mgr.tokenRefresh(context.WithoutCancel(ctx))
+	})
+
+	select {
+	case <-ctx.Done():
+		return fmt.Errorf("failed to refresh token: %w", ctx.Err())
+	case result := <-ch:
+		if result.Err != nil {
+			return fmt.Errorf("failed to refresh token: %w", result.Err)
 		}
+		return nil
 	}
-	return nil
 }
 
-func (mgr *fuelPricesManager) getEffectiveStartTimestamp(path string, lastFetch *time.Time) string {
+func (mgr *fuelPricesManager) getEffectiveStartTimestamp(path string, lastFetch time.Time) string {
 
-	if lastFetch == nil || lastFetch.IsZero() || mgr.fullRefresh {
+	if lastFetch.IsZero() || mgr.fullRefresh {
 		return ""
 	}
 
-	log.Printf("Time since last fetch for %s: %s", path, time.Since(*lastFetch))
-	return lastFetch.Format("2006-01-02 15:04:05") // Not quite RFC3339 ...
+	log.Printf("Time since last fetch for %s: %s", path, time.Since(lastFetch))
+	// Not quite RFC3339, and without a zone, so it must be given in UTC.
+	return lastFetch.UTC().Format("2006-01-02 15:04:05")
+}
+
+// batch is a single decoded upstream batch, along with the raw response it was
+// decoded from.
+type batch[T any] struct {
+	number   int
+	raw      []byte
+	data     []T
+	metaData *models.MetaData
 }
 
 func fetchBatched[T any](
+	ctx context.Context,
 	mgr *fuelPricesManager,
 	path string,
-	lastFetch *time.Time,
-	decode func(io.ReadCloser, int) ([]T, error),
+	decode func([]byte, int) ([]T, *models.MetaData, error),
 	callback BatchCallback[T],
 ) (int, int, error) {
-	if err := mgr.checkTokenExpiry(); err != nil {
+	if mgr.runTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, mgr.runTimeout)
+		defer cancel()
+	}
+
+	if err := mgr.upstreamErr(); err != nil {
+		return 0, 0, err
+	}
+
+	if err := mgr.checkTokenExpiry(ctx); err != nil {
 		return 0, 0, err
 	}
 
-	batchNo := 1
 	count := 0
 	totalDropped := 0
 
-	startTime := time.Now()
-	effectiveStartTimestamp := mgr.getEffectiveStartTimestamp(path, lastFetch)
+	startTime := time.Now().UTC()
+	budget := mgr.retry.newBudget()
+	effectiveStartTimestamp := ""
+	if !isFullRefresh(ctx) {
+		effectiveStartTimestamp = mgr.getEffectiveStartTimestamp(path, mgr.lastFetch(path))
+	}
+
+	var archiver *archive.Writer
+	if mgr.archiveDir != "" {
+		var err error
+		if archiver, err = archive.Create(mgr.archiveDir, path, startTime); err != nil {
+			log.Printf("WARNING: raw responses for %s will not be archived: %v", path, err)
+		} else {
+			defer func() {
+				if err := archiver.Close(); err != nil {
+					log.Printf("WARNING: failed to close archive for %s: %v", path, err)
+				}
+			}()
+		}
+	}
 
-	for {
+	fetch := func(ctx context.Context, batchNo int) (*batch[T], error) {
 		params := neturl.Values{}
 		params.Add("batch-number", strconv.Itoa(batchNo))
 		if effectiveStartTimestamp != "" {
 			params.Add("effective-start-timestamp", effectiveStartTimestamp)
 		}
 		url := fmt.Sprintf("%s/%s?%s", mgr.baseUrl, path, params.Encode())
-		body, err := mgr.get(url)
+		body, err := mgr.get(ctx, url, budget)
 		if err != nil {
-			var stErr *HTTPStatusError
-			if errors.As(err, &stErr) && stErr.StatusCode == http.StatusNotFound {
-				log.Printf("No more batches available for %s, stopping at batch %d", path, batchNo-1)
-				break
-			}
-			return 0, 0, err
+			return nil, err
 		}
+		defer func() {
+			_ = body.Close()
+		}()
 
-		data, err := decode(body, batchNo)
+		raw, err := io.ReadAll(body)
 		if err != nil {
-			_ = body.Close()
-			return 0, 0, err
+			return nil, fmt.Errorf("failed to read response for batch %d: %w", batchNo, err)
 		}
-		_ = body.Close()
+		mgr.inspectSchema(path, batchNo, raw)
+		data, metaData, err := decode(raw, batchNo)
+		if err != nil {
+			return nil, err
+		}
+		return &batch[T]{number: batchNo, raw: raw, data: data, metaData: metaData}, nil
+	}
 
-		numRecords, dropped, err := callback(data)
+	apply := func(ctx context.Context, b *batch[T]) (int, error) {
+		if archiver != nil {
+			if err := archiver.Write(b.number, b.raw); err != nil {
+				log.Printf("WARNING: failed to archive batch %d for %s: %v", b.number, path, err)
+			}
+		}
+
+		info := BatchInfo{Path: path, Number: b.number, FetchedAt: startTime, Full: effectiveStartTimestamp == ""}
+		numRecords, dropped, err := callback(WithBatchInfo(ctx, info), b.data)
 		if err != nil {
-			return 0, 0, fmt.Errorf("callback error: %w", err)
+			return 0, fmt.Errorf("callback error: %w", err)
 		}
 		mgr.metrics.RecordFetchedItems(path, numRecords, dropped)
 		count += numRecords
 		totalDropped += dropped
-		batchNo++
+		return numRecords, nil
+	}
+
+	for batchNo := 1; ; batchNo++ {
+		b, err := fetch(ctx, batchNo)
+		if isNotFound(err) {
+			log.Printf("No more batches available for %s, stopping at batch %d", path, batchNo-1)
+			break
+		} else if err != nil {
+			return 0, 0, err
+		}
+
+		numRecords, err := apply(ctx, b)
+		if err != nil {
+			return 0, 0, err
+		}
 
 		if numRecords == 0 {
 			break
 		}
+
+		if batchNo == 1 && mgr.concurrency > 1 && b.metaData != nil && b.metaData.TotalBatches > 1 {
+			totalBatches := b.metaData.TotalBatches
+			log.Printf("Fetching remaining %d batches for %s using %d workers", totalBatches-1, path, mgr.concurrency)
+			if err := fetchConcurrently(ctx, mgr.concurrency, 2, totalBatches, fetch, apply); err != nil {
+				return 0, 0, err
+			}
+			break
+		}
 	}
 
-	*lastFetch = startTime
+	mgr.setLastFetch(path, startTime)
+	if mgr.watermarks != nil {
+		if err := mgr.watermarks.SaveFetchWatermark(path, startTime); err != nil {
+			log.Printf("WARNING: failed to persist fetch watermark for %s: %v", path, err)
+		}
+	}
 	return count, totalDropped, nil
 }
 
-func (mgr *fuelPricesManager) get(url string) (io.ReadCloser, error) {
-	start := time.Now()
-	log.Printf("GET %s", url)
-	req, err := http.NewRequest("GET", url, nil)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
+// fetchConcurrently fetches batches first..last with a bounded pool of workers,
+// but applies them strictly in batch order so that inserts stay deterministic.
+// A worker slot is only released once its batch has been applied, which also
+// bounds the number of decoded batches held in memory at any one time.
+func fetchConcurrently[T any](
+	ctx context.Context,
+	workers, first, last int,
+	fetch func(context.Context, int) (*batch[T], error),
+	apply func(context.Context, *batch[T]) (int, error),
+) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	type result struct {
+		batch *batch[T]
+		err   error
 	}
-	req.Header.Set("Authorization", "Bearer "+mgr.tokenData.AccessToken)
-	req.Header.Set("Accept", "application/json")
 
-	resp, err := mgr.client.Do(req)
-	mgr.metrics.RecordHttpCall(start, "GET", url, resp, err)
-
-	if err != nil {
-		return nil, fmt.Errorf("failed to fetch from %s: %w", url, err)
+	results := make([]chan result, last+1)
+	for batchNo := first; batchNo <= last; batchNo++ {
+		results[batchNo] = make(chan result, 1)
 	}
 
-	if resp.StatusCode >= http.StatusBadRequest {
-		bodyBytes, readErr := io.ReadAll(resp.Body)
-		if readErr != nil {
-			bodyBytes = fmt.Appendf(nil, "<failed to read body: %v>", readErr)
+	slots := make(chan struct{}, workers)
+	go func() {
+		for batchNo := first; batchNo <= last; batchNo++ {
+			select {
+			case slots <- struct{}{}:
+			case <-ctx.Done():
+				return
+			}
+			go func() {
+				b, err := fetch(ctx, batchNo)
+				results[batchNo] <- result{batch: b, err: err}
+			}()
 		}
-		_ = resp.Body.Close()
+	}()
 
-		return nil, &HTTPStatusError{
-			URL:        url,
-			Status:     resp.Status,
-			StatusCode: resp.StatusCode,
-			Body:       string(bodyBytes),
+	for batchNo := first; batchNo <= last; batchNo++ {
+		var r result
+		select {
+		case r = <-results[batchNo]:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		<-slots
+
+		if isNotFound(r.err) {
+			log.Printf("Upstream reported %d batches, but batch %d was not found; stopping", last, batchNo)
+			return nil
+		} else if r.err != nil {
+			return r.err
+		}
+
+		if _, err := apply(ctx, r.batch); err != nil {
+			return err
 		}
 	}
-	return resp.Body, nil
+	return nil
 }
 
-func (mgr *fuelPricesManager) post(url, contentType string, data any) (io.ReadCloser, error) {
-	start := time.Now()
+func isNotFound(err error) bool {
+	var stErr *HTTPStatusError
+	return errors.As(err, &stErr) && stErr.StatusCode == http.StatusNotFound
+}
+
+func (mgr *fuelPricesManager) get(ctx context.Context, url string, budget *retryBudget) (io.ReadCloser, error) {
+	log.Printf("GET %s", url)
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+mgr.accessToken())
+	req.Header.Set("Accept", "application/json")
+
+	return mgr.do(req, budget)
+}
+
+func (mgr *fuelPricesManager) post(ctx context.Context, url, contentType string, data any, budget *retryBudget) (io.ReadCloser, error) {
 	log.Printf("POST %s", url)
 	jsonData, err := json.Marshal(data)
 	if err != nil {
 		return nil, fmt.Errorf("failed to marshal request body: %w", err)
 	}
 
-	req, err := http.NewRequest("POST", url, bytes.NewBuffer(jsonData))
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(jsonData))
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
 	req.Header.Set("Content-Type", contentType)
 	req.Header.Set("Accept", "application/json")
 
+	return mgr.do(req, budget)
+}
+
+// do performs the request, retrying transient failures according to the
+// manager's retry policy, and returns the response body on success. Every
+// attempt has to get past the circuit breaker first.
+func (mgr *fuelPricesManager) do(req *http.Request, budget *retryBudget) (io.ReadCloser, error) {
+	url := req.URL.String()
+	for attempt := 1; ; attempt++ {
+		if err := mgr.breaker.allow(); err != nil {
+			return nil, fmt.Errorf("not sending %s %s: %w", req.Method, url, err)
+		}
+
+		body, retryAfter, err := mgr.attempt(req)
+		if req.Context().Err() != nil {
+			mgr.breaker.release()
+		} else {
+			mgr.breaker.record(err)
+		}
+		if err == nil {
+			return body, nil
+		}
+
+		reason, retryable := retryReason(err)
+		if !retryable || attempt >= mgr.retry.MaxAttempts || req.Context().Err() != nil {
+			return nil, err
+		}
+
+		delay := mgr.retry.backoff(attempt)
+		if retryAfter > mgr.retry.MaxRetryAfter {
+			log.Printf("Not retrying %s %s: Retry-After of %s exceeds limit", req.Method, url, retryAfter)
+			return nil, err
+		} else if retryAfter > 0 {
+			delay = retryAfter
+		}
+
+		if !budget.take() {
+			log.Printf("Not retrying %s %s: retry budget for this run is exhausted", req.Method, url)
+			return nil, err
+		}
+
+		if req.GetBody != nil {
+			if req.Body, err = req.GetBody(); err != nil {
+				return nil, fmt.Errorf("failed to rewind request body: %w", err)
+			}
+		}
+
+		log.Printf("Retrying %s %s in %s (attempt %d of %d, reason: %s)", req.Method, url, delay, attempt+1, mgr.retry.MaxAttempts, reason)
+		mgr.metrics.RecordRetry(req.Method, url, reason)
+		if err := sleep(req.Context(), delay); err != nil {
+			return nil, fmt.Errorf("aborted retrying %s %s: %w", req.Method, url, err)
+		}
+	}
+}
+
+func (mgr *fuelPricesManager) attempt(req *http.Request) (io.ReadCloser, time.Duration, error) {
+	start := time.Now()
+	url := req.URL.String()
+
 	resp, err := mgr.client.Do(req)
-	mgr.metrics.RecordHttpCall(start, "POST", url, resp, err)
+	mgr.metrics.RecordHttpCall(start, req.Method, url, resp, err)
 
 	if err != nil {
-		return nil, fmt.Errorf("failed to perform request: %w", err)
+		return nil, 0, fmt.Errorf("failed to fetch from %s: %w", url, err)
 	}
 
 	if resp.StatusCode >= http.StatusBadRequest {
@@ -348,13 +729,17 @@ func (mgr *fuelPricesManager) post(url, contentType string, data any) (io.ReadCl
 		}
 		_ = resp.Body.Close()
 
-		return nil, &HTTPStatusError{
+		var retryAfter time.Duration
+		if resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode == http.StatusServiceUnavailable {
+			retryAfter = parseRetryAfter(resp.Header.Get("Retry-After"), time.Now())
+		}
+
+		return nil, retryAfter, &HTTPStatusError{
 			URL:        url,
 			Status:     resp.Status,
 			StatusCode: resp.StatusCode,
 			Body:       string(bodyBytes),
 		}
 	}
-
-	return resp.Body, nil
+	return resp.Body, 0, nil
 }
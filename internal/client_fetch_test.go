@@ -1,15 +1,22 @@
 package internal
 
 import (
+	"context"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"strconv"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/rm-hull/fuel-prices-api/internal/metrics"
+	"github.com/rm-hull/fuel-prices-api/internal/mockupstream"
 	"github.com/rm-hull/fuel-prices-api/internal/models"
+	"github.com/rm-hull/fuel-prices-api/internal/schema"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -58,7 +65,7 @@ func TestAuthenticate_Success(t *testing.T) {
 
 		var req models.AuthRequest
 		err := json.NewDecoder(r.Body).Decode(&req)
-		require.NoError(t, err)
+		assert.NoError(t, err)
 		assert.Equal(t, "test-client-id", req.ClientId)
 
 		resp := models.AuthResponse{
@@ -71,12 +78,12 @@ func TestAuthenticate_Success(t *testing.T) {
 		}
 		w.WriteHeader(http.StatusOK)
 		err = json.NewEncoder(w).Encode(resp)
-		require.NoError(t, err)
+		assert.NoError(t, err)
 	}))
 	defer server.Close()
 
 	mgr := setupTestClient(t, server.URL)
-	err := mgr.authenticate()
+	err := mgr.authenticate(t.Context())
 
 	require.NoError(t, err)
 	assert.Equal(t, "test-access-token", mgr.tokenData.AccessToken)
@@ -92,12 +99,12 @@ func TestAuthenticate_Failure(t *testing.T) {
 		}
 		w.WriteHeader(http.StatusOK)
 		err := json.NewEncoder(w).Encode(resp)
-		require.NoError(t, err)
+		assert.NoError(t, err)
 	}))
 	defer server.Close()
 
 	mgr := setupTestClient(t, server.URL)
-	err := mgr.authenticate()
+	err := mgr.authenticate(t.Context())
 
 	require.Error(t, err)
 	assert.Contains(t, err.Error(), "authentication failed: invalid credentials")
@@ -107,12 +114,12 @@ func TestAuthenticate_HttpError(t *testing.T) {
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusUnauthorized)
 		_, err := w.Write([]byte("unauthorized"))
-		require.NoError(t, err)
+		assert.NoError(t, err)
 	}))
 	defer server.Close()
 
 	mgr := setupTestClient(t, server.URL)
-	err := mgr.authenticate()
+	err := mgr.authenticate(t.Context())
 
 	require.Error(t, err)
 	var stErr *HTTPStatusError
@@ -126,7 +133,7 @@ func TestTokenRefresh_Success(t *testing.T) {
 		assert.Equal(t, "/oauth/regenerate_access_token", r.URL.Path)
 		var req models.TokenRefreshRequest
 		err := json.NewDecoder(r.Body).Decode(&req)
-		require.NoError(t, err)
+		assert.NoError(t, err)
 		assert.Equal(t, "test-refresh-token", req.RefreshToken)
 
 		resp := models.AuthResponse{
@@ -143,7 +150,7 @@ func TestTokenRefresh_Success(t *testing.T) {
 
 	mgr := setupTestClient(t, server.URL)
 	mgr.tokenData.RefreshToken = "test-refresh-token"
-	err := mgr.tokenRefresh()
+	err := mgr.tokenRefresh(t.Context())
 
 	require.NoError(t, err)
 	assert.Equal(t, "new-access-token", mgr.tokenData.AccessToken)
@@ -158,7 +165,7 @@ func TestTokenRefresh_Failure(t *testing.T) {
 	defer server.Close()
 
 	mgr := setupTestClient(t, server.URL)
-	err := mgr.tokenRefresh()
+	err := mgr.tokenRefresh(t.Context())
 	require.Error(t, err)
 }
 
@@ -182,7 +189,7 @@ func TestTokenRefresh_RetryOnServerError(t *testing.T) {
 	defer server.Close()
 
 	mgr := setupTestClient(t, server.URL)
-	err := mgr.tokenRefresh()
+	err := mgr.tokenRefresh(t.Context())
 
 	require.NoError(t, err)
 	assert.Equal(t, "recovered-token", mgr.tokenData.AccessToken)
@@ -204,14 +211,14 @@ func TestCheckTokenExpiry(t *testing.T) {
 	// Not expired
 	mgr.timeTracker.lastAuth = time.Now()
 	mgr.tokenData.ExpiresIn = 3600
-	err := mgr.checkTokenExpiry()
+	err := mgr.checkTokenExpiry(t.Context())
 	require.NoError(t, err)
 	assert.Empty(t, mgr.tokenData.AccessToken) // No refresh happened
 
 	// Expiring soon
 	mgr.timeTracker.lastAuth = time.Now().Add(-56 * time.Minute)
 	mgr.tokenData.ExpiresIn = 3600 // expires in 4 mins
-	err = mgr.checkTokenExpiry()
+	err = mgr.checkTokenExpiry(t.Context())
 	require.NoError(t, err)
 	assert.Equal(t, "refreshed", mgr.tokenData.AccessToken)
 }
@@ -236,7 +243,7 @@ func TestGetFuelPrices_Success(t *testing.T) {
 	mgr.tokenData.ExpiresIn = 3600
 	mgr.timeTracker.lastAuth = time.Now()
 
-	count, dropped, err := mgr.GetFuelPrices(func(batch []models.ForecourtPrices) (int, int, error) {
+	count, dropped, err := mgr.GetFuelPrices(t.Context(), func(_ context.Context, batch []models.ForecourtPrices) (int, int, error) {
 		return len(batch), 0, nil
 	})
 
@@ -265,7 +272,7 @@ func TestGetFillingStations_Success(t *testing.T) {
 	mgr.tokenData.ExpiresIn = 3600
 	mgr.timeTracker.lastAuth = time.Now()
 
-	count, dropped, err := mgr.GetFillingStations(func(batch []models.PetrolFillingStation) (int, int, error) {
+	count, dropped, err := mgr.GetFillingStations(t.Context(), func(_ context.Context, batch []models.PetrolFillingStation) (int, int, error) {
 		return len(batch), 0, nil
 	})
 
@@ -278,15 +285,15 @@ func TestGetEffectiveStartTimestamp(t *testing.T) {
 	mgr := &fuelPricesManager{fullRefresh: false}
 	lastFetch := time.Date(2023, 1, 1, 12, 0, 0, 0, time.UTC)
 
-	ts := mgr.getEffectiveStartTimestamp("path", &lastFetch)
+	ts := mgr.getEffectiveStartTimestamp("path", lastFetch)
 	assert.Equal(t, "2023-01-01 12:00:00", ts)
 
 	mgr.fullRefresh = true
-	ts = mgr.getEffectiveStartTimestamp("path", &lastFetch)
+	ts = mgr.getEffectiveStartTimestamp("path", lastFetch)
 	assert.Equal(t, "", ts)
 
 	mgr.fullRefresh = false
-	ts = mgr.getEffectiveStartTimestamp("path", nil)
+	ts = mgr.getEffectiveStartTimestamp("path", time.Time{})
 	assert.Equal(t, "", ts)
 }
 
@@ -309,7 +316,7 @@ func TestFetchBatched_CallbackError(t *testing.T) {
 	mgr.tokenData.ExpiresIn = 3600
 	mgr.timeTracker.lastAuth = time.Now()
 
-	_, _, err := mgr.GetFuelPrices(func(batch []models.ForecourtPrices) (int, int, error) {
+	_, _, err := mgr.GetFuelPrices(t.Context(), func(_ context.Context, batch []models.ForecourtPrices) (int, int, error) {
 		return 0, 0, fmt.Errorf("callback failed")
 	})
 
@@ -319,14 +326,14 @@ func TestFetchBatched_CallbackError(t *testing.T) {
 
 func TestGetFuelPrices_InvalidUrl(t *testing.T) {
 	mgr := setupTestClient(t, " http://invalid") // leading space makes it invalid
-	_, _, err := mgr.GetFuelPrices(nil)
+	_, _, err := mgr.GetFuelPrices(t.Context(), nil)
 	require.Error(t, err)
 }
 
 func TestGetFuelPrices_DecodeError(t *testing.T) {
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		_, err := w.Write([]byte("invalid json"))
-		require.NoError(t, err)
+		assert.NoError(t, err)
 	}))
 	defer server.Close()
 
@@ -334,7 +341,7 @@ func TestGetFuelPrices_DecodeError(t *testing.T) {
 	mgr.tokenData.ExpiresIn = 3600
 	mgr.timeTracker.lastAuth = time.Now()
 
-	_, _, err := mgr.GetFuelPrices(nil)
+	_, _, err := mgr.GetFuelPrices(t.Context(), nil)
 	require.Error(t, err)
 	assert.Contains(t, err.Error(), "failed to unmarshal response")
 }
@@ -349,7 +356,7 @@ func TestFetchBatched_TokenExpiryError(t *testing.T) {
 	mgr.timeTracker.lastAuth = time.Now().Add(-1 * time.Hour)
 	mgr.tokenData.ExpiresIn = 1800 // expired
 
-	_, _, err := mgr.GetFuelPrices(nil)
+	_, _, err := mgr.GetFuelPrices(t.Context(), nil)
 	require.Error(t, err)
 	assert.Contains(t, err.Error(), "failed to refresh token")
 }
@@ -357,7 +364,7 @@ func TestFetchBatched_TokenExpiryError(t *testing.T) {
 func TestPost_MarshalError(t *testing.T) {
 	mgr := setupTestClient(t, "http://example.com")
 	// can't marshal a channel
-	_, err := mgr.post("http://example.com", "app/json", make(chan int))
+	_, err := mgr.post(t.Context(), "http://example.com", "app/json", make(chan int), nil)
 	require.Error(t, err)
 	assert.Contains(t, err.Error(), "failed to marshal request body")
 }
@@ -374,7 +381,557 @@ func TestNewFuelPricesClient(t *testing.T) {
 
 	t.Setenv("FUEL_PRICES_API_BASE_URL", server.URL)
 
-	client, err := NewFuelPricesClient("id", "secret", false)
+	client, err := NewFuelPricesClient(t.Context(), "id", "secret", false, nil)
 	require.NoError(t, err)
 	assert.NotNil(t, client)
 }
+
+func setupRetryingTestClient(t *testing.T, baseUrl string) *fuelPricesManager {
+	mgr := setupTestClient(t, baseUrl)
+	mgr.retry = retryPolicy{
+		MaxAttempts:   3,
+		BaseDelay:     time.Millisecond,
+		MaxDelay:      5 * time.Millisecond,
+		MaxRetryAfter: time.Second,
+		RunBudget:     10,
+	}
+	mgr.tokenData.ExpiresIn = 3600
+	mgr.timeTracker.lastAuth = time.Now()
+	return mgr
+}
+
+func TestFetchBatched_RetriesTransientErrors(t *testing.T) {
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls == 1 {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		if r.URL.Query().Get("batch-number") == "1" {
+			_ = json.NewEncoder(w).Encode([]models.ForecourtPrices{{NodeId: "1"}})
+			return
+		}
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	mgr := setupRetryingTestClient(t, server.URL)
+
+	count, _, err := mgr.GetFuelPrices(t.Context(), func(_ context.Context, batch []models.ForecourtPrices) (int, int, error) {
+		return len(batch), 0, nil
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, 1, count)
+	assert.Equal(t, 3, calls) // 502, batch 1, then 404 which is never retried
+	assert.Equal(t, 1.0, testutil.ToFloat64(mgr.metrics.RetriesTotal.WithLabelValues("/pfs/fuel-prices", "GET", "502")))
+}
+
+func TestFetchBatched_GivesUpAfterMaxAttempts(t *testing.T) {
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	mgr := setupRetryingTestClient(t, server.URL)
+
+	_, _, err := mgr.GetFuelPrices(t.Context(), nil)
+
+	var stErr *HTTPStatusError
+	require.ErrorAs(t, err, &stErr)
+	assert.Equal(t, http.StatusServiceUnavailable, stErr.StatusCode)
+	assert.Equal(t, 3, calls)
+}
+
+func TestFetchBatched_DoesNotRetryClientErrors(t *testing.T) {
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer server.Close()
+
+	mgr := setupRetryingTestClient(t, server.URL)
+
+	_, _, err := mgr.GetFuelPrices(t.Context(), nil)
+	require.Error(t, err)
+	assert.Equal(t, 1, calls)
+}
+
+func TestFetchBatched_RetryBudgetExhausted(t *testing.T) {
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	mgr := setupRetryingTestClient(t, server.URL)
+	mgr.retry.RunBudget = 1
+
+	_, _, err := mgr.GetFuelPrices(t.Context(), nil)
+	require.Error(t, err)
+	assert.Equal(t, 2, calls)
+}
+
+func TestFetchBatched_HonoursRetryAfter(t *testing.T) {
+	var retriedAt, firstAt time.Time
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if firstAt.IsZero() {
+			firstAt = time.Now()
+			w.Header().Set("Retry-After", "1")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		if retriedAt.IsZero() {
+			retriedAt = time.Now()
+		}
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	mgr := setupRetryingTestClient(t, server.URL)
+
+	_, _, err := mgr.GetFuelPrices(t.Context(), nil)
+	require.NoError(t, err)
+	assert.GreaterOrEqual(t, retriedAt.Sub(firstAt), time.Second)
+}
+
+func TestFetchBatched_RetryAfterTooLong(t *testing.T) {
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Header().Set("Retry-After", "3600")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer server.Close()
+
+	mgr := setupRetryingTestClient(t, server.URL)
+
+	_, _, err := mgr.GetFuelPrices(t.Context(), nil)
+	require.Error(t, err)
+	assert.Equal(t, 1, calls)
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	assert.Equal(t, time.Duration(0), parseRetryAfter("", now))
+	assert.Equal(t, 30*time.Second, parseRetryAfter("30", now))
+	assert.Equal(t, time.Duration(0), parseRetryAfter("-5", now))
+	assert.Equal(t, time.Duration(0), parseRetryAfter("soon", now))
+	assert.Equal(t, 90*time.Second, parseRetryAfter("Thu, 01 Jan 2026 12:01:30 GMT", now))
+	assert.Equal(t, time.Duration(0), parseRetryAfter("Thu, 01 Jan 2026 11:00:00 GMT", now))
+}
+
+func TestRetryPolicy_Backoff(t *testing.T) {
+	policy := retryPolicy{BaseDelay: 100 * time.Millisecond, MaxDelay: time.Second}
+
+	for attempt := 1; attempt <= 40; attempt++ {
+		delay := policy.backoff(attempt)
+		assert.Greater(t, delay, time.Duration(0))
+		assert.LessOrEqual(t, delay, time.Second)
+	}
+	assert.LessOrEqual(t, policy.backoff(1), 100*time.Millisecond)
+}
+
+type stubWatermarkStore struct {
+	watermarks map[string]time.Time
+}
+
+func (s *stubWatermarkStore) FetchWatermarks() (map[string]time.Time, error) {
+	return s.watermarks, nil
+}
+
+func (s *stubWatermarkStore) SaveFetchWatermark(path string, fetchedAt time.Time) error {
+	s.watermarks[path] = fetchedAt
+	return nil
+}
+
+func TestFetchBatched_ResumesFromPersistedWatermark(t *testing.T) {
+	// 09:00 UTC, given in another zone: upstream is always sent UTC.
+	lastFetch := time.Date(2026, 3, 1, 10, 0, 0, 0, time.FixedZone("CET", 3600))
+	var effectiveStart string
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/oauth/generate_access_token" {
+			_ = json.NewEncoder(w).Encode(models.AuthResponse{
+				Success: true,
+				Data:    models.TokenData{AccessToken: "token", ExpiresIn: 3600},
+			})
+			return
+		}
+		effectiveStart = r.URL.Query().Get("effective-start-timestamp")
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	t.Setenv("FUEL_PRICES_API_BASE_URL", server.URL)
+	store := &stubWatermarkStore{watermarks: map[string]time.Time{pricesPath: lastFetch}}
+
+	client, err := NewFuelPricesClient(t.Context(), "id", "secret", false, store)
+	require.NoError(t, err)
+	require.NotNil(t, client.LastUpdated())
+	assert.True(t, client.LastUpdated().Equal(lastFetch))
+
+	_, _, err = client.GetFuelPrices(t.Context(), nil)
+	require.NoError(t, err)
+	assert.Equal(t, "2026-03-01 09:00:00", effectiveStart)
+	assert.True(t, store.watermarks[pricesPath].After(lastFetch))
+	assert.Equal(t, time.UTC, store.watermarks[pricesPath].Location())
+	assert.NotContains(t, store.watermarks, pfsPath)
+}
+
+func TestSetLastFetch_OnlyAdvances(t *testing.T) {
+	mgr := setupRetryingTestClient(t, "http://localhost")
+	later := time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+
+	mgr.setLastFetch(pricesPath, later)
+	mgr.setLastFetch(pricesPath, later.Add(-time.Minute))
+	assert.True(t, mgr.lastFetch(pricesPath).Equal(later))
+	assert.True(t, mgr.lastFetch(pfsPath).IsZero())
+}
+
+func TestFetchBatched_RunTimeoutAbortsHungUpstream(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	}))
+	defer server.Close()
+
+	mgr := setupRetryingTestClient(t, server.URL)
+	mgr.runTimeout = 50 * time.Millisecond
+
+	start := time.Now()
+	_, _, err := mgr.GetFuelPrices(t.Context(), nil)
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Less(t, time.Since(start), 5*time.Second)
+}
+
+func TestFetchBatched_CancelledDuringRetry(t *testing.T) {
+	ctx, cancel := context.WithCancel(t.Context())
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		cancel()
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer server.Close()
+
+	mgr := setupRetryingTestClient(t, server.URL)
+	mgr.retry.BaseDelay = time.Minute
+	mgr.retry.MaxDelay = time.Minute
+
+	_, _, err := mgr.GetFuelPrices(ctx, nil)
+	require.Error(t, err)
+	assert.Equal(t, 1, calls)
+}
+
+func TestFetchBatched_CallbackReceivesContext(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode([]models.ForecourtPrices{{NodeId: "1"}})
+	}))
+	defer server.Close()
+
+	ctx, cancel := context.WithCancel(t.Context())
+	mgr := setupRetryingTestClient(t, server.URL)
+
+	batches := 0
+	_, _, err := mgr.GetFuelPrices(ctx, func(ctx context.Context, batch []models.ForecourtPrices) (int, int, error) {
+		batches++
+		cancel()
+		return len(batch), 0, ctx.Err()
+	})
+
+	require.ErrorIs(t, err, context.Canceled)
+	assert.Equal(t, 1, batches)
+}
+
+func TestFetchBatched_ConcurrentAppliesBatchesInOrder(t *testing.T) {
+	const totalBatches = 8
+	var inFlight, maxInFlight atomic.Int32
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		current := inFlight.Add(1)
+		defer inFlight.Add(-1)
+		for {
+			prev := maxInFlight.Load()
+			if current <= prev || maxInFlight.CompareAndSwap(prev, current) {
+				break
+			}
+		}
+
+		batchNo, err := strconv.Atoi(r.URL.Query().Get("batch-number"))
+		if !assert.NoError(t, err) {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		if batchNo > totalBatches {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		// Later batches respond faster, so they arrive out of order
+		time.Sleep(time.Duration(totalBatches-batchNo) * 5 * time.Millisecond)
+		_ = json.NewEncoder(w).Encode(models.BatchResponse[models.ForecourtPrices]{
+			Data: []models.ForecourtPrices{{NodeId: strconv.Itoa(batchNo)}},
+			MetaData: &models.MetaData{
+				BatchNumber:  batchNo,
+				BatchSize:    1,
+				TotalBatches: totalBatches,
+			},
+		})
+	}))
+	defer server.Close()
+
+	mgr := setupRetryingTestClient(t, server.URL)
+	mgr.concurrency = 3
+
+	var applied []string
+	count, _, err := mgr.GetFuelPrices(t.Context(), func(_ context.Context, batch []models.ForecourtPrices) (int, int, error) {
+		for _, fp := range batch {
+			applied = append(applied, fp.NodeId)
+		}
+		return len(batch), 0, nil
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, totalBatches, count)
+	assert.Equal(t, []string{"1", "2", "3", "4", "5", "6", "7", "8"}, applied)
+	assert.LessOrEqual(t, maxInFlight.Load(), int32(3))
+	assert.Greater(t, maxInFlight.Load(), int32(1))
+}
+
+func TestFetchBatched_ConcurrentStopsOnError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		batchNo := r.URL.Query().Get("batch-number")
+		if batchNo == "3" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(models.BatchResponse[models.ForecourtPrices]{
+			Data:     []models.ForecourtPrices{{NodeId: batchNo}},
+			MetaData: &models.MetaData{TotalBatches: 5},
+		})
+	}))
+	defer server.Close()
+
+	mgr := setupRetryingTestClient(t, server.URL)
+	mgr.concurrency = 2
+
+	var applied []string
+	_, _, err := mgr.GetFuelPrices(t.Context(), func(_ context.Context, batch []models.ForecourtPrices) (int, int, error) {
+		applied = append(applied, batch[0].NodeId)
+		return len(batch), 0, nil
+	})
+
+	var stErr *HTTPStatusError
+	require.ErrorAs(t, err, &stErr)
+	assert.Equal(t, http.StatusForbidden, stErr.StatusCode)
+	assert.Equal(t, []string{"1", "2"}, applied)
+}
+
+func TestFetch_ParallelFetchesShareTokenRefresh(t *testing.T) {
+	var refreshes atomic.Int32
+	var staleTokens atomic.Int32
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/oauth/regenerate_access_token":
+			refreshes.Add(1)
+			time.Sleep(50 * time.Millisecond) // widen the window for overlapping refreshes
+			_ = json.NewEncoder(w).Encode(models.AuthResponse{
+				Success: true,
+				Data:    models.TokenData{AccessToken: "fresh-token", ExpiresIn: 3600},
+			})
+		case "/pfs", "/pfs/fuel-prices":
+			if r.Header.Get("Authorization") != "Bearer fresh-token" {
+				staleTokens.Add(1)
+			}
+			if r.URL.Query().Get("batch-number") != "1" {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			_, _ = w.Write([]byte(`[{"node_id": "1"}]`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	mgr := setupRetryingTestClient(t, server.URL)
+	mgr.tokenData = models.TokenData{AccessToken: "stale-token", RefreshToken: "refresh", ExpiresIn: 60}
+
+	var wg sync.WaitGroup
+	for range 4 {
+		wg.Go(func() {
+			_, _, err := mgr.GetFillingStations(t.Context(), func(ctx context.Context, batch []models.PetrolFillingStation) (int, int, error) {
+				return len(batch), 0, nil
+			})
+			assert.NoError(t, err)
+		})
+		wg.Go(func() {
+			_, _, err := mgr.GetFuelPrices(t.Context(), func(ctx context.Context, batch []models.ForecourtPrices) (int, int, error) {
+				return len(batch), 0, nil
+			})
+			assert.NoError(t, err)
+			assert.NotNil(t, mgr.LastUpdated())
+		})
+	}
+	wg.Wait()
+
+	assert.Equal(t, int32(1), refreshes.Load())
+	assert.Zero(t, staleTokens.Load())
+	assert.Equal(t, "fresh-token", mgr.accessToken())
+	assert.False(t, mgr.lastFetch(pfsPath).IsZero())
+	assert.False(t, mgr.lastFetch(pricesPath).IsZero())
+}
+
+func TestFetchBatched_RecordsSchemaDrift(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("batch-number") != "1" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, _ = w.Write([]byte(`[{"node_id": "1", "fuel_prices": [{"fuel_type": "E10", "price": "139.9", "price_band": "low"}]}]`))
+	}))
+	defer server.Close()
+
+	mgr := setupRetryingTestClient(t, server.URL)
+	assert.False(t, mgr.SchemaDrift().Enabled)
+
+	mgr.drift = schema.NewTracker()
+	_, _, err := mgr.GetFuelPrices(t.Context(), nil)
+	require.Error(t, err) // the type mismatch also fails the regular decode, but is still recorded
+
+	report := mgr.SchemaDrift()
+	assert.True(t, report.Enabled)
+	require.Len(t, report.Drift, 2)
+	assert.Equal(t, "type_mismatch", report.Drift[0].Kind)
+	assert.Equal(t, "fuel_prices[].price", report.Drift[0].Field)
+
+	server.Config.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("batch-number") != "1" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, _ = w.Write([]byte(`[{"node_id": "1", "fuel_prices": [{"fuel_type": "E10", "price": 139.9, "price_band": "low"}, {"price": 149.9}]}]`))
+	})
+	_, _, err = mgr.GetFuelPrices(t.Context(), func(ctx context.Context, batch []models.ForecourtPrices) (int, int, error) {
+		return len(batch), 0, nil
+	})
+	require.NoError(t, err)
+
+	report = mgr.SchemaDrift()
+	require.Len(t, report.Drift, 3)
+	assert.Equal(t, "missing_field", report.Drift[0].Kind)
+	assert.Equal(t, "fuel_prices[].fuel_type", report.Drift[0].Field)
+	assert.Equal(t, "unknown_field", report.Drift[2].Kind)
+	assert.Equal(t, "fuel_prices[].price_band", report.Drift[2].Field)
+	assert.Equal(t, 2, report.Drift[2].Batches)
+	assert.Equal(t, 2.0, testutil.ToFloat64(mgr.metrics.SchemaDriftTotal.WithLabelValues(pricesPath, "unknown_field", "fuel_prices[].price_band")))
+}
+
+func TestDecodeBatch(t *testing.T) {
+	data, metaData, err := decodeBatch[models.ForecourtPrices]([]byte(`[{"node_id": "1"}]`), 1)
+	require.NoError(t, err)
+	assert.Nil(t, metaData)
+	assert.Equal(t, "1", data[0].NodeId)
+
+	data, metaData, err = decodeBatch[models.ForecourtPrices]([]byte(`
+		{"data": [{"node_id": "2"}], "metadata": {"batch_number": 1, "batch_size": 500, "total_batches": 12}}`), 1)
+	require.NoError(t, err)
+	require.NotNil(t, metaData)
+	assert.Equal(t, 12, metaData.TotalBatches)
+	assert.Equal(t, "2", data[0].NodeId)
+
+	_, _, err = decodeBatch[models.ForecourtPrices]([]byte(`{"data": 3}`), 1)
+	require.Error(t, err)
+}
+
+func TestClientAgainstMockUpstream(t *testing.T) {
+	mock := mockupstream.New(mockupstream.Config{
+		BatchSize:    7,
+		TokenTTL:     2 * time.Minute, // forces a token refresh before every fetch
+		WithMetaData: true,
+	}, mockupstream.Generate(50, 1, time.Now()))
+	server := httptest.NewServer(mock.Handler())
+	defer server.Close()
+
+	mgr := setupRetryingTestClient(t, server.URL)
+	mgr.concurrency = 4
+	require.NoError(t, mgr.authenticate(t.Context()))
+
+	mock.InjectFaults(mockupstream.Faults{FailNext: 2})
+
+	repo := setupTestDB(t)
+	numPFS, _, err := mgr.GetFillingStations(t.Context(), repo.InsertPFS)
+	require.NoError(t, err)
+	assert.Equal(t, 50, numPFS)
+
+	numPrices, _, err := mgr.GetFuelPrices(t.Context(), repo.InsertPrices)
+	require.NoError(t, err)
+	assert.Greater(t, numPrices, 150)
+
+	mock.Tick(5)
+	numPrices, _, err = mgr.GetFuelPrices(t.Context(), repo.InsertPrices)
+	require.NoError(t, err)
+	assert.LessOrEqual(t, numPrices, 5)
+}
+
+// TestRefreshStations_DailyFullRefresh checks that once a watermark has been
+// persisted, stations gone from upstream are only noticed by the full refresh
+// which ignores it.
+func TestRefreshStations_DailyFullRefresh(t *testing.T) {
+	var effectiveStarts []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("batch-number") != "1" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		effectiveStarts = append(effectiveStarts, r.URL.Query().Get("effective-start-timestamp"))
+		_ = json.NewEncoder(w).Encode([]models.PetrolFillingStation{
+			{NodeId: "kept", Location: models.Location{Latitude: 51.5, Longitude: -0.1}},
+		})
+	}))
+	defer server.Close()
+
+	repo := setupTestDB(t)
+	_, _, err := repo.InsertPFS(t.Context(), []models.PetrolFillingStation{
+		{NodeId: "kept", Location: models.Location{Latitude: 51.5, Longitude: -0.1}},
+		{NodeId: "gone", Location: models.Location{Latitude: 51.5, Longitude: -0.1}},
+	})
+	require.NoError(t, err)
+	// Seen by an earlier refresh, rather than within the second this one starts.
+	_, err = repo.(*sqlRepository).db.Exec("UPDATE petrol_filling_stations SET last_seen_at = $1", time.Now().UTC().Add(-time.Hour))
+	require.NoError(t, err)
+	require.NoError(t, repo.SaveFetchWatermark(pfsPath, time.Now().Add(-time.Hour)))
+
+	mgr := setupRetryingTestClient(t, server.URL)
+	mgr.watermarks = repo
+	require.NoError(t, mgr.loadWatermarks())
+
+	inactive := func() map[string]bool {
+		results, err := repo.Search([]float64{-1, 51, 1, 52}, 1, true)
+		require.NoError(t, err)
+		stations := make(map[string]bool)
+		for _, result := range results {
+			stations[result.NodeId] = result.Inactive
+		}
+		return stations
+	}
+
+	_, _, err = RefreshStations(t.Context(), mgr, repo, 1)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]bool{"kept": false, "gone": false}, inactive())
+
+	_, _, err = RefreshStations(WithFullRefresh(t.Context()), mgr, repo, 1)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]bool{"kept": false, "gone": true}, inactive())
+
+	require.Len(t, effectiveStarts, 2)
+	assert.NotEmpty(t, effectiveStarts[0])
+	assert.Empty(t, effectiveStarts[1])
+}
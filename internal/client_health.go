@@ -0,0 +1,55 @@
+package internal
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/tavsec/gin-healthcheck/checks"
+)
+
+// Check reports on the health of the upstream: whether the client is
+// degraded, the circuit breaker state, and when it last authenticated and
+// fetched successfully.
+func (mgr *fuelPricesManager) Check() checks.Check {
+	return upstreamCheck{mgr: mgr}
+}
+
+// upstreamCheck is the health check for the upstream. gin-healthcheck only
+// reports a name and whether each check passed, with no detail field and no
+// way to mark a check as degraded rather than failed. Failing it would take
+// the API out of service while it can still serve what is in the database,
+// so the check always passes and its state is carried in the name instead,
+// after a stable "upstream:" prefix which monitors can match on.
+type upstreamCheck struct {
+	mgr *fuelPricesManager
+}
+
+func (check upstreamCheck) Pass() bool {
+	return true
+}
+
+func (check upstreamCheck) Name() string {
+	breaker := check.mgr.breaker.State()
+	status := "ok"
+	if check.mgr.upstreamErr() != nil || breaker != breakerClosed {
+		status = "degraded"
+	}
+
+	check.mgr.mu.RLock()
+	lastAuth := check.mgr.timeTracker.lastAuth
+	lastFetch := check.mgr.timeTracker.lastPricesFetch
+	if check.mgr.timeTracker.lastPfsFetch.After(lastFetch) {
+		lastFetch = check.mgr.timeTracker.lastPfsFetch
+	}
+	check.mgr.mu.RUnlock()
+
+	return fmt.Sprintf("upstream: %s (breaker: %s, last auth: %s, last fetch: %s)",
+		status, breaker, formatLast(lastAuth), formatLast(lastFetch))
+}
+
+func formatLast(t time.Time) string {
+	if t.IsZero() {
+		return "never"
+	}
+	return t.UTC().Format(time.RFC3339)
+}
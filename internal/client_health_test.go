@@ -0,0 +1,22 @@
+package internal
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUpstreamCheck_BreakerStates(t *testing.T) {
+	mgr := &fuelPricesManager{breaker: newCircuitBreaker(nil)}
+	check := mgr.Check()
+
+	for state, expected := range map[breakerState]string{
+		breakerClosed:   "upstream: ok (breaker: closed, last auth: never, last fetch: never)",
+		breakerHalfOpen: "upstream: degraded (breaker: half-open, last auth: never, last fetch: never)",
+		breakerOpen:     "upstream: degraded (breaker: open, last auth: never, last fetch: never)",
+	} {
+		mgr.breaker.state = state
+		assert.True(t, check.Pass(), "upstream problems leave the API healthy")
+		assert.Equal(t, expected, check.Name())
+	}
+}
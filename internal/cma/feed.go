@@ -0,0 +1,178 @@
+// Package cma reads the interim open-data fuel price feeds that retailers
+// publish in the format specified by the Competition and Markets Authority,
+// and maps them onto the Fuel Finder models.
+package cma
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rm-hull/fuel-prices-api/internal/models"
+)
+
+type Feed struct {
+	LastUpdated string    `json:"last_updated"`
+	Stations    []Station `json:"stations"`
+
+	// namespace prefixes the node IDs of the feed's stations, and is set
+	// from the feed's file name by Load.
+	namespace string
+}
+
+type Station struct {
+	SiteId   string `json:"site_id"`
+	Brand    string `json:"brand"`
+	Address  string `json:"address"`
+	Postcode string `json:"postcode"`
+	Location struct {
+		Latitude  number `json:"latitude"`
+		Longitude number `json:"longitude"`
+	} `json:"location"`
+	Prices map[string]number `json:"prices"`
+}
+
+// number accepts both JSON numbers and numeric strings, as retailers' feeds
+// are not consistent about which they use.
+type number float64
+
+func (n *number) UnmarshalJSON(data []byte) error {
+	data = bytes.Trim(data, `"`)
+	if len(data) == 0 || string(data) == "null" {
+		*n = 0
+		return nil
+	}
+	f, err := strconv.ParseFloat(string(data), 64)
+	if err != nil {
+		return fmt.Errorf("invalid number %s: %w", data, err)
+	}
+	*n = number(f)
+	return nil
+}
+
+var timestampLayouts = []string{
+	"02/01/2006 15:04:05",
+	"02/01/2006 15:04",
+	time.RFC3339,
+	time.DateTime,
+}
+
+// Files returns the feed at path, or every JSON feed in it if it is a directory.
+func Files(path string) ([]string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return []string{path}, nil
+	}
+
+	files, err := filepath.Glob(filepath.Join(path, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	slices.Sort(files)
+	return files, nil
+}
+
+func Load(filename string) (*Feed, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", filename, err)
+	}
+
+	feed := Feed{namespace: Source(filename)}
+	if err := json.Unmarshal(data, &feed); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", filename, err)
+	}
+	return &feed, nil
+}
+
+// Source names the retailer of the given feed file, e.g. cma:tesco for
+// tesco.json. It is the source recorded against rows by import-cma, and
+// namespaces node IDs however the feed is imported.
+func Source(filename string) string {
+	return "cma:" + strings.ToLower(strings.TrimSuffix(filepath.Base(filename), filepath.Ext(filename)))
+}
+
+func (feed *Feed) lastUpdated() (time.Time, error) {
+	value := strings.TrimSpace(feed.LastUpdated)
+	for _, layout := range timestampLayouts {
+		if t, err := time.ParseInLocation(layout, value, time.UTC); err == nil {
+			return t.UTC(), nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("unrecognised last_updated timestamp: %q", feed.LastUpdated)
+}
+
+// ToModels maps the feed onto stations and prices, tagged with the given
+// source. Node IDs are namespaced by the feed's retailer, as CMA site IDs are
+// only unique within a single retailer's feed, so a feed imported under a
+// data source's configured name maps onto the same stations as import-cma.
+// All prices are taken to have last been updated at the feed's last_updated
+// time.
+func (feed *Feed) ToModels(source string) ([]models.PetrolFillingStation, []models.ForecourtPrices, error) {
+	lastUpdated, err := feed.lastUpdated()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	stations := make([]models.PetrolFillingStation, 0, len(feed.Stations))
+	prices := make([]models.ForecourtPrices, 0, len(feed.Stations))
+	for _, station := range feed.Stations {
+		if station.SiteId == "" {
+			continue
+		}
+
+		nodeId := feed.namespace + ":" + station.SiteId
+		brand := strings.TrimSpace(station.Brand)
+		pfs := models.PetrolFillingStation{
+			NodeId:                    nodeId,
+			TradingName:               brand,
+			IsSameTradingAndBrandName: true,
+			BrandName:                 brand,
+			Location: models.Location{
+				AddressLine1: strings.TrimSpace(station.Address),
+				Postcode:     strings.TrimSpace(station.Postcode),
+				Latitude:     float64(station.Location.Latitude),
+				Longitude:    float64(station.Location.Longitude),
+			},
+			Source: source,
+		}
+		forecourt := models.ForecourtPrices{
+			NodeId:      nodeId,
+			TradingName: brand,
+			Source:      source,
+		}
+
+		codes := make([]string, 0, len(station.Prices))
+		for code := range station.Prices {
+			codes = append(codes, code)
+		}
+		slices.Sort(codes)
+		for _, code := range codes {
+			price := float64(station.Prices[code])
+			if price <= 0 {
+				continue
+			}
+			fuelType := models.CanonicalFuelType(code)
+			pfs.FuelTypes = append(pfs.FuelTypes, fuelType)
+			forecourt.FuelPrices = append(forecourt.FuelPrices, models.FuelPrice{
+				FuelType:         fuelType,
+				Price:            price,
+				PriceLastUpdated: lastUpdated,
+			})
+		}
+
+		stations = append(stations, pfs)
+		prices = append(prices, forecourt)
+	}
+
+	return stations, prices, nil
+}
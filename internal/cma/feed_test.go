@@ -0,0 +1,73 @@
+package cma
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const sampleFeed = `{
+	"last_updated": "21/11/2023 10:45:02",
+	"stations": [
+		{
+			"site_id": "gbfs0001",
+			"brand": "TESCO ",
+			"address": "Tesco Extra, Oxford Road, Leeds",
+			"postcode": "LS1 1AA",
+			"location": {"latitude": 53.8, "longitude": "-1.55"},
+			"prices": {"E10": 145.9, "E5": "155.9", "B7": 152.9, "SDV": 0}
+		},
+		{
+			"site_id": "",
+			"brand": "TESCO",
+			"prices": {"E10": 145.9}
+		}
+	]
+}`
+
+func TestLoadAndMap(t *testing.T) {
+	dir := t.TempDir()
+	filename := filepath.Join(dir, "Tesco.json")
+	require.NoError(t, os.WriteFile(filename, []byte(sampleFeed), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "README.txt"), []byte("not a feed"), 0644))
+
+	files, err := Files(dir)
+	require.NoError(t, err)
+	assert.Equal(t, []string{filename}, files)
+
+	feed, err := Load(filename)
+	require.NoError(t, err)
+
+	source := Source(filename)
+	assert.Equal(t, "cma:tesco", source)
+
+	stations, prices, err := feed.ToModels(source)
+	require.NoError(t, err)
+	require.Len(t, stations, 1)
+	require.Len(t, prices, 1)
+
+	pfs := stations[0]
+	assert.Equal(t, "cma:tesco:gbfs0001", pfs.NodeId)
+	assert.Equal(t, "TESCO", pfs.BrandName)
+	assert.Equal(t, "LS1 1AA", pfs.Location.Postcode)
+	assert.Equal(t, -1.55, pfs.Location.Longitude)
+	assert.Equal(t, []string{"B7_STANDARD", "E10", "E5"}, pfs.FuelTypes)
+	assert.Equal(t, source, pfs.Source)
+
+	assert.Equal(t, pfs.NodeId, prices[0].NodeId)
+	assert.Equal(t, source, prices[0].Source)
+	require.Len(t, prices[0].FuelPrices, 3)
+	assert.Equal(t, "E5", prices[0].FuelPrices[2].FuelType)
+	assert.Equal(t, 155.9, prices[0].FuelPrices[2].Price)
+	assert.Equal(t, time.Date(2023, 11, 21, 10, 45, 2, 0, time.UTC), prices[0].FuelPrices[0].PriceLastUpdated)
+}
+
+func TestToModels_InvalidTimestamp(t *testing.T) {
+	feed := &Feed{LastUpdated: "yesterday"}
+	_, _, err := feed.ToModels("cma:test")
+	assert.Error(t, err)
+}
@@ -1,40 +1,85 @@
 package internal
 
 import (
+	"context"
 	"log"
 
+	"github.com/rm-hull/fuel-prices-api/internal/models"
 	"github.com/robfig/cron/v3"
 )
 
 const CRON_SCHEDULE_PFS = "0 */6 * * *"     // Every 6 hours
+const CRON_SCHEDULE_PFS_FULL = "15 3 * * *" // Daily
 const CRON_SCHEDULE_PRICES = "10 */1 * * *" // Every hour
+const CRON_SCHEDULE_BOUNDS = "30 2 * * *"   // Daily
 
-func StartCron(client FuelPricesClient, repo FuelPricesRepository) (*cron.Cron, error) {
+// StartCron schedules the periodic PFS and fuel price fetches for each data
+// source. Scheduled PFS fetches are incremental where the source allows, so a
+// daily full refresh also runs, which is what notices stations that have
+// gone. Cancelling ctx aborts any fetch that is in flight; callers should
+// then wait on the context returned by the scheduler's Stop method for
+// running jobs to finish.
+func StartCron(ctx context.Context, sources []DataSource, repo FuelPricesRepository) (*cron.Cron, error) {
 
-	c := cron.New()
+	threshold, err := LoadInactiveThreshold()
+	if err != nil {
+		return nil, err
+	}
 
-	log.Print("Starting CRON jobs to update petrol filling stations and fuel prices")
+	c := cron.New()
 
-	if _, err := c.AddFunc(CRON_SCHEDULE_PFS, func() {
-		numPFS, dropped, err := client.GetFillingStations(repo.InsertPFS)
-		if err != nil {
-			log.Printf("Error fetching PFS: %v (dropped: %d) \n", err, dropped)
-			return
+	if _, err := c.AddFunc(CRON_SCHEDULE_BOUNDS, func() {
+		if err := repo.RefreshPriceBounds(); err != nil {
+			log.Printf("Error refreshing price bounds: %v\n", err)
 		}
-		log.Printf("Inserted %d PFS", numPFS)
 	}); err != nil {
 		return nil, err
 	}
 
-	if _, err := c.AddFunc(CRON_SCHEDULE_PRICES, func() {
-		numPrices, dropped, err := client.GetFuelPrices(repo.InsertPrices)
-		if err != nil {
-			log.Printf("Error fetching fuel prices: %v\n", err)
-			return
+	for _, source := range sources {
+		pfsSchedule, pricesSchedule := CRON_SCHEDULE_PFS, CRON_SCHEDULE_PRICES
+		if scheduled, ok := source.(Scheduled); ok {
+			stations, prices := scheduled.Schedules()
+			if stations != "" {
+				pfsSchedule = stations
+			}
+			if prices != "" {
+				pricesSchedule = prices
+			}
+		}
+
+		log.Printf("Starting CRON jobs to update petrol filling stations and fuel prices from %s", source.Name())
+		source := TrackImports(source, repo, models.ImportTriggerCron)
+
+		refreshStations := func(ctx context.Context) {
+			numPFS, dropped, err := RefreshStations(ctx, source, repo, threshold)
+			if err != nil {
+				log.Printf("Error fetching PFS from %s: %v (dropped: %d) \n", source.Name(), err, dropped)
+				return
+			}
+			log.Printf("Inserted %d PFS from %s", numPFS, source.Name())
+		}
+		if _, err := c.AddFunc(pfsSchedule, func() {
+			refreshStations(ctx)
+		}); err != nil {
+			return nil, err
+		}
+		if _, err := c.AddFunc(CRON_SCHEDULE_PFS_FULL, func() {
+			refreshStations(WithFullRefresh(ctx))
+		}); err != nil {
+			return nil, err
+		}
+
+		if _, err := c.AddFunc(pricesSchedule, func() {
+			numPrices, dropped, err := source.GetFuelPrices(ctx, repo.InsertPrices)
+			if err != nil {
+				log.Printf("Error fetching fuel prices from %s: %v\n", source.Name(), err)
+				return
+			}
+			log.Printf("Inserted %d fuel prices from %s (dropped: %d) ", numPrices, source.Name(), dropped)
+		}); err != nil {
+			return nil, err
 		}
-		log.Printf("Inserted %d fuel prices (dropped: %d) ", numPrices, dropped)
-	}); err != nil {
-		return nil, err
 	}
 
 	c.Start()
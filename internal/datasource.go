@@ -0,0 +1,112 @@
+package internal
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+
+	"github.com/rm-hull/fuel-prices-api/internal/models"
+)
+
+// DataSource is a feed of stations and prices which can be ingested through
+// the same callbacks as the GOV.UK Fuel Finder API. The name of the source is
+// recorded against everything it produces.
+type DataSource interface {
+	Name() string
+	GetFillingStations(context.Context, BatchCallback[models.PetrolFillingStation]) (int, int, error)
+	GetFuelPrices(context.Context, BatchCallback[models.ForecourtPrices]) (int, int, error)
+}
+
+// Scheduled may be implemented by a data source to override the default CRON
+// schedules used to fetch from it.
+type Scheduled interface {
+	Schedules() (stations string, prices string)
+}
+
+// SourceConfig configures a single data source. Kind selects the registered
+// implementation, and Name is what its stations and prices are tagged with.
+type SourceConfig struct {
+	Name             string `json:"name"`
+	Kind             string `json:"kind"`
+	Path             string `json:"path"`
+	StationsSchedule string `json:"stations_schedule,omitempty"`
+	PricesSchedule   string `json:"prices_schedule,omitempty"`
+}
+
+type DataSourceFactory func(cfg SourceConfig) (DataSource, error)
+
+var (
+	registryMu sync.RWMutex
+	registry   = make(map[string]DataSourceFactory)
+)
+
+// RegisterDataSource makes a kind of data source available to
+// NewDataSource. It panics if the kind is registered twice.
+func RegisterDataSource(kind string, factory DataSourceFactory) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	if _, exists := registry[kind]; exists {
+		panic("data source kind already registered: " + kind)
+	}
+	registry[kind] = factory
+}
+
+// DataSourceKinds returns the registered kinds of data source, sorted.
+func DataSourceKinds() []string {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	kinds := make([]string, 0, len(registry))
+	for kind := range registry {
+		kinds = append(kinds, kind)
+	}
+	sort.Strings(kinds)
+	return kinds
+}
+
+func NewDataSource(cfg SourceConfig) (DataSource, error) {
+	registryMu.RLock()
+	factory, exists := registry[cfg.Kind]
+	registryMu.RUnlock()
+
+	if !exists {
+		return nil, fmt.Errorf("unknown kind of data source %q for %s (available: %v)", cfg.Kind, cfg.Name, DataSourceKinds())
+	}
+	return factory(cfg)
+}
+
+// LoadDataSources reads a JSON array of source configs and creates each of
+// the sources in it.
+func LoadDataSources(path string) ([]DataSource, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read data source config %s: %w", path, err)
+	}
+
+	var configs []SourceConfig
+	if err := json.Unmarshal(data, &configs); err != nil {
+		return nil, fmt.Errorf("failed to parse data source config %s: %w", path, err)
+	}
+
+	names := make(map[string]struct{}, len(configs))
+	sources := make([]DataSource, 0, len(configs))
+	for _, cfg := range configs {
+		if cfg.Name == "" || cfg.Name == models.SourceFuelFinder {
+			return nil, fmt.Errorf("invalid data source name %q in %s", cfg.Name, path)
+		}
+		if _, duplicate := names[cfg.Name]; duplicate {
+			return nil, fmt.Errorf("duplicate data source name %q in %s", cfg.Name, path)
+		}
+		names[cfg.Name] = struct{}{}
+
+		source, err := NewDataSource(cfg)
+		if err != nil {
+			return nil, err
+		}
+		sources = append(sources, source)
+	}
+	return sources, nil
+}
@@ -0,0 +1,131 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/rm-hull/fuel-prices-api/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type stubDataSource struct {
+	cfg SourceConfig
+}
+
+func (src *stubDataSource) Name() string {
+	return src.cfg.Name
+}
+
+func (src *stubDataSource) GetFillingStations(ctx context.Context, callback BatchCallback[models.PetrolFillingStation]) (int, int, error) {
+	return callback(ctx, []models.PetrolFillingStation{{NodeId: src.cfg.Name + ":1", Source: src.cfg.Name}})
+}
+
+func (src *stubDataSource) GetFuelPrices(ctx context.Context, callback BatchCallback[models.ForecourtPrices]) (int, int, error) {
+	return callback(ctx, nil)
+}
+
+func init() {
+	RegisterDataSource("stub", func(cfg SourceConfig) (DataSource, error) {
+		return &stubDataSource{cfg: cfg}, nil
+	})
+}
+
+func writeSourceConfig(t *testing.T, content string) string {
+	path := filepath.Join(t.TempDir(), "sources.json")
+	require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	return path
+}
+
+func TestLoadDataSources(t *testing.T) {
+	sources, err := LoadDataSources(writeSourceConfig(t, `[
+		{"name": "one", "kind": "stub"},
+		{"name": "two", "kind": "stub", "path": "./data"}
+	]`))
+	require.NoError(t, err)
+	require.Len(t, sources, 2)
+	assert.Equal(t, "one", sources[0].Name())
+	assert.Equal(t, "./data", sources[1].(*stubDataSource).cfg.Path)
+	assert.Contains(t, DataSourceKinds(), "stub")
+}
+
+func TestLoadDataSources_Invalid(t *testing.T) {
+	for name, content := range map[string]string{
+		"unknown kind":   `[{"name": "one", "kind": "nope"}]`,
+		"duplicate name": `[{"name": "one", "kind": "stub"}, {"name": "one", "kind": "stub"}]`,
+		"reserved name":  `[{"name": "fuel-finder", "kind": "stub"}]`,
+		"missing name":   `[{"kind": "stub"}]`,
+		"malformed":      `{`,
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := LoadDataSources(writeSourceConfig(t, content))
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestRegisterDataSource_Duplicate(t *testing.T) {
+	assert.Panics(t, func() {
+		RegisterDataSource("stub", nil)
+	})
+}
+
+func TestDataSourceTagsStoredRows(t *testing.T) {
+	repo := setupTestDB(t)
+	source, err := NewDataSource(SourceConfig{Name: "fr", Kind: "stub"})
+	require.NoError(t, err)
+
+	numPFS, _, err := source.GetFillingStations(t.Context(), repo.InsertPFS)
+	require.NoError(t, err)
+	assert.Equal(t, 1, numPFS)
+
+	var stored string
+	require.NoError(t, repo.(*sqlRepository).db.QueryRow("SELECT source FROM petrol_filling_stations WHERE node_id = 'fr:1'").Scan(&stored))
+	assert.Equal(t, "fr", stored)
+}
+
+func TestTrackImports(t *testing.T) {
+	repo := setupTestDB(t)
+	source, err := NewDataSource(SourceConfig{Name: "fr", Kind: "stub"})
+	require.NoError(t, err)
+	tracked := TrackImports(source, repo, models.ImportTriggerCommand)
+
+	_, _, err = tracked.GetFillingStations(t.Context(), repo.InsertPFS)
+	require.NoError(t, err)
+
+	_, _, err = tracked.GetFuelPrices(t.Context(), func(context.Context, []models.ForecourtPrices) (int, int, error) {
+		return 0, 0, errors.New("boom")
+	})
+	require.Error(t, err)
+
+	runs, err := repo.ImportRuns(models.ImportRunFilter{Source: "fr"}, 10, 0)
+	require.NoError(t, err)
+	require.Len(t, runs, 2)
+
+	failed := runs[0]
+	assert.Equal(t, models.ImportJobPrices, failed.Job)
+	assert.Equal(t, models.ImportFailed, failed.Status)
+	require.NotNil(t, failed.Error)
+	assert.Equal(t, "boom", *failed.Error)
+	assert.Equal(t, 0, failed.Batches)
+
+	runs, err = repo.ImportRuns(models.ImportRunFilter{Status: models.ImportSucceeded}, 10, 0)
+	require.NoError(t, err)
+	require.Len(t, runs, 1)
+	assert.Equal(t, models.ImportJobStations, runs[0].Job)
+	assert.Equal(t, models.ImportTriggerCommand, runs[0].TriggeredBy)
+	assert.Equal(t, 1, runs[0].Inserted)
+	assert.NotNil(t, runs[0].FinishedAt)
+
+	run, err := repo.ImportRun(runs[0].Id)
+	require.NoError(t, err)
+	assert.Equal(t, 1, run.Batches)
+	require.Len(t, run.BatchDetails, 1)
+	assert.Equal(t, 1, run.BatchDetails[0].Records)
+
+	_, err = repo.ImportRun(run.Id + 100)
+	assert.ErrorIs(t, err, ErrNotFound)
+}
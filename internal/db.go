@@ -2,17 +2,35 @@ package internal
 
 import (
 	"database/sql"
-	_ "embed"
+	"errors"
 	"fmt"
 	"log"
+	"net/url"
+	"path"
 	"strings"
 
 	"github.com/golang-migrate/migrate/v4"
+	_ "github.com/golang-migrate/migrate/v4/database/postgres"
 	_ "github.com/golang-migrate/migrate/v4/database/sqlite3"
 	_ "github.com/golang-migrate/migrate/v4/source/file"
+	"github.com/lib/pq"
 	_ "github.com/mattn/go-sqlite3"
 )
 
+// IsPostgres reports whether the --db DSN names a PostgreSQL database, by its
+// postgres:// or postgresql:// scheme. Anything else is a SQLite file path.
+func IsPostgres(dsn string) bool {
+	return strings.HasPrefix(dsn, "postgres://") || strings.HasPrefix(dsn, "postgresql://")
+}
+
+// queriesFor returns the SQL statements for the database the connection is to.
+func queriesFor(db *sql.DB) *queries {
+	if _, ok := db.Driver().(*pq.Driver); ok {
+		return postgresQueries
+	}
+	return sqliteQueries
+}
+
 type migrateLogger struct{}
 
 func (l *migrateLogger) Printf(format string, v ...interface{}) {
@@ -23,8 +41,15 @@ func (l *migrateLogger) Verbose() bool {
 	return true
 }
 
+// Migrate applies the migrations for the database's dialect. PostgreSQL has
+// its own, in the postgres directory of migrationsPath.
 func Migrate(migrationsPath, dbPath string) error {
-	m, err := migrate.New("file://"+migrationsPath, "sqlite3://"+dbPath)
+	source, database := "file://"+migrationsPath, "sqlite3://"+dbPath
+	if IsPostgres(dbPath) {
+		source, database = "file://"+path.Join(migrationsPath, "postgres"), dbPath
+	}
+
+	m, err := migrate.New(source, database)
 	if err != nil {
 		return err
 	}
@@ -44,6 +69,10 @@ func Migrate(migrationsPath, dbPath string) error {
 }
 
 func Connect(dbPath string) (*sql.DB, error) {
+	if IsPostgres(dbPath) {
+		return connectPostgres(dbPath)
+	}
+
 	dsn := dbPath
 	if strings.Contains(dsn, "?") {
 		dsn += "&"
@@ -63,3 +92,39 @@ func Connect(dbPath string) (*sql.DB, error) {
 	log.Printf("connected to database: %s", dsn)
 	return db, nil
 }
+
+// connectPostgres opens a PostgreSQL database. Unless the DSN says otherwise,
+// the session is in UTC, as SQLite's timestamps are.
+func connectPostgres(dsn string) (*sql.DB, error) {
+	u, err := url.Parse(dsn)
+	if err != nil {
+		// The parse error would repeat the DSN, password and all.
+		return nil, errors.New("failed to parse PostgreSQL DSN")
+	}
+	params := u.Query()
+	if !params.Has("timezone") {
+		params.Set("timezone", "UTC")
+		u.RawQuery = params.Encode()
+	}
+
+	db, err := sql.Open("postgres", u.String())
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database: %w", err)
+	}
+
+	if err = db.Ping(); err != nil {
+		_ = db.Close()
+		return nil, fmt.Errorf("failed to connect to database: %w", err)
+	}
+	log.Printf("connected to database: %s", redactDSN(dsn))
+	return db, nil
+}
+
+// redactDSN hides the password in a PostgreSQL DSN, for logging.
+func redactDSN(dsn string) string {
+	u, err := url.Parse(dsn)
+	if err != nil {
+		return "postgres://"
+	}
+	return u.Redacted()
+}
@@ -0,0 +1,70 @@
+package internal
+
+import (
+	"context"
+	"log"
+
+	"github.com/rm-hull/fuel-prices-api/internal/models"
+)
+
+// TrackImports wraps a data source so that every fetch of stations or prices
+// from it is recorded in the import_runs ledger. A failure to write to the
+// ledger is logged, but never fails the import itself.
+func TrackImports(source DataSource, repo FuelPricesRepository, triggeredBy string) DataSource {
+	return &trackedSource{DataSource: source, repo: repo, triggeredBy: triggeredBy}
+}
+
+type trackedSource struct {
+	DataSource
+	repo        FuelPricesRepository
+	triggeredBy string
+}
+
+func (src *trackedSource) GetFillingStations(ctx context.Context, callback BatchCallback[models.PetrolFillingStation]) (int, int, error) {
+	return trackImport(ctx, src, models.ImportJobStations, src.DataSource.GetFillingStations, callback)
+}
+
+func (src *trackedSource) GetFuelPrices(ctx context.Context, callback BatchCallback[models.ForecourtPrices]) (int, int, error) {
+	return trackImport(ctx, src, models.ImportJobPrices, src.DataSource.GetFuelPrices, callback)
+}
+
+func trackImport[T any](
+	ctx context.Context,
+	src *trackedSource,
+	job string,
+	fetch func(context.Context, BatchCallback[T]) (int, int, error),
+	callback BatchCallback[T],
+) (int, int, error) {
+	run := &models.ImportRun{Job: job, Source: src.Name(), TriggeredBy: src.triggeredBy}
+	if err := src.repo.StartImportRun(ctx, run); err != nil {
+		log.Printf("WARNING: failed to record %s import from %s: %v", job, src.Name(), err)
+		return fetch(ctx, callback)
+	}
+
+	batches := 0
+	count, dropped, err := fetch(ctx, func(ctx context.Context, batch []T) (int, int, error) {
+		numRecords, numDropped, err := callback(ctx, batch)
+		if err != nil {
+			return numRecords, numDropped, err
+		}
+
+		batches++
+		record := models.ImportRunBatch{Number: batches, Records: numRecords, Dropped: numDropped}
+		if info, ok := BatchInfoFrom(ctx); ok {
+			record.Number = info.Number
+			record.Path = &info.Path
+			record.FetchedAt = &info.FetchedAt
+		}
+		if err := src.repo.RecordImportBatch(ctx, run.Id, record); err != nil {
+			log.Printf("WARNING: failed to record batch %d of import run %d: %v", record.Number, run.Id, err)
+		}
+		return numRecords, numDropped, nil
+	})
+
+	// The run is recorded even when it ended because ctx was cancelled.
+	run.Inserted, run.Dropped = count, dropped
+	if finishErr := src.repo.FinishImportRun(context.WithoutCancel(ctx), run, err); finishErr != nil {
+		log.Printf("WARNING: failed to record the end of import run %d: %v", run.Id, finishErr)
+	}
+	return count, dropped, err
+}
@@ -16,6 +16,9 @@ type ClientFetchMetrics struct {
 	ResponseStatusCode *prometheus.CounterVec
 	ItemsFetchedTotal  *prometheus.CounterVec
 	ItemsDroppedTotal  *prometheus.CounterVec
+	RetriesTotal       *prometheus.CounterVec
+	SchemaDriftTotal   *prometheus.CounterVec
+	BreakerState       prometheus.Gauge
 }
 
 func NewClientFetchMetrics(reg prometheus.Registerer) *ClientFetchMetrics {
@@ -49,6 +52,26 @@ func NewClientFetchMetrics(reg prometheus.Registerer) *ClientFetchMetrics {
 			},
 			[]string{"path"},
 		),
+		RetriesTotal: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "fuel_prices_govuk_api_http_retries_total",
+				Help: "GOV.UK fuel finder client API total number of HTTP requests retried, by reason.",
+			},
+			[]string{"path", "method", "reason"},
+		),
+		SchemaDriftTotal: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "fuel_prices_govuk_api_schema_drift_total",
+				Help: "GOV.UK fuel finder client API total number of records deviating from the expected schema, by kind and field.",
+			},
+			[]string{"path", "kind", "field"},
+		),
+		BreakerState: prometheus.NewGauge(
+			prometheus.GaugeOpts{
+				Name: "fuel_prices_govuk_api_circuit_breaker_state",
+				Help: "GOV.UK fuel finder client API circuit breaker state: 0 = closed, 1 = half-open, 2 = open.",
+			},
+		),
 	}
 
 	RegisterOrPanic(reg,
@@ -56,19 +79,26 @@ func NewClientFetchMetrics(reg prometheus.Registerer) *ClientFetchMetrics {
 		m.ResponseStatusCode,
 		m.ItemsFetchedTotal,
 		m.ItemsDroppedTotal,
+		m.RetriesTotal,
+		m.SchemaDriftTotal,
+		m.BreakerState,
 	)
 
 	return m
 }
 
+func endpointPath(endpoint string) string {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		log.Printf("failed to parse endpoint URL '%s' for metrics: %v", endpoint, err)
+		return "invalid_url"
+	}
+	return u.Path
+}
+
 func (m *ClientFetchMetrics) RecordHttpCall(start time.Time, method, endpoint string, resp *http.Response, err error) {
 	if m != nil {
-		u, parseErr := url.Parse(endpoint)
-		path := u.Path
-		if parseErr != nil {
-			log.Printf("failed to parse endpoint URL '%s' for metrics: %v", endpoint, parseErr)
-			path = "invalid_url"
-		}
+		path := endpointPath(endpoint)
 		m.ResponseLatency.WithLabelValues(path, method).Observe(time.Since(start).Seconds())
 		if err == nil {
 			m.ResponseStatusCode.WithLabelValues(path, method, strconv.Itoa(resp.StatusCode)).Inc()
@@ -89,3 +119,24 @@ func (m *ClientFetchMetrics) RecordFetchedItems(path string, count int, dropped
 		m.ItemsDroppedTotal.WithLabelValues(path).Add(float64(dropped))
 	}
 }
+
+func (m *ClientFetchMetrics) RecordRetry(method, endpoint, reason string) {
+	if m == nil {
+		return
+	}
+	m.RetriesTotal.WithLabelValues(endpointPath(endpoint), method, reason).Inc()
+}
+
+func (m *ClientFetchMetrics) RecordSchemaDrift(path, kind, field string, occurrences int) {
+	if m == nil {
+		return
+	}
+	m.SchemaDriftTotal.WithLabelValues(path, kind, field).Add(float64(occurrences))
+}
+
+func (m *ClientFetchMetrics) RecordBreakerState(state int) {
+	if m == nil {
+		return
+	}
+	m.BreakerState.Set(float64(state))
+}
@@ -0,0 +1,47 @@
+package metrics
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// ImportMetrics tracks when each import job last succeeded, and how long its
+// most recent run took.
+type ImportMetrics struct {
+	LastSuccess *prometheus.GaugeVec
+	Duration    *prometheus.GaugeVec
+}
+
+func NewImportMetrics(reg prometheus.Registerer) *ImportMetrics {
+	m := &ImportMetrics{
+		LastSuccess: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "fuel_prices_import_last_success_timestamp_seconds",
+				Help: "Unix time at which the import job last finished successfully.",
+			},
+			[]string{"job", "source"},
+		),
+		Duration: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "fuel_prices_import_duration_seconds",
+				Help: "Duration of the most recent run of the import job, whether or not it succeeded.",
+			},
+			[]string{"job", "source"},
+		),
+	}
+
+	RegisterOrPanic(reg, m.LastSuccess, m.Duration)
+
+	return m
+}
+
+func (m *ImportMetrics) RecordRun(job, source string, finishedAt time.Time, duration time.Duration, succeeded bool) {
+	if m == nil {
+		return
+	}
+	m.Duration.WithLabelValues(job, source).Set(duration.Seconds())
+	if succeeded {
+		m.LastSuccess.WithLabelValues(job, source).Set(float64(finishedAt.Unix()))
+	}
+}
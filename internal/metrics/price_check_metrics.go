@@ -0,0 +1,62 @@
+package metrics
+
+import "github.com/prometheus/client_golang/prometheus"
+
+// PriceCheckMetrics counts incoming prices which were quarantined or flagged
+// as anomalous, and the outcome of reviewing quarantined ones.
+type PriceCheckMetrics struct {
+	Quarantined *prometheus.CounterVec
+	Reviewed    *prometheus.CounterVec
+	Anomalies   *prometheus.CounterVec
+}
+
+func NewPriceCheckMetrics(reg prometheus.Registerer) *PriceCheckMetrics {
+	m := &PriceCheckMetrics{
+		Quarantined: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "fuel_prices_quarantined_total",
+				Help: "Number of fuel prices written to the price_quarantine table, by reason.",
+			},
+			[]string{"reason", "source"},
+		),
+		Reviewed: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "fuel_prices_quarantine_reviews_total",
+				Help: "Number of quarantined fuel prices approved or rejected.",
+			},
+			[]string{"decision"},
+		),
+		Anomalies: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "fuel_prices_anomalies_total",
+				Help: "Number of stored fuel prices flagged as anomalous, by kind.",
+			},
+			[]string{"kind"},
+		),
+	}
+
+	RegisterOrPanic(reg, m.Quarantined, m.Reviewed, m.Anomalies)
+
+	return m
+}
+
+func (m *PriceCheckMetrics) RecordQuarantined(reason, source string) {
+	if m == nil {
+		return
+	}
+	m.Quarantined.WithLabelValues(reason, source).Inc()
+}
+
+func (m *PriceCheckMetrics) RecordReview(decision string) {
+	if m == nil {
+		return
+	}
+	m.Reviewed.WithLabelValues(decision).Inc()
+}
+
+func (m *PriceCheckMetrics) RecordAnomaly(kind string) {
+	if m == nil {
+		return
+	}
+	m.Anomalies.WithLabelValues(kind).Inc()
+}
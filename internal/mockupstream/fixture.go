@@ -0,0 +1,138 @@
+package mockupstream
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/rm-hull/fuel-prices-api/internal/models"
+)
+
+// Fixture is the data served by the mock upstream. It can either be loaded
+// from a JSON file with the same shape, or generated.
+type Fixture struct {
+	Stations []models.PetrolFillingStation `json:"stations"`
+	Prices   []models.ForecourtPrices      `json:"prices"`
+}
+
+func LoadFixture(path string) (*Fixture, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read fixture %s: %w", path, err)
+	}
+
+	var fixture Fixture
+	if err := json.Unmarshal(data, &fixture); err != nil {
+		return nil, fmt.Errorf("failed to parse fixture %s: %w", path, err)
+	}
+	return &fixture, nil
+}
+
+type generatorArea struct {
+	postcodeArea string
+	city         string
+	county       string
+	latitude     float64
+	longitude    float64
+}
+
+var generatorAreas = []generatorArea{
+	{"LS", "Leeds", "West Yorkshire", 53.80, -1.55},
+	{"M", "Manchester", "Greater Manchester", 53.48, -2.24},
+	{"B", "Birmingham", "West Midlands", 52.49, -1.89},
+	{"OX", "Oxford", "Oxfordshire", 51.75, -1.26},
+	{"EH", "Edinburgh", "Midlothian", 55.95, -3.19},
+	{"CF", "Cardiff", "South Glamorgan", 51.48, -3.18},
+	{"BT", "Belfast", "County Antrim", 54.60, -5.93},
+	{"EX", "Exeter", "Devon", 50.72, -3.53},
+	{"NR", "Norwich", "Norfolk", 52.63, 1.30},
+	{"SW", "London", "Greater London", 51.47, -0.17},
+}
+
+var generatorBrands = []string{"ASDA", "BP", "ESSO", "JET", "MORRISONS", "SAINSBURYS", "SHELL", "TESCO", "TEXACO", "GULF"}
+
+var generatorFuels = []struct {
+	fuelType string
+	price    float64
+}{
+	{"E10", 135.9},
+	{"E5", 145.9},
+	{"B7_STANDARD", 142.9},
+	{"B7_PREMIUM", 155.9},
+}
+
+// Generate builds a deterministic fixture of numStations stations spread
+// across a handful of UK postcode areas, each with a current price for every
+// fuel type it sells.
+func Generate(numStations int, seed uint64, now time.Time) *Fixture {
+	rng := rand.New(rand.NewPCG(seed, seed))
+	fixture := &Fixture{
+		Stations: make([]models.PetrolFillingStation, 0, numStations),
+		Prices:   make([]models.ForecourtPrices, 0, numStations),
+	}
+
+	for i := range numStations {
+		area := generatorAreas[rng.IntN(len(generatorAreas))]
+		brand := generatorBrands[rng.IntN(len(generatorBrands))]
+		hash := sha256.Sum256([]byte(strconv.Itoa(i)))
+		nodeId := hex.EncodeToString(hash[:])
+		postcode := fmt.Sprintf("%s%d %d%c%c", area.postcodeArea, 1+rng.IntN(20), rng.IntN(10), 'A'+rng.IntN(26), 'A'+rng.IntN(26))
+		tradingName := fmt.Sprintf("%s %s %d", brand, area.city, i)
+
+		pfs := models.PetrolFillingStation{
+			NodeId:                      nodeId,
+			MftOrganisationName:         brand + " LTD",
+			PublicPhoneNumber:           fmt.Sprintf("0%010d", rng.IntN(1e10)),
+			TradingName:                 tradingName,
+			BrandName:                   brand,
+			IsSupermarketServiceStation: rng.IntN(4) == 0,
+			IsMotorwayServiceStation:    rng.IntN(20) == 0,
+			Location: models.Location{
+				AddressLine1: fmt.Sprintf("%d High Street", 1+rng.IntN(200)),
+				City:         area.city,
+				Country:      "United Kingdom",
+				County:       area.county,
+				Postcode:     postcode,
+				Latitude:     round(area.latitude+rng.Float64()*0.2-0.1, 6),
+				Longitude:    round(area.longitude+rng.Float64()*0.3-0.15, 6),
+			},
+			Amenities: []string{"customer_toilets", "car_wash"}[:rng.IntN(3)],
+		}
+
+		forecourt := models.ForecourtPrices{
+			NodeId:              nodeId,
+			MftOrganisationName: pfs.MftOrganisationName,
+			PublicPhoneNumber:   pfs.PublicPhoneNumber,
+			TradingName:         tradingName,
+		}
+		for _, fuel := range generatorFuels {
+			if fuel.fuelType == "B7_PREMIUM" && rng.IntN(2) == 0 {
+				continue
+			}
+			updated := now.Add(-time.Duration(rng.IntN(72*60)) * time.Minute).UTC().Truncate(time.Second)
+			pfs.FuelTypes = append(pfs.FuelTypes, fuel.fuelType)
+			forecourt.FuelPrices = append(forecourt.FuelPrices, models.FuelPrice{
+				FuelType:                      fuel.fuelType,
+				Price:                         round(fuel.price+rng.Float64()*10-5, 1),
+				PriceLastUpdated:              updated,
+				PriceChangeEffectiveTimestamp: &updated,
+			})
+		}
+
+		fixture.Stations = append(fixture.Stations, pfs)
+		fixture.Prices = append(fixture.Prices, forecourt)
+	}
+
+	return fixture
+}
+
+func round(value float64, places int) float64 {
+	scale := math.Pow10(places)
+	return math.Round(value*scale) / scale
+}
@@ -0,0 +1,416 @@
+package mockupstream
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"log"
+	mathrand "math/rand/v2"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/rm-hull/fuel-prices-api/internal/models"
+)
+
+// Config controls the behaviour of the mock upstream.
+type Config struct {
+	// ClientId and ClientSecret are the only credentials accepted. If either
+	// is empty, any credentials are accepted.
+	ClientId     string
+	ClientSecret string
+	BatchSize    int
+	TokenTTL     time.Duration
+	// WithMetaData wraps batches in an envelope carrying total_batches, rather
+	// than returning a bare JSON array.
+	WithMetaData bool
+	// ErrorRate and MalformedRate are the probabilities (0..1) of any batch
+	// request failing with a 503, or returning truncated JSON respectively.
+	ErrorRate     float64
+	MalformedRate float64
+	Seed          uint64
+}
+
+// Faults are one-off failures injected into the next batch requests, on
+// top of any random failures configured.
+type Faults struct {
+	FailNext      int `json:"fail_next"`
+	FailStatus    int `json:"fail_status"`
+	RetryAfter    int `json:"retry_after"`
+	MalformedNext int `json:"malformed_next"`
+}
+
+type station struct {
+	pfs       models.PetrolFillingStation
+	updatedAt time.Time
+}
+
+type token struct {
+	clientId  string
+	expiresAt time.Time
+}
+
+// Server emulates the subset of the GOV.UK Fuel Finder API used by
+// FuelPricesClient.
+type Server struct {
+	cfg Config
+
+	mu            sync.Mutex
+	rng           *mathrand.Rand
+	stations      []station
+	prices        []models.ForecourtPrices
+	accessTokens  map[string]token
+	refreshTokens map[string]string
+	faults        Faults
+}
+
+func New(cfg Config, fixture *Fixture) *Server {
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = 500
+	}
+	if cfg.TokenTTL <= 0 {
+		cfg.TokenTTL = time.Hour
+	}
+
+	loadedAt := time.Now().UTC()
+	stations := make([]station, 0, len(fixture.Stations))
+	for _, pfs := range fixture.Stations {
+		stations = append(stations, station{pfs: pfs, updatedAt: loadedAt})
+	}
+
+	return &Server{
+		cfg:           cfg,
+		rng:           mathrand.New(mathrand.NewPCG(cfg.Seed, cfg.Seed)),
+		stations:      stations,
+		prices:        fixture.Prices,
+		accessTokens:  make(map[string]token),
+		refreshTokens: make(map[string]string),
+	}
+}
+
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /oauth/generate_access_token", s.generateAccessToken)
+	mux.HandleFunc("POST /oauth/regenerate_access_token", s.regenerateAccessToken)
+	mux.HandleFunc("GET /pfs", s.authorized(s.fillingStations))
+	mux.HandleFunc("GET /pfs/fuel-prices", s.authorized(s.fuelPrices))
+
+	mux.HandleFunc("POST /_mock/faults", s.injectFaults)
+	mux.HandleFunc("POST /_mock/expire-tokens", s.expireTokens)
+	mux.HandleFunc("POST /_mock/tick", s.tick)
+	return mux
+}
+
+// InjectFaults replaces any outstanding one-off faults.
+func (s *Server) InjectFaults(faults Faults) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if faults.FailNext > 0 && faults.FailStatus == 0 {
+		faults.FailStatus = http.StatusServiceUnavailable
+	}
+	s.faults = faults
+}
+
+// ExpireTokens invalidates every access token issued so far, while leaving
+// refresh tokens usable.
+func (s *Server) ExpireTokens() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for accessToken, t := range s.accessTokens {
+		t.expiresAt = time.Time{}
+		s.accessTokens[accessToken] = t
+	}
+}
+
+// Tick changes the price of up to n randomly chosen fuels, marking them as
+// updated now, so that incremental fetches have something to pick up.
+func (s *Server) Tick(n int) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now().UTC().Truncate(time.Second)
+	changed := 0
+	for range n {
+		if len(s.prices) == 0 {
+			break
+		}
+		forecourt := &s.prices[s.rng.IntN(len(s.prices))]
+		if len(forecourt.FuelPrices) == 0 {
+			continue
+		}
+		fp := &forecourt.FuelPrices[s.rng.IntN(len(forecourt.FuelPrices))]
+		fp.Price = round(fp.Price+float64(s.rng.IntN(7)-3), 1)
+		fp.PriceLastUpdated = now
+		fp.PriceChangeEffectiveTimestamp = &now
+		changed++
+	}
+	return changed
+}
+
+func (s *Server) generateAccessToken(w http.ResponseWriter, r *http.Request) {
+	var req models.AuthRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, models.AuthResponse{Message: "invalid request body"})
+		return
+	}
+
+	if s.cfg.ClientId != "" && s.cfg.ClientSecret != "" &&
+		(req.ClientId != s.cfg.ClientId || req.ClientSecret != s.cfg.ClientSecret) {
+		writeJSON(w, http.StatusUnauthorized, models.AuthResponse{Message: "invalid client credentials"})
+		return
+	}
+
+	s.mu.Lock()
+	accessToken := s.issueAccessToken(req.ClientId)
+	refreshToken := randomToken()
+	s.refreshTokens[refreshToken] = req.ClientId
+	s.mu.Unlock()
+
+	writeJSON(w, http.StatusOK, models.AuthResponse{
+		Success: true,
+		Data: models.TokenData{
+			AccessToken:  accessToken,
+			TokenType:    "Bearer",
+			ExpiresIn:    int(s.cfg.TokenTTL.Seconds()),
+			RefreshToken: refreshToken,
+		},
+	})
+}
+
+func (s *Server) regenerateAccessToken(w http.ResponseWriter, r *http.Request) {
+	var req models.TokenRefreshRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, models.AuthResponse{Message: "invalid request body"})
+		return
+	}
+
+	s.mu.Lock()
+	clientId, ok := s.refreshTokens[req.RefreshToken]
+	var accessToken string
+	if ok && clientId == req.ClientId {
+		accessToken = s.issueAccessToken(clientId)
+	}
+	s.mu.Unlock()
+
+	if accessToken == "" {
+		writeJSON(w, http.StatusUnauthorized, models.AuthResponse{Message: "invalid refresh token"})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, models.AuthResponse{
+		Success: true,
+		Data: models.TokenData{
+			AccessToken: accessToken,
+			TokenType:   "Bearer",
+			ExpiresIn:   int(s.cfg.TokenTTL.Seconds()),
+		},
+	})
+}
+
+// issueAccessToken must be called with the lock held.
+func (s *Server) issueAccessToken(clientId string) string {
+	accessToken := randomToken()
+	s.accessTokens[accessToken] = token{
+		clientId:  clientId,
+		expiresAt: time.Now().Add(s.cfg.TokenTTL),
+	}
+	return accessToken
+}
+
+func (s *Server) authorized(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const prefix = "Bearer "
+		header := r.Header.Get("Authorization")
+		if len(header) <= len(prefix) || header[:len(prefix)] != prefix {
+			writeJSON(w, http.StatusUnauthorized, object{"success": false, "message": "missing bearer token"})
+			return
+		}
+
+		s.mu.Lock()
+		t, ok := s.accessTokens[header[len(prefix):]]
+		s.mu.Unlock()
+
+		if !ok || time.Now().After(t.expiresAt) {
+			writeJSON(w, http.StatusUnauthorized, object{"success": false, "message": "access token is invalid or has expired"})
+			return
+		}
+		next(w, r)
+	}
+}
+
+func (s *Server) fillingStations(w http.ResponseWriter, r *http.Request) {
+	since, batchNo, ok := parseBatchParams(w, r)
+	if !ok {
+		return
+	}
+
+	s.mu.Lock()
+	filtered := make([]models.PetrolFillingStation, 0, len(s.stations))
+	for _, st := range s.stations {
+		if !st.updatedAt.Before(since) {
+			filtered = append(filtered, st.pfs)
+		}
+	}
+	s.mu.Unlock()
+
+	writeBatch(s, w, filtered, batchNo)
+}
+
+func (s *Server) fuelPrices(w http.ResponseWriter, r *http.Request) {
+	since, batchNo, ok := parseBatchParams(w, r)
+	if !ok {
+		return
+	}
+
+	s.mu.Lock()
+	filtered := make([]models.ForecourtPrices, 0, len(s.prices))
+	for _, forecourt := range s.prices {
+		changed := forecourt
+		changed.FuelPrices = nil
+		for _, fp := range forecourt.FuelPrices {
+			if !fp.PriceLastUpdated.Before(since) {
+				changed.FuelPrices = append(changed.FuelPrices, fp)
+			}
+		}
+		if len(changed.FuelPrices) > 0 {
+			filtered = append(filtered, changed)
+		}
+	}
+	s.mu.Unlock()
+
+	writeBatch(s, w, filtered, batchNo)
+}
+
+func parseBatchParams(w http.ResponseWriter, r *http.Request) (time.Time, int, bool) {
+	var since time.Time
+	if ts := r.URL.Query().Get("effective-start-timestamp"); ts != "" {
+		var err error
+		if since, err = time.ParseInLocation(time.DateTime, ts, time.UTC); err != nil {
+			writeJSON(w, http.StatusBadRequest, object{"success": false, "message": "invalid effective-start-timestamp"})
+			return since, 0, false
+		}
+	}
+
+	batchNo := 1
+	if b := r.URL.Query().Get("batch-number"); b != "" {
+		var err error
+		if batchNo, err = strconv.Atoi(b); err != nil || batchNo < 1 {
+			writeJSON(w, http.StatusBadRequest, object{"success": false, "message": "invalid batch-number"})
+			return since, 0, false
+		}
+	}
+	return since, batchNo, true
+}
+
+func writeBatch[T any](s *Server, w http.ResponseWriter, items []T, batchNo int) {
+	if s.injectFailure(w) {
+		return
+	}
+
+	totalBatches := (len(items) + s.cfg.BatchSize - 1) / s.cfg.BatchSize
+	if batchNo > totalBatches {
+		writeJSON(w, http.StatusNotFound, object{"success": false, "message": "batch not found"})
+		return
+	}
+
+	start := (batchNo - 1) * s.cfg.BatchSize
+	end := min(start+s.cfg.BatchSize, len(items))
+
+	var body any = items[start:end]
+	if s.cfg.WithMetaData {
+		body = models.BatchResponse[T]{
+			Data: items[start:end],
+			MetaData: &models.MetaData{
+				BatchNumber:  batchNo,
+				BatchSize:    s.cfg.BatchSize,
+				TotalBatches: totalBatches,
+			},
+		}
+	}
+
+	if s.injectMalformed() {
+		data, _ := json.Marshal(body)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write(data[:len(data)/2])
+		return
+	}
+	writeJSON(w, http.StatusOK, body)
+}
+
+func (s *Server) injectFailure(w http.ResponseWriter) bool {
+	s.mu.Lock()
+	status := 0
+	retryAfter := 0
+	if s.faults.FailNext > 0 {
+		s.faults.FailNext--
+		status = s.faults.FailStatus
+		retryAfter = s.faults.RetryAfter
+	} else if s.cfg.ErrorRate > 0 && s.rng.Float64() < s.cfg.ErrorRate {
+		status = http.StatusServiceUnavailable
+	}
+	s.mu.Unlock()
+
+	if status == 0 {
+		return false
+	}
+	if retryAfter > 0 {
+		w.Header().Set("Retry-After", strconv.Itoa(retryAfter))
+	}
+	writeJSON(w, status, object{"success": false, "message": "injected failure"})
+	return true
+}
+
+func (s *Server) injectMalformed() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.faults.MalformedNext > 0 {
+		s.faults.MalformedNext--
+		return true
+	}
+	return s.cfg.MalformedRate > 0 && s.rng.Float64() < s.cfg.MalformedRate
+}
+
+func (s *Server) injectFaults(w http.ResponseWriter, r *http.Request) {
+	var faults Faults
+	if err := json.NewDecoder(r.Body).Decode(&faults); err != nil {
+		writeJSON(w, http.StatusBadRequest, object{"error": "invalid faults"})
+		return
+	}
+	s.InjectFaults(faults)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) expireTokens(w http.ResponseWriter, r *http.Request) {
+	s.ExpireTokens()
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) tick(w http.ResponseWriter, r *http.Request) {
+	n := 10
+	if c := r.URL.Query().Get("changes"); c != "" {
+		var err error
+		if n, err = strconv.Atoi(c); err != nil || n < 0 {
+			writeJSON(w, http.StatusBadRequest, object{"error": "invalid changes parameter"})
+			return
+		}
+	}
+	writeJSON(w, http.StatusOK, object{"changed": s.Tick(n)})
+}
+
+// object is a shorthand for ad-hoc JSON response bodies.
+type object map[string]any
+
+func writeJSON(w http.ResponseWriter, status int, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(body); err != nil {
+		log.Printf("failed to write mock response: %v", err)
+	}
+}
+
+func randomToken() string {
+	b := make([]byte, 24)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
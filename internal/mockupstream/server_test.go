@@ -0,0 +1,133 @@
+package mockupstream
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/rm-hull/fuel-prices-api/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func authenticate(t *testing.T, baseUrl string, req models.AuthRequest) (int, models.AuthResponse) {
+	body, err := json.Marshal(req)
+	require.NoError(t, err)
+	resp, err := http.Post(baseUrl+"/oauth/generate_access_token", "application/json", bytes.NewReader(body))
+	require.NoError(t, err)
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	var authResp models.AuthResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&authResp))
+	return resp.StatusCode, authResp
+}
+
+func get(t *testing.T, baseUrl, path, accessToken string, params url.Values) (*http.Response, []byte) {
+	req, err := http.NewRequest("GET", baseUrl+path+"?"+params.Encode(), nil)
+	require.NoError(t, err)
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	var buf bytes.Buffer
+	_, err = buf.ReadFrom(resp.Body)
+	require.NoError(t, err)
+	return resp, buf.Bytes()
+}
+
+func TestAuthentication(t *testing.T) {
+	server := httptest.NewServer(New(Config{ClientId: "id", ClientSecret: "secret"}, &Fixture{}).Handler())
+	defer server.Close()
+
+	status, _ := authenticate(t, server.URL, models.AuthRequest{ClientId: "id", ClientSecret: "wrong"})
+	assert.Equal(t, http.StatusUnauthorized, status)
+
+	status, authResp := authenticate(t, server.URL, models.AuthRequest{ClientId: "id", ClientSecret: "secret"})
+	assert.Equal(t, http.StatusOK, status)
+	assert.True(t, authResp.Success)
+	assert.Equal(t, 3600, authResp.Data.ExpiresIn)
+	assert.NotEmpty(t, authResp.Data.RefreshToken)
+
+	resp, _ := get(t, server.URL, "/pfs", "not-a-token", nil)
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+}
+
+func TestBatchesAndFiltering(t *testing.T) {
+	now := time.Now().UTC().Truncate(time.Second)
+	fixture := Generate(5, 42, now.Add(-100*time.Hour))
+	mock := New(Config{BatchSize: 2}, fixture)
+	server := httptest.NewServer(mock.Handler())
+	defer server.Close()
+
+	_, authResp := authenticate(t, server.URL, models.AuthRequest{})
+	accessToken := authResp.Data.AccessToken
+
+	var total int
+	for batchNo := 1; ; batchNo++ {
+		resp, body := get(t, server.URL, "/pfs/fuel-prices", accessToken, url.Values{"batch-number": {strconv.Itoa(batchNo)}})
+		if resp.StatusCode == http.StatusNotFound {
+			assert.Equal(t, 4, batchNo)
+			break
+		}
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		var batch []models.ForecourtPrices
+		require.NoError(t, json.Unmarshal(body, &batch))
+		total += len(batch)
+	}
+	assert.Equal(t, 5, total)
+
+	assert.Equal(t, 3, mock.Tick(3))
+	resp, body := get(t, server.URL, "/pfs/fuel-prices", accessToken, url.Values{
+		"batch-number":              {"1"},
+		"effective-start-timestamp": {now.Add(-time.Minute).Format(time.DateTime)},
+	})
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var changed []models.ForecourtPrices
+	require.NoError(t, json.Unmarshal(body, &changed))
+	assert.NotEmpty(t, changed)
+	for _, forecourt := range changed {
+		for _, fp := range forecourt.FuelPrices {
+			assert.False(t, fp.PriceLastUpdated.Before(now.Add(-time.Minute)))
+		}
+	}
+}
+
+func TestFaultInjection(t *testing.T) {
+	mock := New(Config{BatchSize: 10, WithMetaData: true}, Generate(3, 1, time.Now()))
+	server := httptest.NewServer(mock.Handler())
+	defer server.Close()
+
+	_, authResp := authenticate(t, server.URL, models.AuthRequest{})
+	accessToken := authResp.Data.AccessToken
+	params := url.Values{"batch-number": {"1"}}
+
+	mock.InjectFaults(Faults{FailNext: 1, FailStatus: http.StatusBadGateway, MalformedNext: 1})
+
+	resp, _ := get(t, server.URL, "/pfs", accessToken, params)
+	assert.Equal(t, http.StatusBadGateway, resp.StatusCode)
+
+	resp, body := get(t, server.URL, "/pfs", accessToken, params)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.False(t, json.Valid(body))
+
+	resp, body = get(t, server.URL, "/pfs", accessToken, params)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	var batch models.BatchResponse[models.PetrolFillingStation]
+	require.NoError(t, json.Unmarshal(body, &batch))
+	assert.Len(t, batch.Data, 3)
+	assert.Equal(t, 1, batch.MetaData.TotalBatches)
+
+	mock.ExpireTokens()
+	resp, _ = get(t, server.URL, "/pfs", accessToken, params)
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+}
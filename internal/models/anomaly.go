@@ -0,0 +1,28 @@
+package models
+
+import "time"
+
+// Kinds of price anomaly.
+const (
+	AnomalyJump        = "jump"
+	AnomalyAreaOutlier = "area_outlier"
+)
+
+// PriceAnomaly is a stored price which was flagged as anomalous, and so is
+// left out of the statistics.
+type PriceAnomaly struct {
+	NodeId           string    `json:"node_id"`
+	TradingName      *string   `json:"trading_name,omitempty"`
+	Postcode         *string   `json:"postcode,omitempty"`
+	FuelType         string    `json:"fuel_type"`
+	Price            float64   `json:"price"`
+	PriceLastUpdated time.Time `json:"price_last_updated"`
+	Anomaly          string    `json:"anomaly"`
+	Reference        *float64  `json:"reference,omitempty"`
+	Source           string    `json:"source"`
+}
+
+type AnomaliesResponse struct {
+	Results     []PriceAnomaly `json:"results"`
+	Attribution []string       `json:"attribution"`
+}
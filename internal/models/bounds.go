@@ -0,0 +1,93 @@
+package models
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Where a fuel type's price bounds came from.
+const (
+	BoundsDefault    = "default"
+	BoundsConfigured = "configured"
+	BoundsDerived    = "percentile"
+)
+
+// PriceBounds is the range of plausible prices, in pence, for a fuel type.
+// Cleansed prices outside it are treated as input-entry errors.
+type PriceBounds struct {
+	Min    float64 `json:"min"`
+	Max    float64 `json:"max"`
+	Source string  `json:"source"`
+}
+
+func (b PriceBounds) Contains(price float64) bool {
+	return price >= b.Min && price <= b.Max
+}
+
+// PriceBoundsSet holds the bounds for each fuel type, falling back to Default
+// for fuel types without their own.
+type PriceBoundsSet struct {
+	Default     PriceBounds            `json:"default"`
+	FuelTypes   map[string]PriceBounds `json:"fuel_types"`
+	Percentiles []float64              `json:"percentiles,omitempty"`
+	LastUpdated *time.Time             `json:"last_updated,omitempty"`
+}
+
+// DefaultPriceBounds returns the built-in bounds: 100-300p, except for LPG
+// which is sold at roughly half the price of petrol and diesel.
+func DefaultPriceBounds() *PriceBoundsSet {
+	return &PriceBoundsSet{
+		Default: PriceBounds{Min: 100, Max: 300, Source: BoundsDefault},
+		FuelTypes: map[string]PriceBounds{
+			"LPG": {Min: 50, Max: 200, Source: BoundsDefault},
+		},
+	}
+}
+
+// For returns the bounds for the given fuel type. A nil set gives the
+// built-in bounds.
+func (s *PriceBoundsSet) For(fuelType string) PriceBounds {
+	if s == nil {
+		return DefaultPriceBounds().For(fuelType)
+	}
+	if bounds, ok := s.FuelTypes[fuelType]; ok {
+		return bounds
+	}
+	return s.Default
+}
+
+// ParsePriceBounds parses a comma-separated list of FUEL_TYPE=MIN:MAX
+// entries, e.g. "LPG=50:200,E5_PREMIUM=120:350". The fuel type "*" sets the
+// default bounds.
+func ParsePriceBounds(spec string) (map[string]PriceBounds, error) {
+	result := make(map[string]PriceBounds)
+	for entry := range strings.SplitSeq(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		fuelType, limits, ok := strings.Cut(entry, "=")
+		minStr, maxStr, ok2 := strings.Cut(limits, ":")
+		if !ok || !ok2 {
+			return nil, fmt.Errorf("invalid price bounds %q: expected FUEL_TYPE=MIN:MAX", entry)
+		}
+
+		lower, err := strconv.ParseFloat(strings.TrimSpace(minStr), 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid minimum in price bounds %q: %w", entry, err)
+		}
+		upper, err := strconv.ParseFloat(strings.TrimSpace(maxStr), 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid maximum in price bounds %q: %w", entry, err)
+		}
+		if lower < 0 || upper <= lower {
+			return nil, fmt.Errorf("invalid price bounds %q: minimum must be non-negative and less than maximum", entry)
+		}
+
+		result[strings.ToUpper(strings.TrimSpace(fuelType))] = PriceBounds{Min: lower, Max: upper, Source: BoundsConfigured}
+	}
+	return result, nil
+}
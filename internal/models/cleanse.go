@@ -0,0 +1,17 @@
+package models
+
+// CleanseRuleResult counts the rows a cleanse rule changed.
+type CleanseRuleResult struct {
+	Rule        string `json:"rule"`
+	Description string `json:"description"`
+	Updated     int    `json:"updated"`
+	Deleted     int    `json:"deleted"`
+}
+
+// CleanseReport summarises a run of the cleanse rules over stored prices.
+type CleanseReport struct {
+	RunId    string              `json:"run_id"`
+	DryRun   bool                `json:"dry_run"`
+	Examined int                 `json:"examined"`
+	Rules    []CleanseRuleResult `json:"rules"`
+}
@@ -0,0 +1,10 @@
+package models
+
+// CompactReport summarises converting stored price history into one row per
+// change of price.
+type CompactReport struct {
+	DryRun   bool `json:"dry_run"`
+	Examined int  `json:"examined"`
+	Kept     int  `json:"kept"`
+	Removed  int  `json:"removed"`
+}
@@ -0,0 +1,133 @@
+package models
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Kinds of coordinate issue found when validating a station's location
+// against its postcode centroid, or the UK when there is no centroid.
+const (
+	CoordinatesMissing     = "missing"
+	CoordinatesSwapped     = "swapped"
+	CoordinatesMismatch    = "postcode_mismatch"
+	CoordinatesOutOfBounds = "out_of_bounds"
+)
+
+const earthRadiusKm = 6371.0
+
+// A rough bounding box around the UK, from the Scillies to Shetland and
+// Northern Ireland to East Anglia.
+const (
+	ukMinLatitude  = 49.8
+	ukMaxLatitude  = 60.9
+	ukMinLongitude = -8.7
+	ukMaxLongitude = 1.8
+)
+
+// onsMissingLatitude is used by the ONS Postcode Directory for postcodes
+// which have no grid reference.
+const onsMissingLatitude = 99.999999
+
+type PostcodeCentroid struct {
+	Postcode  string
+	Latitude  float64
+	Longitude float64
+}
+
+// NormalisePostcode upper-cases a postcode and removes its spaces, so that
+// "sw1a 1aa" and "SW1A1AA" match.
+func NormalisePostcode(postcode string) string {
+	return strings.ToUpper(strings.Join(strings.Fields(postcode), ""))
+}
+
+// PostcodeCentroidFromCSV maps a row of the ONS Postcode Directory, or any
+// CSV with postcode, latitude and longitude columns, to a centroid. It
+// returns nil for postcodes without coordinates.
+func PostcodeCentroidFromCSV(record, headers []string) (*PostcodeCentroid, error) {
+	field := func(names ...string) (string, error) {
+		for _, name := range names {
+			if i := slices.IndexFunc(headers, func(header string) bool {
+				return strings.EqualFold(strings.TrimPrefix(strings.TrimSpace(header), "\ufeff"), name)
+			}); i >= 0 && i < len(record) {
+				return strings.TrimSpace(record[i]), nil
+			}
+		}
+		return "", fmt.Errorf("missing %s column", names[0])
+	}
+
+	postcode, err := field("pcds", "pcd", "postcode")
+	if err != nil {
+		return nil, err
+	}
+	latValue, err := field("lat", "latitude")
+	if err != nil {
+		return nil, err
+	}
+	lonValue, err := field("long", "longitude", "lon", "lng")
+	if err != nil {
+		return nil, err
+	}
+
+	if latValue == "" || lonValue == "" {
+		return nil, nil
+	}
+	lat, err := strconv.ParseFloat(latValue, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid latitude %q: %w", latValue, err)
+	}
+	lon, err := strconv.ParseFloat(lonValue, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid longitude %q: %w", lonValue, err)
+	}
+	if lat == onsMissingLatitude {
+		return nil, nil
+	}
+
+	return &PostcodeCentroid{Postcode: NormalisePostcode(postcode), Latitude: lat, Longitude: lon}, nil
+}
+
+// InUK reports whether a point falls within a rough bounding box around the
+// UK.
+func InUK(lat, lon float64) bool {
+	return lat >= ukMinLatitude && lat <= ukMaxLatitude && lon >= ukMinLongitude && lon <= ukMaxLongitude
+}
+
+// DistanceKm is the great-circle distance between two points.
+func DistanceKm(lat1, lon1, lat2, lon2 float64) float64 {
+	phi1 := lat1 * math.Pi / 180
+	phi2 := lat2 * math.Pi / 180
+	dPhi := (lat2 - lat1) * math.Pi / 180
+	dLambda := (lon2 - lon1) * math.Pi / 180
+
+	a := math.Sin(dPhi/2)*math.Sin(dPhi/2) +
+		math.Cos(phi1)*math.Cos(phi2)*math.Sin(dLambda/2)*math.Sin(dLambda/2)
+	return 2 * earthRadiusKm * math.Asin(math.Sqrt(a))
+}
+
+// CoordinateIssue is a station whose coordinates did not match its postcode.
+// When they were repaired, Latitude and Longitude are the replacements and
+// the source's coordinates are kept in OriginalLatitude and OriginalLongitude.
+type CoordinateIssue struct {
+	NodeId            string    `json:"node_id"`
+	TradingName       *string   `json:"trading_name,omitempty"`
+	Postcode          *string   `json:"postcode,omitempty"`
+	Issue             string    `json:"issue"`
+	DistanceKm        *float64  `json:"distance_km,omitempty"`
+	Latitude          float64   `json:"latitude"`
+	Longitude         float64   `json:"longitude"`
+	OriginalLatitude  *float64  `json:"original_latitude,omitempty"`
+	OriginalLongitude *float64  `json:"original_longitude,omitempty"`
+	Repaired          bool      `json:"repaired"`
+	Source            string    `json:"source"`
+	UpdatedAt         time.Time `json:"updated_at"`
+}
+
+type CoordinateIssuesResponse struct {
+	Results     []CoordinateIssue `json:"results"`
+	Attribution []string          `json:"attribution"`
+}
@@ -11,6 +11,15 @@ import (
 
 var json = jsoniter.ConfigCompatibleWithStandardLibrary
 
+// SourceFuelFinder is the source recorded against stations and prices that
+// came from the GOV.UK Fuel Finder API, which is assumed when none is set.
+const SourceFuelFinder = "fuel-finder"
+
+// CountryCodeUK is the ISO 3166-1 country code recorded against stations in
+// the UK, which is assumed when none is set. Prices are only comparable with
+// others from the same country, so the UK stats are restricted to it.
+const CountryCodeUK = "GB"
+
 type Location struct {
 	AddressLine1 string  `json:"address_line_1"`
 	AddressLine2 string  `json:"address_line_2,omitempty"`
@@ -51,7 +60,9 @@ type PetrolFillingStation struct {
 			Is24Hours bool   `json:"is_24_hours"`
 		} `json:"bank_holiday"`
 	} `json:"opening_times"`
-	FuelTypes []string `json:"fuel_types"`
+	FuelTypes   []string `json:"fuel_types"`
+	Source      string   `json:"-"`
+	CountryCode string   `json:"-"`
 }
 
 type FuelPrice struct {
@@ -67,6 +78,7 @@ type ForecourtPrices struct {
 	PublicPhoneNumber   string      `json:"public_phone_number"`
 	TradingName         string      `json:"trading_name"`
 	FuelPrices          []FuelPrice `json:"fuel_prices"`
+	Source              string      `json:"-"`
 }
 
 type MetaData struct {
@@ -76,6 +88,11 @@ type MetaData struct {
 	Cached       bool `json:"cached"`
 }
 
+type BatchResponse[T any] struct {
+	Data     []T       `json:"data"`
+	MetaData *MetaData `json:"metadata,omitempty"`
+}
+
 func (pfs *PetrolFillingStation) ToTuple() []any {
 
 	return []any{
@@ -100,13 +117,23 @@ func (pfs *PetrolFillingStation) ToTuple() []any {
 		pfs.Location.Longitude,
 		toJSON(pfs.OpeningTimes),
 		toJSON(pfs.Amenities),
-		toJSON(pfs.FuelTypes),
+		toJSON(canonicalFuelTypes(pfs.FuelTypes)),
+		sourceOrDefault(pfs.Source),
+		countryCodeOrDefault(pfs.CountryCode),
 	}
 }
 
-func (fp *FuelPrice) ToTuple(nodeId string) []any {
+// InUK reports whether the station is in the UK, rather than one of the
+// French or German feeds.
+func (pfs *PetrolFillingStation) InUK() bool {
+	return countryCodeOrDefault(pfs.CountryCode) == CountryCodeUK
+}
+
+// ToTuple returns the columns stored for the price, along with the cleansed
+// price among them, which callers compare with the price in force.
+func (fp *FuelPrice) ToTuple(nodeId, source string) ([]any, float64) {
 
-	price, logMsg := cleansePrice(fp.Price)
+	price, logMsg := CleansePrice(fp.Price)
 	if logMsg != "" {
 		log.Println(logMsg)
 	}
@@ -117,12 +144,45 @@ func (fp *FuelPrice) ToTuple(nodeId string) []any {
 		fp.PriceLastUpdated,
 		price,
 		fp.PriceChangeEffectiveTimestamp,
+		sourceOrDefault(source),
+	}, price
+}
+
+func canonicalFuelTypes(fuelTypes []string) []string {
+	if fuelTypes == nil {
+		return nil
+	}
+	results := make([]string, len(fuelTypes))
+	for i, fuelType := range fuelTypes {
+		results[i] = CanonicalFuelType(fuelType)
+	}
+	return results
+}
+
+func sourceOrDefault(source string) string {
+	if source == "" {
+		return SourceFuelFinder
+	}
+	return source
+}
+
+func countryCodeOrDefault(countryCode string) string {
+	if countryCode == "" {
+		return CountryCodeUK
 	}
+	return countryCode
+}
+
+func (fp *FuelPrice) IsPriceCorrected() bool {
+	_, logMsg := CleansePrice(fp.Price)
+	return logMsg != ""
 }
 
-func (fp *FuelPrice) IsPriceOutOfBounds() bool {
-	price, _ := cleansePrice(fp.Price)
-	return price < 100 || price > 300
+// IsPriceOutOfBounds reports whether the cleansed price falls outside the
+// bounds for its fuel type.
+func (fp *FuelPrice) IsPriceOutOfBounds(bounds *PriceBoundsSet) bool {
+	price, _ := CleansePrice(fp.Price)
+	return !bounds.For(fp.FuelType).Contains(price)
 }
 
 func toJSON(v any) string {
@@ -150,7 +210,10 @@ func cleanseAddressLine1(addressLine1, city, postcode string) string {
 	return addressLine1
 }
 
-func cleansePrice(price float64) (float64, string) {
+// CleansePrice rescales prices which look like they were given in pounds or
+// tenths of a penny, returning the price in pence and a warning describing
+// the correction, if any.
+func CleansePrice(price float64) (float64, string) {
 	// The API sometimes returns prices in pounds (rather than pence) or in tenths of pence
 	// (rather than pence), so we just adjust accordingly before writing to the database.
 	if price < 10 {
@@ -160,3 +223,50 @@ func cleansePrice(price float64) (float64, string) {
 	}
 	return price, ""
 }
+
+type SchemaDrift struct {
+	Path        string    `json:"path"`
+	Kind        string    `json:"kind"`
+	Field       string    `json:"field"`
+	Expected    string    `json:"expected,omitempty"`
+	Actual      string    `json:"actual,omitempty"`
+	Occurrences int       `json:"occurrences"`
+	Batches     int       `json:"batches"`
+	FirstSeen   time.Time `json:"first_seen"`
+	LastSeen    time.Time `json:"last_seen"`
+}
+
+type SchemaDriftReport struct {
+	Enabled bool          `json:"enabled"`
+	Drift   []SchemaDrift `json:"drift"`
+}
+
+// ImportDiff summarises what an import would change in the database.
+type ImportDiff struct {
+	NewStations       int            `json:"new_stations"`
+	ChangedStations   int            `json:"changed_stations"`
+	UnchangedStations int            `json:"unchanged_stations"`
+	ChangedAttributes map[string]int `json:"changed_attributes"`
+	NewPrices         int            `json:"new_prices"`
+	ChangedPrices     int            `json:"changed_prices"`
+	UnchangedPrices   int            `json:"unchanged_prices"`
+	DroppedPrices     int            `json:"dropped_prices"`
+	CorrectedPrices   int            `json:"corrected_prices"`
+}
+
+func (diff *ImportDiff) Add(other *ImportDiff) {
+	diff.NewStations += other.NewStations
+	diff.ChangedStations += other.ChangedStations
+	diff.UnchangedStations += other.UnchangedStations
+	for attr, count := range other.ChangedAttributes {
+		if diff.ChangedAttributes == nil {
+			diff.ChangedAttributes = make(map[string]int)
+		}
+		diff.ChangedAttributes[attr] += count
+	}
+	diff.NewPrices += other.NewPrices
+	diff.ChangedPrices += other.ChangedPrices
+	diff.UnchangedPrices += other.UnchangedPrices
+	diff.DroppedPrices += other.DroppedPrices
+	diff.CorrectedPrices += other.CorrectedPrices
+}
@@ -0,0 +1,155 @@
+package models
+
+import (
+	"cmp"
+	"slices"
+	"strings"
+)
+
+// Fuel families, which group fuel types for display.
+const (
+	FuelFamilyPetrol = "petrol"
+	FuelFamilyDiesel = "diesel"
+	FuelFamilyLPG    = "lpg"
+	FuelFamilyHVO    = "hvo"
+)
+
+// unknownSortOrder places fuel types missing from the catalogue last.
+const unknownSortOrder = 1000
+
+type FuelType struct {
+	Code        string   `json:"code"`
+	DisplayName string   `json:"display_name"`
+	Description string   `json:"description,omitempty"`
+	Family      string   `json:"family,omitempty"`
+	SortOrder   int      `json:"sort_order"`
+	Aliases     []string `json:"aliases,omitempty"`
+	Stations    int      `json:"stations"`
+}
+
+type FuelTypesResponse struct {
+	Results     []FuelType `json:"results"`
+	Attribution []string   `json:"attribution"`
+}
+
+// fuelTypes is the canonical catalogue. Codes are those used by Fuel Finder;
+// aliases are the variants seen from other sources and retailers.
+var fuelTypes = []FuelType{
+	{
+		Code:        "E10",
+		DisplayName: "Unleaded (E10)",
+		Description: "Standard unleaded petrol, containing up to 10% bioethanol",
+		Family:      FuelFamilyPetrol,
+		SortOrder:   10,
+		Aliases:     []string{"UNLEADED", "SP95_E10"},
+	},
+	{
+		Code:        "E5",
+		DisplayName: "Super Unleaded (E5)",
+		Description: "Higher octane unleaded petrol, containing up to 5% bioethanol",
+		Family:      FuelFamilyPetrol,
+		SortOrder:   20,
+		Aliases:     []string{"SUPER_UNLEADED", "SP95"},
+	},
+	{
+		Code:        "E5_PREMIUM",
+		DisplayName: "Premium Unleaded",
+		Description: "Premium 98+ octane unleaded petrol",
+		Family:      FuelFamilyPetrol,
+		SortOrder:   30,
+		Aliases:     []string{"SP98", "PREMIUM_UNLEADED"},
+	},
+	{
+		Code:        "E85",
+		DisplayName: "Bioethanol (E85)",
+		Description: "Petrol containing up to 85% bioethanol, for flex-fuel vehicles",
+		Family:      FuelFamilyPetrol,
+		SortOrder:   40,
+	},
+	{
+		Code:        "B7_STANDARD",
+		DisplayName: "Diesel (B7)",
+		Description: "Standard diesel, containing up to 7% biodiesel",
+		Family:      FuelFamilyDiesel,
+		SortOrder:   50,
+		Aliases:     []string{"B7", "B7S", "DIESEL", "GAZOLE"},
+	},
+	{
+		Code:        "B7_PREMIUM",
+		DisplayName: "Premium Diesel",
+		Description: "Premium diesel with additional cleaning additives",
+		Family:      FuelFamilyDiesel,
+		SortOrder:   60,
+		Aliases:     []string{"B7P", "SDV", "SUPER_DIESEL", "PREMIUM_DIESEL"},
+	},
+	{
+		Code:        "B10",
+		DisplayName: "Diesel (B10)",
+		Description: "Diesel containing up to 10% biodiesel",
+		Family:      FuelFamilyDiesel,
+		SortOrder:   70,
+	},
+	{
+		Code:        "HVO",
+		DisplayName: "HVO",
+		Description: "Hydrotreated vegetable oil, a renewable replacement for diesel",
+		Family:      FuelFamilyHVO,
+		SortOrder:   80,
+		Aliases:     []string{"HVO100"},
+	},
+	{
+		Code:        "LPG",
+		DisplayName: "LPG",
+		Description: "Liquefied petroleum gas, also sold as Autogas",
+		Family:      FuelFamilyLPG,
+		SortOrder:   90,
+		Aliases:     []string{"AUTOGAS", "GPL", "GPLC"},
+	},
+}
+
+// fuelTypeAliases maps each code and alias onto its canonical code.
+var fuelTypeAliases = func() map[string]string {
+	aliases := make(map[string]string)
+	for _, fuelType := range fuelTypes {
+		aliases[fuelType.Code] = fuelType.Code
+		for _, alias := range fuelType.Aliases {
+			aliases[alias] = fuelType.Code
+		}
+	}
+	return aliases
+}()
+
+// CanonicalFuelType maps a fuel type as received from a source onto its code
+// in the catalogue. Fuel types which are not in the catalogue are upper-cased
+// with separators replaced by underscores, but otherwise kept as they are.
+func CanonicalFuelType(fuelType string) string {
+	normalised := strings.ToUpper(strings.Join(strings.FieldsFunc(fuelType, func(r rune) bool {
+		return r == ' ' || r == '-' || r == '_'
+	}), "_"))
+	if code, ok := fuelTypeAliases[normalised]; ok {
+		return code
+	}
+	return normalised
+}
+
+// FuelTypeCatalogue lists every fuel type in the catalogue, and any others
+// in counts, with the number of stations selling each, in display order.
+func FuelTypeCatalogue(counts map[string]int) []FuelType {
+	results := make([]FuelType, 0, len(fuelTypes)+len(counts))
+	for _, fuelType := range fuelTypes {
+		fuelType.Aliases = slices.Clone(fuelType.Aliases)
+		fuelType.Stations = counts[fuelType.Code]
+		results = append(results, fuelType)
+	}
+
+	for code, stations := range counts {
+		if _, ok := fuelTypeAliases[code]; !ok {
+			results = append(results, FuelType{Code: code, DisplayName: code, SortOrder: unknownSortOrder, Stations: stations})
+		}
+	}
+
+	slices.SortFunc(results, func(a, b FuelType) int {
+		return cmp.Or(cmp.Compare(a.SortOrder, b.SortOrder), strings.Compare(a.Code, b.Code))
+	})
+	return results
+}
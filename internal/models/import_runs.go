@@ -0,0 +1,64 @@
+package models
+
+import "time"
+
+// Jobs recorded in the import_runs ledger.
+const (
+	ImportJobStations = "stations"
+	ImportJobPrices   = "prices"
+)
+
+// What started an import run.
+const (
+	ImportTriggerCron    = "cron"
+	ImportTriggerCommand = "import"
+)
+
+// States of an import run.
+const (
+	ImportRunning   = "running"
+	ImportSucceeded = "succeeded"
+	ImportFailed    = "failed"
+)
+
+// ImportRun is a single fetch of stations or prices from a data source.
+type ImportRun struct {
+	Id           int64            `json:"id"`
+	Job          string           `json:"job"`
+	Source       string           `json:"source"`
+	TriggeredBy  string           `json:"triggered_by"`
+	Status       string           `json:"status"`
+	StartedAt    time.Time        `json:"started_at"`
+	FinishedAt   *time.Time       `json:"finished_at,omitempty"`
+	DurationMs   *int64           `json:"duration_ms,omitempty"`
+	Batches      int              `json:"batches"`
+	Inserted     int              `json:"inserted"`
+	Dropped      int              `json:"dropped"`
+	Error        *string          `json:"error,omitempty"`
+	BatchDetails []ImportRunBatch `json:"batch_details,omitempty"`
+}
+
+// ImportRunBatch is a batch of records applied during an import run.
+type ImportRunBatch struct {
+	Number    int        `json:"number"`
+	Path      *string    `json:"path,omitempty"`
+	Records   int        `json:"records"`
+	Dropped   int        `json:"dropped"`
+	FetchedAt *time.Time `json:"fetched_at,omitempty"`
+	AppliedAt time.Time  `json:"applied_at"`
+}
+
+// ImportRunFilter selects import runs; empty fields match every run.
+type ImportRunFilter struct {
+	Job         string
+	Source      string
+	Status      string
+	TriggeredBy string
+	Since       time.Time
+}
+
+type ImportRunsResponse struct {
+	Results []ImportRun `json:"results"`
+	Limit   int         `json:"limit"`
+	Offset  int         `json:"offset"`
+}
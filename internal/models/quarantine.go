@@ -0,0 +1,70 @@
+package models
+
+import "time"
+
+// Reasons a price is quarantined.
+const (
+	QuarantineOutOfBounds = "out_of_bounds"
+	QuarantineRescaled    = "rescaled"
+)
+
+// Review states of a quarantined price.
+const (
+	QuarantinePending  = "pending"
+	QuarantineApproved = "approved"
+	QuarantineRejected = "rejected"
+)
+
+// QuarantinedPrice is a price which cleansing either dropped or rescaled,
+// held back for review along with the batch it arrived in.
+type QuarantinedPrice struct {
+	Id                            int64      `json:"id"`
+	NodeId                        string     `json:"node_id"`
+	FuelType                      string     `json:"fuel_type"`
+	PriceLastUpdated              time.Time  `json:"price_last_updated"`
+	PriceChangeEffectiveTimestamp *time.Time `json:"price_change_effective_timestamp,omitempty"`
+	OriginalPrice                 float64    `json:"original_price"`
+	CorrectedPrice                float64    `json:"corrected_price"`
+	Reason                        string     `json:"reason"`
+	Source                        string     `json:"source"`
+	BatchPath                     *string    `json:"batch_path,omitempty"`
+	BatchNumber                   *int       `json:"batch_number,omitempty"`
+	BatchFetchedAt                *time.Time `json:"batch_fetched_at,omitempty"`
+	Status                        string     `json:"status"`
+	QuarantinedAt                 time.Time  `json:"quarantined_at"`
+	ReviewedAt                    *time.Time `json:"reviewed_at,omitempty"`
+}
+
+type QuarantineResponse struct {
+	Results []QuarantinedPrice `json:"results"`
+	Limit   int                `json:"limit"`
+	Offset  int                `json:"offset"`
+}
+
+// QuarantineReason returns why the price should be quarantined, or an empty
+// string if it can be stored as-is.
+func (fp *FuelPrice) QuarantineReason(bounds *PriceBoundsSet) string {
+	if fp.IsPriceOutOfBounds(bounds) {
+		return QuarantineOutOfBounds
+	}
+	if fp.IsPriceCorrected() {
+		return QuarantineRescaled
+	}
+	return ""
+}
+
+// QuarantineTuple returns the values for a price_quarantine row, less the
+// batch columns.
+func (fp *FuelPrice) QuarantineTuple(nodeId, source, reason string) []any {
+	price, _ := CleansePrice(fp.Price)
+	return []any{
+		nodeId,
+		fp.FuelType,
+		fp.PriceLastUpdated,
+		fp.PriceChangeEffectiveTimestamp,
+		fp.Price,
+		price,
+		reason,
+		sourceOrDefault(source),
+	}
+}
@@ -2,16 +2,21 @@ package models
 
 import "time"
 
+// PriceInfo is a price charged by a station, which was first reported at
+// FirstSeen and most recently confirmed at UpdatedOn.
 type PriceInfo struct {
 	Price         float64    `json:"price"`
+	FirstSeen     time.Time  `json:"first_seen"`
 	UpdatedOn     time.Time  `json:"updated_on"`
 	EffectiveFrom *time.Time `json:"effective_from,omitempty"`
+	Anomaly       string     `json:"anomaly,omitempty"`
 }
 
 type SearchResult struct {
 	PetrolFillingStation
 	FuelPrices map[string][]PriceInfo `json:"fuel_prices,omitempty"`
 	Retailer   *Retailer              `json:"retailer,omitempty"`
+	Inactive   bool                   `json:"inactive,omitempty"`
 }
 
 type SearchResponse struct {
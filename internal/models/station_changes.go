@@ -0,0 +1,40 @@
+package models
+
+import "time"
+
+// StationChange is a single attribute of a station changing value on import.
+type StationChange struct {
+	Attribute string    `json:"attribute"`
+	OldValue  *string   `json:"old_value"`
+	NewValue  *string   `json:"new_value"`
+	Source    string    `json:"source"`
+	ChangedAt time.Time `json:"changed_at"`
+}
+
+type StationChangesResponse struct {
+	NodeId      string          `json:"node_id"`
+	Results     []StationChange `json:"results"`
+	Attribution []string        `json:"attribution"`
+}
+
+// Station activity events.
+const (
+	StationVanished   = "vanished"
+	StationReappeared = "reappeared"
+)
+
+// StationActivity is a station being marked inactive after dropping out of
+// its source's full refreshes, or active again on reappearing.
+type StationActivity struct {
+	NodeId      string    `json:"node_id"`
+	TradingName *string   `json:"trading_name,omitempty"`
+	Postcode    *string   `json:"postcode,omitempty"`
+	Event       string    `json:"event"`
+	Source      string    `json:"source"`
+	ChangedAt   time.Time `json:"changed_at"`
+}
+
+type StationActivityResponse struct {
+	Results     []StationActivity `json:"results"`
+	Attribution []string          `json:"attribution"`
+}
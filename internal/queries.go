@@ -0,0 +1,151 @@
+package internal
+
+import (
+	"embed"
+	"fmt"
+	"path"
+)
+
+//go:embed sql
+var sqlFiles embed.FS
+
+// queries holds the SQL statements of one database dialect, read from the
+// directory of embedded SQL files for it. Each dialect has the same files.
+type queries struct {
+	anomalyContext          string
+	areaPrices              string
+	bulkConfirmPrices       string
+	bulkPfsContext          string
+	bulkPriceContext        string
+	cleanseCandidates       string
+	cleanseUpdate           string
+	compactConfirm          string
+	compactDelete           string
+	compactDrop             string
+	compactRuns             string
+	compactSummary          string
+	confirmPrice            string
+	coordinateIssues        string
+	countMissedRefreshes    string
+	currentPriceRun         string
+	deactivateStations      string
+	deletePrice             string
+	distributionStats       string
+	fetchWatermarks         string
+	finishImportRun         string
+	fuelTypeCounts          string
+	fuelTypes               string
+	importRun               string
+	importRunBatches        string
+	insertCleanseAudit      string
+	insertImportBatch       string
+	insertPfs               string
+	insertPostcodeCentroid  string
+	insertPrices            string
+	insertQuarantine        string
+	insertStationChange     string
+	listAnomalies           string
+	listImportRuns          string
+	listQuarantine          string
+	nationalPrices          string
+	nextPriceRun            string
+	postcodeCentroid        string
+	priceHistory            string
+	priceRun                string
+	quarantineStatus        string
+	quarantineStatusById    string
+	recordStationReappeared string
+	recordStationVanished   string
+	resetFetchWatermarks    string
+	reviewQuarantine        string
+	searchPfs               string
+	searchPrices            string
+	searchRadiusPfs         string
+	searchRadiusPrices      string
+	selectPfs               string
+	snapshotStats           string
+	startImportRun          string
+	stationActivity         string
+	stationChanges          string
+	updateImportProgress    string
+	updatePriceRun          string
+	upsertFetchWatermark    string
+	vacuum                  string
+}
+
+var (
+	sqliteQueries   = loadQueries("sql")
+	postgresQueries = loadQueries("sql/postgres")
+)
+
+func loadQueries(dir string) *queries {
+	read := func(name string) string {
+		data, err := sqlFiles.ReadFile(path.Join(dir, name+".sql"))
+		if err != nil {
+			panic(fmt.Sprintf("missing SQL file: %v", err))
+		}
+		return string(data)
+	}
+
+	return &queries{
+		anomalyContext:          read("anomaly_context"),
+		areaPrices:              read("area_prices"),
+		bulkConfirmPrices:       read("bulk_confirm_prices"),
+		bulkPfsContext:          read("bulk_pfs_context"),
+		bulkPriceContext:        read("bulk_price_context"),
+		cleanseCandidates:       read("cleanse_candidates"),
+		cleanseUpdate:           read("cleanse_update"),
+		compactConfirm:          read("compact_confirm"),
+		compactDelete:           read("compact_delete"),
+		compactDrop:             read("compact_drop"),
+		compactRuns:             read("compact_runs"),
+		compactSummary:          read("compact_summary"),
+		confirmPrice:            read("confirm_price"),
+		coordinateIssues:        read("coordinate_issues"),
+		countMissedRefreshes:    read("count_missed_refreshes"),
+		currentPriceRun:         read("current_price_run"),
+		deactivateStations:      read("deactivate_stations"),
+		deletePrice:             read("delete_price"),
+		distributionStats:       read("distribution_stats"),
+		fetchWatermarks:         read("fetch_watermarks"),
+		finishImportRun:         read("finish_import_run"),
+		fuelTypeCounts:          read("fuel_type_counts"),
+		fuelTypes:               read("fuel_types"),
+		importRun:               read("import_run"),
+		importRunBatches:        read("import_run_batches"),
+		insertCleanseAudit:      read("insert_cleanse_audit"),
+		insertImportBatch:       read("insert_import_batch"),
+		insertPfs:               read("insert_pfs"),
+		insertPostcodeCentroid:  read("insert_postcode_centroid"),
+		insertPrices:            read("insert_prices"),
+		insertQuarantine:        read("insert_quarantine"),
+		insertStationChange:     read("insert_station_change"),
+		listAnomalies:           read("list_anomalies"),
+		listImportRuns:          read("list_import_runs"),
+		listQuarantine:          read("list_quarantine"),
+		nationalPrices:          read("national_prices"),
+		nextPriceRun:            read("next_price_run"),
+		postcodeCentroid:        read("postcode_centroid"),
+		priceHistory:            read("price_history"),
+		priceRun:                read("price_run"),
+		quarantineStatus:        read("quarantine_status"),
+		quarantineStatusById:    read("quarantine_status_by_id"),
+		recordStationReappeared: read("record_station_reappeared"),
+		recordStationVanished:   read("record_station_vanished"),
+		resetFetchWatermarks:    read("reset_fetch_watermarks"),
+		reviewQuarantine:        read("review_quarantine"),
+		searchPfs:               read("search_pfs"),
+		searchPrices:            read("search_prices"),
+		searchRadiusPfs:         read("search_radius_pfs"),
+		searchRadiusPrices:      read("search_radius_prices"),
+		selectPfs:               read("select_pfs"),
+		snapshotStats:           read("snapshot_stats"),
+		startImportRun:          read("start_import_run"),
+		stationActivity:         read("station_activity"),
+		stationChanges:          read("station_changes"),
+		updateImportProgress:    read("update_import_progress"),
+		updatePriceRun:          read("update_price_run"),
+		upsertFetchWatermark:    read("upsert_fetch_watermark"),
+		vacuum:                  read("vacuum"),
+	}
+}
@@ -0,0 +1,70 @@
+package internal
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/rm-hull/fuel-prices-api/internal/archive"
+	"github.com/rm-hull/fuel-prices-api/internal/models"
+)
+
+// Replay pushes every archived raw upstream response under dir back through
+// the same callbacks used by a live fetch. Filling stations are replayed
+// before fuel prices, each in the chronological order they were fetched.
+func Replay(ctx context.Context, dir string, repo FuelPricesRepository) error {
+	numPFS, dropped, err := replayPath(ctx, dir, pfsPath, decodeBatch[models.PetrolFillingStation], repo.InsertPFS)
+	if err != nil {
+		return err
+	}
+	log.Printf("replayed %d filling stations (dropped: %d)", numPFS, dropped)
+
+	numPrices, dropped, err := replayPath(ctx, dir, pricesPath, decodeBatch[models.ForecourtPrices], repo.InsertPrices)
+	if err != nil {
+		return err
+	}
+	log.Printf("replayed %d fuel prices (dropped: %d)", numPrices, dropped)
+
+	return nil
+}
+
+func replayPath[T any](
+	ctx context.Context,
+	dir, path string,
+	decode func([]byte, int) ([]T, *models.MetaData, error),
+	callback BatchCallback[T],
+) (int, int, error) {
+	files, err := archive.Files(dir, path)
+	if err != nil {
+		return 0, 0, err
+	}
+	log.Printf("Found %d archive files for %s in %s", len(files), path, dir)
+
+	count := 0
+	totalDropped := 0
+	for _, file := range files {
+		for record, err := range archive.Read(file) {
+			if err != nil {
+				return 0, 0, err
+			}
+			if record.Path != path {
+				continue
+			}
+
+			data, _, err := decode(record.Body, record.BatchNumber)
+			if err != nil {
+				return 0, 0, fmt.Errorf("failed to decode batch %d in %s: %w", record.BatchNumber, file, err)
+			}
+
+			info := BatchInfo{Path: path, Number: record.BatchNumber, FetchedAt: record.FetchedAt}
+			numRecords, dropped, err := callback(WithBatchInfo(ctx, info), data)
+			if err != nil {
+				return 0, 0, fmt.Errorf("callback error: %w", err)
+			}
+			count += numRecords
+			totalDropped += dropped
+		}
+	}
+
+	return count, totalDropped, nil
+}
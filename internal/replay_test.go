@@ -0,0 +1,57 @@
+package internal
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/rm-hull/fuel-prices-api/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReplayArchivedResponses(t *testing.T) {
+	now := time.Now().UTC().Truncate(time.Second)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("batch-number") != "1" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		switch r.URL.Path {
+		case "/pfs":
+			_ = json.NewEncoder(w).Encode([]models.PetrolFillingStation{
+				{NodeId: "node-1", Location: models.Location{Latitude: 51.5, Longitude: -0.1}},
+			})
+		case "/pfs/fuel-prices":
+			_ = json.NewEncoder(w).Encode([]models.ForecourtPrices{{
+				NodeId: "node-1",
+				FuelPrices: []models.FuelPrice{
+					{FuelType: "E10", Price: 141.9, PriceLastUpdated: now},
+				},
+			}})
+		}
+	}))
+	defer server.Close()
+
+	archiveDir := t.TempDir()
+	mgr := setupRetryingTestClient(t, server.URL)
+	mgr.archiveDir = archiveDir
+
+	source := setupTestDB(t)
+	_, _, err := mgr.GetFillingStations(t.Context(), source.InsertPFS)
+	require.NoError(t, err)
+	_, _, err = mgr.GetFuelPrices(t.Context(), source.InsertPrices)
+	require.NoError(t, err)
+
+	rebuilt := setupTestDB(t)
+	require.NoError(t, Replay(t.Context(), archiveDir, rebuilt))
+
+	results, err := rebuilt.Search([]float64{-0.2, 51.4, 0.0, 51.6}, 1, false)
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.Equal(t, "node-1", results[0].NodeId)
+	require.Contains(t, results[0].FuelPrices, "E10")
+	assert.Equal(t, 141.9, results[0].FuelPrices["E10"][0].Price)
+}
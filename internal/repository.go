@@ -1,12 +1,13 @@
 package internal
 
 import (
+	"context"
 	"database/sql"
-	_ "embed"
 	"fmt"
 	"log"
 	"sort"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/kofalt/go-memoize"
@@ -16,73 +17,102 @@ import (
 	"github.com/tavsec/gin-healthcheck/checks"
 )
 
-//go:embed sql/insert_pfs.sql
-var insertPfsSQL string
-
-//go:embed sql/insert_prices.sql
-var insertPricesSQL string
-
-//go:embed sql/search_pfs.sql
-var searchPfsSQL string
-
-//go:embed sql/search_prices.sql
-var searchPricesSQL string
-
-//go:embed sql/snapshot_stats.sql
-var snapshotStatsSQL string
-
-//go:embed sql/distribution_stats.sql
-var distributionStatsSQL string
-
-//go:embed sql/price_history.sql
-var priceHistorySQL string
-
-//go:embed sql/fuel_types.sql
-var fuelTypesSQL string
-
 type FuelPricesRepository interface {
-	InsertPFS(batch []models.PetrolFillingStation) (int, int, error)
-	InsertPrices(batch []models.ForecourtPrices) (int, int, error)
-	Search(boundingBox []float64, perTypeLimit int) ([]models.SearchResult, error)
+	InsertPFS(ctx context.Context, batch []models.PetrolFillingStation) (int, int, error)
+	InsertPrices(ctx context.Context, batch []models.ForecourtPrices) (int, int, error)
+	DiffPFS(ctx context.Context, batch []models.PetrolFillingStation) (*models.ImportDiff, error)
+	DiffPrices(ctx context.Context, batch []models.ForecourtPrices) (*models.ImportDiff, error)
+	Cleanse(ctx context.Context, dryRun bool) (*models.CleanseReport, error)
+	Compact(ctx context.Context, dryRun bool) (*models.CompactReport, error)
+	Search(boundingBox []float64, perTypeLimit int, includeInactive bool) ([]models.SearchResult, error)
+	SearchRadius(latitude, longitude, radius float64, perTypeLimit int, includeInactive bool) ([]models.SearchResult, error)
 	PriceHistory(nodeId, fuelType string) ([]models.FuelPrice, error)
+	StationChanges(nodeId, attribute string, limit int) ([]models.StationChange, error)
+	MarkMissingStations(ctx context.Context, source string, refreshStarted time.Time, threshold int) (int, error)
+	StationActivity(since time.Time, limit, offset int) ([]models.StationActivity, error)
 	FuelTypes() (map[string]struct{}, error)
+	FuelTypeCounts() (map[string]int, error)
 	SnapshotStats() (*models.SnapshotStatistics, error)
 	DistributionStats() (*models.DistributionStatistics, error)
+	FetchWatermarks() (map[string]time.Time, error)
+	SaveFetchWatermark(path string, fetchedAt time.Time) error
+	ResetFetchWatermarks() error
+	PriceBounds() *models.PriceBoundsSet
+	ConfigurePriceBounds(cfg BoundsConfig) error
+	RefreshPriceBounds() error
+	ConfigureAnomalyDetection(cfg AnomalyConfig)
+	ConfigureCoordinateValidation(cfg CoordinateConfig)
+	InsertPostcodeCentroids(ctx context.Context, batch []models.PostcodeCentroid) (int, error)
+	CoordinateIssues(issue string, repairedOnly bool, limit, offset int) ([]models.CoordinateIssue, error)
+	Anomalies(since time.Time, limit, offset int) ([]models.PriceAnomaly, error)
+	QuarantinedPrices(status string, limit, offset int) ([]models.QuarantinedPrice, error)
+	StartImportRun(ctx context.Context, run *models.ImportRun) error
+	RecordImportBatch(ctx context.Context, runId int64, batch models.ImportRunBatch) error
+	FinishImportRun(ctx context.Context, run *models.ImportRun, runErr error) error
+	ImportRuns(filter models.ImportRunFilter, limit, offset int) ([]models.ImportRun, error)
+	ImportRun(id int64) (*models.ImportRun, error)
+	ReviewQuarantinedPrice(ctx context.Context, id int64, approve bool) (*models.QuarantinedPrice, error)
 	Close() error
 	Check() checks.Check
 }
 
-type sqliteRepository struct {
+type sqlRepository struct {
 	db        *sql.DB
+	q         *queries
 	retailers *models.Retailers
 	cache     *memoize.Memoizer
 	metrics   *metrics.SqlMetrics
+	checks    *metrics.PriceCheckMetrics
+	imports   *metrics.ImportMetrics
+
+	// bulkThreshold is the fewest rows in a batch for it to be inserted by
+	// the bulk insert path.
+	bulkThreshold int
+
+	bounds           atomic.Pointer[models.PriceBoundsSet]
+	configMu         sync.Mutex
+	boundsConfig     BoundsConfig
+	anomalyConfig    AnomalyConfig
+	coordinateConfig CoordinateConfig
 }
 
+// NewFuelPricesRepository returns a repository backed by the database, which
+// may be SQLite or PostgreSQL: its SQL dialect follows the connection's driver.
 func NewFuelPricesRepository(db *sql.DB, retailers *models.Retailers) FuelPricesRepository {
-	return &sqliteRepository{
+	repo := &sqlRepository{
 		db:        db,
+		q:         queriesFor(db),
 		retailers: retailers,
 		cache:     memoize.NewMemoizer(60*time.Minute, 10*time.Minute),
 		metrics:   metrics.NewSqlMetrics(prometheus.DefaultRegisterer),
+		checks:    metrics.NewPriceCheckMetrics(prometheus.DefaultRegisterer),
+		imports:   metrics.NewImportMetrics(prometheus.DefaultRegisterer),
 	}
+	repo.bounds.Store(models.DefaultPriceBounds())
+	repo.anomalyConfig = DefaultAnomalyConfig()
+	repo.coordinateConfig = DefaultCoordinateConfig()
+	repo.bulkThreshold = defaultBulkInsertThreshold
+	return repo
 }
 
-func (repo *sqliteRepository) Close() error {
+func (repo *sqlRepository) Close() error {
 	return repo.db.Close()
 }
 
-func (repo *sqliteRepository) Check() checks.Check {
+func (repo *sqlRepository) Check() checks.Check {
 	return checks.SqlCheck{Sql: repo.db}
 }
 
-func (repo *sqliteRepository) InsertPFS(batch []models.PetrolFillingStation) (int, int, error) {
+func (repo *sqlRepository) InsertPFS(ctx context.Context, batch []models.PetrolFillingStation) (int, int, error) {
 	if len(batch) == 0 {
 		return 0, 0, nil
 	}
+	if len(batch) >= repo.bulkThreshold {
+		return repo.bulkInsertPFS(ctx, batch)
+	}
 
 	defer repo.metrics.Record(time.Now(), "insertPFS")
-	tx, err := repo.db.Begin()
+	tx, err := repo.db.BeginTx(ctx, nil)
 	if err != nil {
 		return 0, 0, fmt.Errorf("failed to begin transaction: %w", err)
 	}
@@ -94,7 +124,7 @@ func (repo *sqliteRepository) InsertPFS(batch []models.PetrolFillingStation) (in
 		}
 	}()
 
-	stmt, err := tx.Prepare(insertPfsSQL)
+	stmt, err := tx.PrepareContext(ctx, repo.q.insertPfs)
 	if err != nil {
 		return 0, 0, fmt.Errorf("failed to prepare statement: %w", err)
 	}
@@ -104,9 +134,35 @@ func (repo *sqliteRepository) InsertPFS(batch []models.PetrolFillingStation) (in
 		}
 	}()
 
+	changes, err := repo.prepareStationChangeLog(ctx, tx)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer changes.close()
+
+	coordinates, err := repo.prepareCoordinateValidator(ctx, tx)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer coordinates.close()
+
+	// Truncated, as last_seen_at is compared with the start of a refresh.
+	seenAt := time.Now().UTC().Truncate(time.Second)
+
 	count := 0
 	for _, pfs := range batch {
-		_, err = stmt.Exec(pfs.ToTuple()...)
+		var check coordinateCheck
+		if check, err = coordinates.check(ctx, &pfs); err != nil {
+			return 0, 0, err
+		}
+
+		tuple := pfs.ToTuple()
+		if err = changes.record(ctx, pfs.NodeId, pfs.Source, tuple); err != nil {
+			return 0, 0, err
+		}
+
+		tuple = append(tuple, seenAt)
+		_, err = stmt.ExecContext(ctx, append(tuple, check.tuple()...)...)
 		if err != nil {
 			return 0, 0, fmt.Errorf("failed to execute individual insert: %w", err)
 		}
@@ -120,13 +176,26 @@ func (repo *sqliteRepository) InsertPFS(batch []models.PetrolFillingStation) (in
 	return count, 0, nil
 }
 
-func (repo *sqliteRepository) InsertPrices(batch []models.ForecourtPrices) (int, int, error) {
+func (repo *sqlRepository) InsertPrices(ctx context.Context, batch []models.ForecourtPrices) (int, int, error) {
 	if len(batch) == 0 {
 		return 0, 0, nil
 	}
 
+	rows := 0
+	for _, forecourtPrices := range batch {
+		rows += len(forecourtPrices.FuelPrices)
+	}
+	if rows >= repo.bulkThreshold {
+		return repo.bulkInsertPrices(ctx, batch)
+	}
+
+	anomalies, err := repo.newAnomalyDetector()
+	if err != nil {
+		return 0, 0, err
+	}
+
 	defer repo.metrics.Record(time.Now(), "insertPrices")
-	tx, err := repo.db.Begin()
+	tx, err := repo.db.BeginTx(ctx, nil)
 	if err != nil {
 		return 0, 0, fmt.Errorf("failed to begin transaction: %w", err)
 	}
@@ -138,7 +207,7 @@ func (repo *sqliteRepository) InsertPrices(batch []models.ForecourtPrices) (int,
 		}
 	}()
 
-	stmt, err := tx.Prepare(insertPricesSQL)
+	stmt, err := tx.PrepareContext(ctx, repo.q.insertPrices)
 	if err != nil {
 		return 0, 0, fmt.Errorf("failed to prepare statement: %w", err)
 	}
@@ -148,19 +217,69 @@ func (repo *sqliteRepository) InsertPrices(batch []models.ForecourtPrices) (int,
 		}
 	}()
 
+	quarantine, err := repo.prepareQuarantine(ctx, tx)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer quarantine.close()
+
+	if err = anomalies.prepare(ctx, tx); err != nil {
+		return 0, 0, err
+	}
+	defer anomalies.close()
+
+	runs, err := repo.preparePriceRuns(ctx, tx)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer runs.close()
+
+	batchInfo, _ := BatchInfoFrom(ctx)
+	bounds := repo.PriceBounds()
+
 	count := 0
 	dropped := 0
 	for _, forecourtPrices := range batch {
 		for _, fuelPrice := range forecourtPrices.FuelPrices {
-			if fuelPrice.IsPriceOutOfBounds() {
-				log.Printf("WARNING: %s price of %0.2fp looks like an input-entry error; dropping fuel_price record for node_id: %s", fuelPrice.FuelType, fuelPrice.Price, forecourtPrices.NodeId)
+			fuelPrice.FuelType = models.CanonicalFuelType(fuelPrice.FuelType)
+			var drop bool
+			if drop, err = quarantine.screen(ctx, &fuelPrice, forecourtPrices.NodeId, forecourtPrices.Source, bounds, batchInfo); err != nil {
+				return 0, 0, err
+			}
+			if drop {
 				dropped++
 				continue
 			}
-			_, err = stmt.Exec(fuelPrice.ToTuple(forecourtPrices.NodeId)...)
+
+			tuple, price := fuelPrice.ToTuple(forecourtPrices.NodeId, forecourtPrices.Source)
+			var confirmed bool
+			if confirmed, err = runs.confirmed(ctx, forecourtPrices.NodeId, &fuelPrice, price); err != nil {
+				return 0, 0, err
+			}
+			if confirmed {
+				count++
+				continue
+			}
+
+			var anomaly string
+			var reference *float64
+			anomaly, reference, err = anomalies.check(ctx, forecourtPrices.NodeId, &fuelPrice, price)
+			if err != nil {
+				return 0, 0, err
+			}
+			if anomaly != "" {
+				log.Printf("WARNING: %s price of %0.2fp for node_id: %s flagged as %s anomaly", fuelPrice.FuelType, fuelPrice.Price, forecourtPrices.NodeId, anomaly)
+			}
+
+			_, err = stmt.ExecContext(ctx, append(tuple, nullString(anomaly), reference, fuelPrice.PriceLastUpdated)...)
 			if err != nil {
 				return 0, 0, fmt.Errorf("failed to execute individual insert: %w", err)
 			}
+			// A price which lands out of turn ahead of a run of the same price
+			// takes that run over.
+			if err = repo.foldNextRun(ctx, tx, forecourtPrices.NodeId, fuelPrice.FuelType, fuelPrice.PriceLastUpdated); err != nil {
+				return 0, 0, err
+			}
 			count++
 		}
 	}
@@ -172,7 +291,23 @@ func (repo *sqliteRepository) InsertPrices(batch []models.ForecourtPrices) (int,
 	return count, dropped, nil
 }
 
-func (repo *sqliteRepository) Search(boundingBox []float64, perTypeLimit int) ([]models.SearchResult, error) {
+func (repo *sqlRepository) Search(boundingBox []float64, perTypeLimit int, includeInactive bool) ([]models.SearchResult, error) {
+	minLng, minLat, maxLng, maxLat := boundingBox[0], boundingBox[1], boundingBox[2], boundingBox[3]
+	return repo.search(
+		repo.q.searchPfs, []any{minLat, maxLat, minLng, maxLng, includeInactive},
+		repo.q.searchPrices, []any{minLat, maxLat, minLng, maxLng, perTypeLimit},
+	)
+}
+
+// SearchRadius finds the stations within radius metres of a point.
+func (repo *sqlRepository) SearchRadius(latitude, longitude, radius float64, perTypeLimit int, includeInactive bool) ([]models.SearchResult, error) {
+	return repo.search(
+		repo.q.searchRadiusPfs, []any{latitude, longitude, radius, includeInactive},
+		repo.q.searchRadiusPrices, []any{latitude, longitude, radius, perTypeLimit},
+	)
+}
+
+func (repo *sqlRepository) search(pfsQuery string, pfsArgs []any, pricesQuery string, pricesArgs []any) ([]models.SearchResult, error) {
 	var (
 		pfs       []models.SearchResult
 		prices    map[string]map[string][]models.PriceInfo
@@ -182,8 +317,8 @@ func (repo *sqliteRepository) Search(boundingBox []float64, perTypeLimit int) ([
 	)
 
 	wg.Add(2)
-	go repo.fetchPfs(boundingBox, &pfs, &pfsErr, wg.Done)
-	go repo.fetchPrices(boundingBox, &prices, &pricesErr, perTypeLimit, wg.Done)
+	go repo.fetchPfs(pfsQuery, pfsArgs, &pfs, &pfsErr, wg.Done)
+	go repo.fetchPrices(pricesQuery, pricesArgs, &prices, &pricesErr, wg.Done)
 
 	wg.Wait()
 
@@ -204,11 +339,11 @@ func (repo *sqliteRepository) Search(boundingBox []float64, perTypeLimit int) ([
 	return pfs, nil
 }
 
-func (repo *sqliteRepository) fetchPfs(boundingBox []float64, results *[]models.SearchResult, err *error, done func()) {
+func (repo *sqlRepository) fetchPfs(query string, args []any, results *[]models.SearchResult, err *error, done func()) {
 	defer done()
 
 	defer repo.metrics.Record(time.Now(), "fetchPFS")
-	rows, queryErr := repo.db.Query(searchPfsSQL, boundingBox[1], boundingBox[3], boundingBox[0], boundingBox[2])
+	rows, queryErr := repo.db.Query(query, args...)
 	if queryErr != nil {
 		*err = fmt.Errorf("failed to execute search query: %w", queryErr)
 		return
@@ -229,7 +364,7 @@ func (repo *sqliteRepository) fetchPfs(boundingBox []float64, results *[]models.
 			&result.IsSupermarketServiceStation,
 			&result.Location.AddressLine1, &result.Location.AddressLine2, &result.Location.City, &result.Location.Country,
 			&result.Location.County, &result.Location.Postcode, &result.Location.Latitude, &result.Location.Longitude,
-			&openingTimesJSON, &amenitiesJSON, &fuelTypesJSON,
+			&openingTimesJSON, &amenitiesJSON, &fuelTypesJSON, &result.Inactive,
 		); scanErr != nil {
 			*err = fmt.Errorf("failed to scan row: %w", scanErr)
 			return
@@ -256,11 +391,11 @@ func (repo *sqliteRepository) fetchPfs(boundingBox []float64, results *[]models.
 	}
 }
 
-func (repo *sqliteRepository) fetchPrices(boundingBox []float64, results *map[string]map[string][]models.PriceInfo, err *error, perTypeLimit int, done func()) {
+func (repo *sqlRepository) fetchPrices(query string, args []any, results *map[string]map[string][]models.PriceInfo, err *error, done func()) {
 	defer done()
 
 	defer repo.metrics.Record(time.Now(), "fetchPrices")
-	rows, queryErr := repo.db.Query(searchPricesSQL, boundingBox[1], boundingBox[3], boundingBox[0], boundingBox[2], perTypeLimit)
+	rows, queryErr := repo.db.Query(query, args...)
 	if queryErr != nil {
 		*err = fmt.Errorf("failed to execute search query: %w", queryErr)
 		return
@@ -276,9 +411,11 @@ func (repo *sqliteRepository) fetchPrices(boundingBox []float64, results *map[st
 	for rows.Next() {
 		var nodeId string
 		var fuelPrice models.FuelPrice
+		var lastConfirmed time.Time
+		var anomaly sql.NullString
 		if scanErr := rows.Scan(
-			&nodeId, &fuelPrice.FuelType, &fuelPrice.PriceLastUpdated,
-			&fuelPrice.Price, &fuelPrice.PriceChangeEffectiveTimestamp,
+			&nodeId, &fuelPrice.FuelType, &fuelPrice.PriceLastUpdated, &lastConfirmed,
+			&fuelPrice.Price, &fuelPrice.PriceChangeEffectiveTimestamp, &anomaly,
 		); scanErr != nil {
 			*err = fmt.Errorf("failed to scan row: %w", scanErr)
 			return
@@ -289,27 +426,29 @@ func (repo *sqliteRepository) fetchPrices(boundingBox []float64, results *map[st
 
 		(*results)[nodeId][fuelPrice.FuelType] = append((*results)[nodeId][fuelPrice.FuelType], models.PriceInfo{
 			Price:         fuelPrice.Price,
-			UpdatedOn:     fuelPrice.PriceLastUpdated,
+			FirstSeen:     fuelPrice.PriceLastUpdated,
+			UpdatedOn:     lastConfirmed,
 			EffectiveFrom: fuelPrice.PriceChangeEffectiveTimestamp,
+			Anomaly:       anomaly.String,
 		})
 	}
 }
 
-func (repo *sqliteRepository) SnapshotStats() (*models.SnapshotStatistics, error) {
+func (repo *sqlRepository) SnapshotStats() (*models.SnapshotStatistics, error) {
 	result, err, _ := memoize.Call(repo.cache, "snapshot_stats", repo.snapshotQuery)
 	return result, err
 }
 
-func (repo *sqliteRepository) DistributionStats() (*models.DistributionStatistics, error) {
+func (repo *sqlRepository) DistributionStats() (*models.DistributionStatistics, error) {
 	result, err, _ := memoize.Call(repo.cache, "distribution_stats", repo.distributionQuery)
 	return result, err
 }
 
-func (repo *sqliteRepository) snapshotQuery() (*models.SnapshotStatistics, error) {
+func (repo *sqlRepository) snapshotQuery() (*models.SnapshotStatistics, error) {
 	now := time.Now()
 
 	defer repo.metrics.Record(now, "statistics")
-	snapshotRows, err := repo.db.Query(snapshotStatsSQL)
+	snapshotRows, err := repo.db.Query(repo.q.snapshotStats)
 	if err != nil {
 		return nil, fmt.Errorf("failed to execute snapshot stats: %w", err)
 	}
@@ -339,11 +478,11 @@ func (repo *sqliteRepository) snapshotQuery() (*models.SnapshotStatistics, error
 	}, nil
 }
 
-func (repo *sqliteRepository) distributionQuery() (*models.DistributionStatistics, error) {
+func (repo *sqlRepository) distributionQuery() (*models.DistributionStatistics, error) {
 	now := time.Now()
 
 	defer repo.metrics.Record(now, "distribution")
-	distributionRows, err := repo.db.Query(distributionStatsSQL)
+	distributionRows, err := repo.db.Query(repo.q.distributionStats)
 	if err != nil {
 		return nil, fmt.Errorf("failed to execute distribution stats: %w", err)
 	}
@@ -413,10 +552,10 @@ func (repo *sqliteRepository) distributionQuery() (*models.DistributionStatistic
 	}, nil
 }
 
-func (repo *sqliteRepository) PriceHistory(nodeId, fuelType string) ([]models.FuelPrice, error) {
+func (repo *sqlRepository) PriceHistory(nodeId, fuelType string) ([]models.FuelPrice, error) {
 
 	defer repo.metrics.Record(time.Now(), "priceHistory")
-	rows, err := repo.db.Query(priceHistorySQL, nodeId, fuelType)
+	rows, err := repo.db.Query(repo.q.priceHistory, nodeId, fuelType)
 	if err != nil {
 		return nil, fmt.Errorf("failed to execute price history query: %w", err)
 	}
@@ -442,16 +581,16 @@ func (repo *sqliteRepository) PriceHistory(nodeId, fuelType string) ([]models.Fu
 	return results, nil
 }
 
-func (repo *sqliteRepository) FuelTypes() (map[string]struct{}, error) {
+func (repo *sqlRepository) FuelTypes() (map[string]struct{}, error) {
 	result, err, _ := memoize.Call(repo.cache, "fuel_types", repo.fuelTypesQuery)
 	return result, err
 }
 
-func (repo *sqliteRepository) fuelTypesQuery() (map[string]struct{}, error) {
+func (repo *sqlRepository) fuelTypesQuery() (map[string]struct{}, error) {
 	now := time.Now()
 
 	defer repo.metrics.Record(now, "fuelTypes")
-	rows, err := repo.db.Query(fuelTypesSQL)
+	rows, err := repo.db.Query(repo.q.fuelTypes)
 	if err != nil {
 		return nil, fmt.Errorf("failed to execute fuel-types query: %w", err)
 	}
@@ -473,3 +612,88 @@ func (repo *sqliteRepository) fuelTypesQuery() (map[string]struct{}, error) {
 
 	return results, nil
 }
+
+// FuelTypeCounts returns the number of stations with a current price for
+// each fuel type.
+func (repo *sqlRepository) FuelTypeCounts() (map[string]int, error) {
+	result, err, _ := memoize.Call(repo.cache, "fuel_type_counts", repo.fuelTypeCountsQuery)
+	return result, err
+}
+
+func (repo *sqlRepository) fuelTypeCountsQuery() (map[string]int, error) {
+
+	defer repo.metrics.Record(time.Now(), "fuelTypeCounts")
+	rows, err := repo.db.Query(repo.q.fuelTypeCounts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute fuel type counts query: %w", err)
+	}
+	defer func() {
+		if closeErr := rows.Close(); closeErr != nil {
+			log.Printf("failed to close rows: %v", closeErr)
+		}
+	}()
+
+	results := make(map[string]int)
+	for rows.Next() {
+		var fuelType string
+		var stations int
+		if err := rows.Scan(&fuelType, &stations); err != nil {
+			return nil, fmt.Errorf("failed to scan row: %w", err)
+		}
+		results[fuelType] = stations
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating over rows: %w", err)
+	}
+
+	return results, nil
+}
+
+func (repo *sqlRepository) FetchWatermarks() (map[string]time.Time, error) {
+
+	defer repo.metrics.Record(time.Now(), "fetchWatermarks")
+	rows, err := repo.db.Query(repo.q.fetchWatermarks)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute fetch watermarks query: %w", err)
+	}
+	defer func() {
+		if closeErr := rows.Close(); closeErr != nil {
+			log.Printf("failed to close rows: %v", closeErr)
+		}
+	}()
+
+	results := make(map[string]time.Time)
+	for rows.Next() {
+		var path string
+		var lastFetchedAt time.Time
+		if err := rows.Scan(&path, &lastFetchedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan row: %w", err)
+		}
+		results[path] = lastFetchedAt
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating over rows: %w", err)
+	}
+
+	return results, nil
+}
+
+func (repo *sqlRepository) SaveFetchWatermark(path string, fetchedAt time.Time) error {
+
+	defer repo.metrics.Record(time.Now(), "saveFetchWatermark")
+	if _, err := repo.db.Exec(repo.q.upsertFetchWatermark, path, fetchedAt.UTC()); err != nil {
+		return fmt.Errorf("failed to save fetch watermark for %s: %w", path, err)
+	}
+	return nil
+}
+
+func (repo *sqlRepository) ResetFetchWatermarks() error {
+
+	defer repo.metrics.Record(time.Now(), "resetFetchWatermarks")
+	if _, err := repo.db.Exec(repo.q.resetFetchWatermarks); err != nil {
+		return fmt.Errorf("failed to reset fetch watermarks: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,271 @@
+package internal
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"math"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/kofalt/go-memoize"
+	"github.com/rm-hull/fuel-prices-api/internal/metrics"
+	"github.com/rm-hull/fuel-prices-api/internal/models"
+	"github.com/rm-hull/fuel-prices-api/internal/stats"
+)
+
+const (
+	// minAreaSamples is the fewest current prices a postcode area needs for
+	// a fuel type before prices there are compared with them.
+	minAreaSamples = 5
+
+	// minAreaStdDev stops areas where every station charges the same from
+	// flagging any price which differs by a fraction of a penny.
+	minAreaStdDev = 1.0
+)
+
+// AnomalyConfig configures how incoming prices are checked for anomalies.
+type AnomalyConfig struct {
+	// JumpThreshold is the largest fractional change from the station's
+	// previous price which is not flagged. Zero disables the check.
+	JumpThreshold float64
+
+	// StdDevs is how many standard deviations a price may be from the
+	// postcode area median before it is flagged. Zero disables the check.
+	StdDevs float64
+}
+
+func DefaultAnomalyConfig() AnomalyConfig {
+	return AnomalyConfig{JumpThreshold: 0.15, StdDevs: 3}
+}
+
+// LoadAnomalyConfig reads the anomaly detection configuration from
+// FUEL_PRICES_ANOMALY_JUMP_PERCENT and FUEL_PRICES_ANOMALY_STDDEVS, falling
+// back to the defaults for either which is unset.
+func LoadAnomalyConfig() (AnomalyConfig, error) {
+	cfg := DefaultAnomalyConfig()
+
+	if value := os.Getenv("FUEL_PRICES_ANOMALY_JUMP_PERCENT"); value != "" {
+		percent, err := strconv.ParseFloat(value, 64)
+		if err != nil || percent < 0 {
+			return cfg, fmt.Errorf("invalid FUEL_PRICES_ANOMALY_JUMP_PERCENT %q", value)
+		}
+		cfg.JumpThreshold = percent / 100
+	}
+
+	if value := os.Getenv("FUEL_PRICES_ANOMALY_STDDEVS"); value != "" {
+		stdDevs, err := strconv.ParseFloat(value, 64)
+		if err != nil || stdDevs < 0 {
+			return cfg, fmt.Errorf("invalid FUEL_PRICES_ANOMALY_STDDEVS %q", value)
+		}
+		cfg.StdDevs = stdDevs
+	}
+
+	return cfg, nil
+}
+
+func (repo *sqlRepository) ConfigureAnomalyDetection(cfg AnomalyConfig) {
+	repo.configMu.Lock()
+	defer repo.configMu.Unlock()
+
+	repo.anomalyConfig = cfg
+}
+
+type areaKey struct {
+	area     string
+	fuelType string
+}
+
+type areaStats struct {
+	median  float64
+	stdDev  float64
+	samples int
+}
+
+func (repo *sqlRepository) areaPriceStats() (map[areaKey]areaStats, error) {
+	result, err, _ := memoize.Call(repo.cache, "area_price_stats", repo.areaPriceStatsQuery)
+	return result, err
+}
+
+func (repo *sqlRepository) areaPriceStatsQuery() (map[areaKey]areaStats, error) {
+	defer repo.metrics.Record(time.Now(), "areaPrices")
+	rows, err := repo.db.Query(repo.q.areaPrices)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute area prices query: %w", err)
+	}
+	defer func() {
+		if closeErr := rows.Close(); closeErr != nil {
+			log.Printf("failed to close rows: %v", closeErr)
+		}
+	}()
+
+	prices := make(map[areaKey][]float64)
+	for rows.Next() {
+		var key areaKey
+		var price float64
+		if err := rows.Scan(&key.area, &key.fuelType, &price); err != nil {
+			return nil, fmt.Errorf("failed to scan row: %w", err)
+		}
+		prices[key] = append(prices[key], price)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating over rows: %w", err)
+	}
+
+	result := make(map[areaKey]areaStats, len(prices))
+	for key, sorted := range prices {
+		sum, sumSquares := 0.0, 0.0
+		for _, price := range sorted {
+			sum += price
+			sumSquares += price * price
+		}
+		n := float64(len(sorted))
+		mean := sum / n
+		result[key] = areaStats{
+			median:  stats.Percentile(sorted, 50),
+			stdDev:  math.Sqrt(math.Max(0, sumSquares/n-mean*mean)),
+			samples: len(sorted),
+		}
+	}
+	return result, nil
+}
+
+// anomalyDetector checks prices within an insert transaction.
+type anomalyDetector struct {
+	query   string
+	stmt    *sql.Stmt
+	cfg     AnomalyConfig
+	areas   map[areaKey]areaStats
+	metrics *metrics.PriceCheckMetrics
+}
+
+// newAnomalyDetector must be called before the insert transaction begins, as
+// it may need to query the postcode area statistics.
+func (repo *sqlRepository) newAnomalyDetector() (*anomalyDetector, error) {
+	repo.configMu.Lock()
+	cfg := repo.anomalyConfig
+	repo.configMu.Unlock()
+
+	d := &anomalyDetector{query: repo.q.anomalyContext, cfg: cfg, metrics: repo.checks}
+	if cfg.StdDevs > 0 {
+		areas, err := repo.areaPriceStats()
+		if err != nil {
+			return nil, err
+		}
+		d.areas = areas
+	}
+	return d, nil
+}
+
+func (d *anomalyDetector) prepare(ctx context.Context, tx *sql.Tx) error {
+	stmt, err := tx.PrepareContext(ctx, d.query)
+	if err != nil {
+		return fmt.Errorf("failed to prepare anomaly statement: %w", err)
+	}
+	d.stmt = stmt
+	return nil
+}
+
+func (d *anomalyDetector) close() {
+	if err := d.stmt.Close(); err != nil {
+		log.Printf("failed to close statement: %v", err)
+	}
+}
+
+// check returns the kind of anomaly the cleansed price is, if any, and the
+// price it was compared with.
+//
+// A jump is measured from the station's last unflagged price, unless the
+// station's last price was the same as this one: a repeated price is taken
+// as confirmation of a genuine change.
+func (d *anomalyDetector) check(ctx context.Context, nodeId string, fp *models.FuelPrice, price float64) (string, *float64, error) {
+	if d.cfg.JumpThreshold <= 0 && d.cfg.StdDevs <= 0 {
+		return "", nil, nil
+	}
+
+	var c anomalyContext
+	err := d.stmt.QueryRowContext(ctx,
+		nodeId, fp.FuelType, fp.PriceLastUpdated,
+		nodeId, fp.FuelType, fp.PriceLastUpdated,
+		nodeId,
+	).Scan(&c.previous, &c.previousNormal, &c.area)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to query anomaly context for node_id %s: %w", nodeId, err)
+	}
+
+	anomaly, reference := d.evaluate(fp.FuelType, price, c)
+	return anomaly, reference, nil
+}
+
+// anomalyContext is what a price is compared with: the station's previous
+// price, its last unflagged price, and its postcode area.
+type anomalyContext struct {
+	previous       sql.NullFloat64
+	previousNormal sql.NullFloat64
+	area           sql.NullString
+}
+
+// evaluate checks the price against the context it has been looked up with.
+func (d *anomalyDetector) evaluate(fuelType string, price float64, c anomalyContext) (string, *float64) {
+	if d.cfg.JumpThreshold > 0 && c.previousNormal.Valid && c.previousNormal.Float64 > 0 {
+		confirmed := c.previous.Valid && sameValue(price, c.previous.Float64)
+		if !confirmed && math.Abs(price-c.previousNormal.Float64)/c.previousNormal.Float64 > d.cfg.JumpThreshold {
+			d.metrics.RecordAnomaly(models.AnomalyJump)
+			return models.AnomalyJump, &c.previousNormal.Float64
+		}
+	}
+
+	if d.cfg.StdDevs > 0 && c.area.Valid {
+		stats, ok := d.areas[areaKey{area: c.area.String, fuelType: fuelType}]
+		if ok && stats.samples >= minAreaSamples {
+			if math.Abs(price-stats.median) > d.cfg.StdDevs*math.Max(stats.stdDev, minAreaStdDev) {
+				d.metrics.RecordAnomaly(models.AnomalyAreaOutlier)
+				return models.AnomalyAreaOutlier, &stats.median
+			}
+		}
+	}
+
+	return "", nil
+}
+
+func (repo *sqlRepository) Anomalies(since time.Time, limit, offset int) ([]models.PriceAnomaly, error) {
+
+	defer repo.metrics.Record(time.Now(), "anomalies")
+	rows, err := repo.db.Query(repo.q.listAnomalies, since, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute anomalies query: %w", err)
+	}
+	defer func() {
+		if closeErr := rows.Close(); closeErr != nil {
+			log.Printf("failed to close rows: %v", closeErr)
+		}
+	}()
+
+	results := make([]models.PriceAnomaly, 0, limit)
+	for rows.Next() {
+		var result models.PriceAnomaly
+		if err := rows.Scan(
+			&result.NodeId,
+			&result.TradingName,
+			&result.Postcode,
+			&result.FuelType,
+			&result.Price,
+			&result.PriceLastUpdated,
+			&result.Anomaly,
+			&result.Reference,
+			&result.Source,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan row: %w", err)
+		}
+		results = append(results, result)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating over rows: %w", err)
+	}
+
+	return results, nil
+}
@@ -0,0 +1,150 @@
+package internal
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rm-hull/fuel-prices-api/internal/models"
+	"github.com/rm-hull/fuel-prices-api/internal/stats"
+)
+
+const (
+	// minPercentileSamples is the fewest current prices a fuel type needs
+	// before its bounds are derived from them.
+	minPercentileSamples = 30
+
+	// percentileMargin widens derived bounds so that genuine price moves
+	// beyond the current national range are still let through.
+	percentileMargin = 0.2
+)
+
+// BoundsConfig configures the price bounds used to reject implausible prices.
+type BoundsConfig struct {
+	// Overrides take precedence over both built-in and derived bounds. The
+	// fuel type "*" replaces the default bounds.
+	Overrides map[string]models.PriceBounds
+
+	// Percentiles, if set, is the lower and upper national percentile from
+	// which each fuel type's bounds are derived.
+	Percentiles []float64
+}
+
+// LoadBoundsConfig reads the bounds configuration from FUEL_PRICES_BOUNDS
+// (e.g. "LPG=50:200,*=100:300") and FUEL_PRICES_BOUNDS_PERCENTILES
+// (e.g. "1:99").
+func LoadBoundsConfig() (BoundsConfig, error) {
+	var cfg BoundsConfig
+
+	overrides, err := models.ParsePriceBounds(os.Getenv("FUEL_PRICES_BOUNDS"))
+	if err != nil {
+		return cfg, fmt.Errorf("invalid FUEL_PRICES_BOUNDS: %w", err)
+	}
+	cfg.Overrides = overrides
+
+	if spec := os.Getenv("FUEL_PRICES_BOUNDS_PERCENTILES"); spec != "" {
+		lowerStr, upperStr, ok := strings.Cut(spec, ":")
+		lower, lerr := strconv.ParseFloat(strings.TrimSpace(lowerStr), 64)
+		upper, uerr := strconv.ParseFloat(strings.TrimSpace(upperStr), 64)
+		if !ok || lerr != nil || uerr != nil || lower < 0 || upper > 100 || lower >= upper {
+			return cfg, fmt.Errorf("invalid FUEL_PRICES_BOUNDS_PERCENTILES %q: expected LOWER:UPPER between 0 and 100", spec)
+		}
+		cfg.Percentiles = []float64{lower, upper}
+	}
+
+	return cfg, nil
+}
+
+func (repo *sqlRepository) PriceBounds() *models.PriceBoundsSet {
+	return repo.bounds.Load()
+}
+
+// ConfigurePriceBounds replaces the bounds configuration and refreshes the
+// active bounds from it.
+func (repo *sqlRepository) ConfigurePriceBounds(cfg BoundsConfig) error {
+	repo.configMu.Lock()
+	repo.boundsConfig = cfg
+	repo.configMu.Unlock()
+
+	return repo.RefreshPriceBounds()
+}
+
+// RefreshPriceBounds recomputes the active bounds. When percentiles are
+// configured, each fuel type with enough current prices gets bounds derived
+// from the national distribution that also feeds fuel_price_snapshot_stats.
+func (repo *sqlRepository) RefreshPriceBounds() error {
+	repo.configMu.Lock()
+	cfg := repo.boundsConfig
+	repo.configMu.Unlock()
+
+	set := models.DefaultPriceBounds()
+	if len(cfg.Percentiles) == 2 {
+		derived, err := repo.derivePriceBounds(cfg.Percentiles[0], cfg.Percentiles[1])
+		if err != nil {
+			return err
+		}
+		for fuelType, bounds := range derived {
+			set.FuelTypes[fuelType] = bounds
+		}
+		set.Percentiles = cfg.Percentiles
+	}
+
+	for fuelType, bounds := range cfg.Overrides {
+		if fuelType == "*" {
+			set.Default = bounds
+		} else {
+			set.FuelTypes[fuelType] = bounds
+		}
+	}
+
+	now := time.Now().UTC()
+	set.LastUpdated = &now
+	repo.bounds.Store(set)
+	return nil
+}
+
+// derivePriceBounds takes the percentiles of the latest prices for each fuel
+// type. The latest price view only holds prices from UK stations, so euro cent
+// prices from the French and German feeds don't drag the bounds down.
+func (repo *sqlRepository) derivePriceBounds(lower, upper float64) (map[string]models.PriceBounds, error) {
+	defer repo.metrics.Record(time.Now(), "nationalPrices")
+	rows, err := repo.db.Query(repo.q.nationalPrices)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute national prices query: %w", err)
+	}
+	defer func() {
+		if closeErr := rows.Close(); closeErr != nil {
+			log.Printf("failed to close rows: %v", closeErr)
+		}
+	}()
+
+	prices := make(map[string][]float64)
+	for rows.Next() {
+		var fuelType string
+		var price float64
+		if err := rows.Scan(&fuelType, &price); err != nil {
+			return nil, fmt.Errorf("failed to scan row: %w", err)
+		}
+		prices[fuelType] = append(prices[fuelType], price)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating over rows: %w", err)
+	}
+
+	result := make(map[string]models.PriceBounds, len(prices))
+	for fuelType, sorted := range prices {
+		if len(sorted) < minPercentileSamples {
+			continue
+		}
+		result[fuelType] = models.PriceBounds{
+			Min:    stats.Percentile(sorted, lower) * (1 - percentileMargin),
+			Max:    stats.Percentile(sorted, upper) * (1 + percentileMargin),
+			Source: models.BoundsDerived,
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,475 @@
+package internal
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"iter"
+	"log"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rm-hull/fuel-prices-api/internal/models"
+)
+
+const (
+	// defaultBulkInsertThreshold is the fewest rows in a batch for it to be
+	// written by the bulk insert path. Smaller batches, such as incremental
+	// updates, are written a row at a time.
+	defaultBulkInsertThreshold = 1000
+
+	// bulkInsertRows is how many rows the bulk insert path looks up and writes
+	// with each statement. It keeps the widest statement, for stations, well
+	// within SQLite's limit on bound parameters.
+	bulkInsertRows = 250
+)
+
+// numberedParam matches PostgreSQL's numbered parameters, which must be
+// renumbered in each repeated row, unlike SQLite's ? parameters.
+var numberedParam = regexp.MustCompile(`\$\d+`)
+
+// bulkStatement repeats the single-row VALUES clause of a statement to look
+// up or write many rows at once. Statements are prepared for each number of
+// rows the first time it is needed.
+type bulkStatement struct {
+	tx    *sql.Tx
+	head  string
+	row   string
+	tail  string
+	width int
+	stmts map[int]*sql.Stmt
+}
+
+func prepareBulkStatement(tx *sql.Tx, query string) (*bulkStatement, error) {
+	start := strings.Index(query, "VALUES (")
+	if start < 0 {
+		return nil, errors.New("statement has no VALUES clause to repeat")
+	}
+	start += len("VALUES ")
+	end := start + strings.Index(query[start:], ")") + 1
+
+	row := query[start:end]
+	width := len(numberedParam.FindAllString(row, -1))
+	if width == 0 {
+		width = strings.Count(row, "?")
+	}
+	return &bulkStatement{
+		tx:    tx,
+		head:  query[:start],
+		row:   row,
+		tail:  query[end:],
+		width: width,
+		stmts: make(map[int]*sql.Stmt),
+	}, nil
+}
+
+func (s *bulkStatement) close() {
+	for _, stmt := range s.stmts {
+		if err := stmt.Close(); err != nil {
+			log.Printf("failed to close statement: %v", err)
+		}
+	}
+}
+
+// statement returns the statement for as many rows as there are arguments.
+func (s *bulkStatement) statement(ctx context.Context, args []any) (*sql.Stmt, error) {
+	if len(args)%s.width != 0 {
+		return nil, fmt.Errorf("bulk statement given %d values for rows of %d", len(args), s.width)
+	}
+
+	n := len(args) / s.width
+	if stmt, ok := s.stmts[n]; ok {
+		return stmt, nil
+	}
+
+	rows := make([]string, n)
+	for i := range rows {
+		rows[i] = s.repeat(i)
+	}
+	stmt, err := s.tx.PrepareContext(ctx, s.head+strings.Join(rows, ",\n    ")+s.tail)
+	if err != nil {
+		return nil, fmt.Errorf("failed to prepare bulk statement: %w", err)
+	}
+	s.stmts[n] = stmt
+	return stmt, nil
+}
+
+// repeat returns the i'th row of the VALUES clause, with any numbered
+// parameters following on from those of the rows before it.
+func (s *bulkStatement) repeat(i int) string {
+	return numberedParam.ReplaceAllStringFunc(s.row, func(param string) string {
+		n, _ := strconv.Atoi(param[1:])
+		return "$" + strconv.Itoa(n+i*s.width)
+	})
+}
+
+func (s *bulkStatement) exec(ctx context.Context, args []any) error {
+	if len(args) == 0 {
+		return nil
+	}
+	stmt, err := s.statement(ctx, args)
+	if err != nil {
+		return err
+	}
+	if _, err := stmt.ExecContext(ctx, args...); err != nil {
+		return fmt.Errorf("failed to execute bulk statement: %w", err)
+	}
+	return nil
+}
+
+func (s *bulkStatement) query(ctx context.Context, args []any) (*sql.Rows, error) {
+	stmt, err := s.statement(ctx, args)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := stmt.QueryContext(ctx, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute bulk query: %w", err)
+	}
+	return rows, nil
+}
+
+// bulkChunks splits items into chunks of up to bulkInsertRows, starting a new
+// chunk rather than repeat a key within one. Everything in a chunk is looked
+// up before any of it is written, so a repeated key must see the earlier row.
+func bulkChunks[T any](items []T, key func(T) string) iter.Seq[[]T] {
+	return func(yield func([]T) bool) {
+		seen := make(map[string]struct{}, bulkInsertRows)
+		start := 0
+		for i, item := range items {
+			k := key(item)
+			if _, repeated := seen[k]; repeated || i-start == bulkInsertRows {
+				if !yield(items[start:i]) {
+					return
+				}
+				start = i
+				clear(seen)
+			}
+			seen[k] = struct{}{}
+		}
+		if start < len(items) {
+			yield(items[start:])
+		}
+	}
+}
+
+// stationContext is what the bulk insert path looks up for each station.
+type stationContext struct {
+	centroid models.PostcodeCentroid
+	found    bool
+	inactive bool
+	existing []any
+}
+
+func lookupStations(ctx context.Context, lookup *bulkStatement, chunk []models.PetrolFillingStation) ([]stationContext, []string, error) {
+	args := make([]any, 0, len(chunk)*lookup.width)
+	for i, pfs := range chunk {
+		args = append(args, i, pfs.NodeId, models.NormalisePostcode(pfs.Location.Postcode))
+	}
+
+	rows, err := lookup.query(ctx, args)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer func() {
+		if closeErr := rows.Close(); closeErr != nil {
+			log.Printf("failed to close rows: %v", closeErr)
+		}
+	}()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read columns: %w", err)
+	}
+	// The existing row follows the index, centroid and inactive flag.
+	columns = columns[4:]
+
+	results := make([]stationContext, len(chunk))
+	for rows.Next() {
+		var idx int
+		var lat, lon sql.NullFloat64
+		var inactive sql.NullBool
+		existing := make([]any, len(columns))
+		dest := []any{&idx, &lat, &lon, &inactive}
+		for i := range existing {
+			dest = append(dest, &existing[i])
+		}
+		if err := rows.Scan(dest...); err != nil {
+			return nil, nil, fmt.Errorf("failed to scan row: %w", err)
+		}
+
+		result := stationContext{
+			centroid: models.PostcodeCentroid{Latitude: lat.Float64, Longitude: lon.Float64},
+			found:    lat.Valid && lon.Valid,
+			inactive: inactive.Bool,
+		}
+		if existing[0] != nil {
+			result.existing = existing
+		}
+		results[idx] = result
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, nil, fmt.Errorf("error iterating over rows: %w", err)
+	}
+
+	return results, columns, nil
+}
+
+// bulkInsertPFS is InsertPFS for large batches: stations are looked up and
+// written a chunk at a time, rather than a row at a time.
+func (repo *sqlRepository) bulkInsertPFS(ctx context.Context, batch []models.PetrolFillingStation) (int, int, error) {
+
+	defer repo.metrics.Record(time.Now(), "bulkInsertPFS")
+	tx, err := repo.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			if rbErr := tx.Rollback(); rbErr != nil {
+				log.Printf("error rolling back transaction: %v", rbErr)
+			}
+		}
+	}()
+
+	lookup, err := prepareBulkStatement(tx, repo.q.bulkPfsContext)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer lookup.close()
+
+	insert, err := prepareBulkStatement(tx, repo.q.insertPfs)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer insert.close()
+
+	changes, err := repo.prepareStationChangeLog(ctx, tx)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer changes.close()
+
+	coordinates, err := repo.prepareCoordinateValidator(ctx, tx)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer coordinates.close()
+
+	// Truncated, as last_seen_at is compared with the start of a refresh.
+	seenAt := time.Now().UTC().Truncate(time.Second)
+
+	count := 0
+	nodeId := func(pfs models.PetrolFillingStation) string { return pfs.NodeId }
+	for chunk := range bulkChunks(batch, nodeId) {
+		var contexts []stationContext
+		var columns []string
+		if contexts, columns, err = lookupStations(ctx, lookup, chunk); err != nil {
+			return 0, 0, err
+		}
+
+		args := make([]any, 0, len(chunk)*insert.width)
+		for i, pfs := range chunk {
+			c := contexts[i]
+			check := coordinates.validate(&pfs, c.centroid, c.found)
+
+			tuple := pfs.ToTuple()
+			if c.existing != nil {
+				if c.inactive {
+					if err = changes.reappear(ctx, pfs.NodeId); err != nil {
+						return 0, 0, err
+					}
+				}
+				if err = changes.log(ctx, pfs.NodeId, pfs.Source, columns, c.existing, tuple); err != nil {
+					return 0, 0, err
+				}
+			}
+
+			args = append(append(append(args, tuple...), seenAt), check.tuple()...)
+			count++
+		}
+
+		if err = insert.exec(ctx, args); err != nil {
+			return 0, 0, err
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return 0, 0, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return count, 0, nil
+}
+
+// bulkPrice is a price which has passed the quarantine checks, waiting to be
+// looked up and written by the bulk insert path.
+type bulkPrice struct {
+	nodeId string
+	source string
+	price  models.FuelPrice
+}
+
+// priceContext is what the bulk insert path looks up for each price: the run
+// in force, if any, the price of the run after it, and what an anomaly check
+// compares the price with.
+type priceContext struct {
+	runId     sql.NullInt64
+	runPrice  sql.NullFloat64
+	nextPrice sql.NullFloat64
+	anomaly   anomalyContext
+}
+
+func lookupPrices(ctx context.Context, lookup *bulkStatement, chunk []bulkPrice) ([]priceContext, error) {
+	args := make([]any, 0, len(chunk)*lookup.width)
+	for i, p := range chunk {
+		args = append(args, i, p.nodeId, p.price.FuelType, p.price.PriceLastUpdated)
+	}
+
+	rows, err := lookup.query(ctx, args)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if closeErr := rows.Close(); closeErr != nil {
+			log.Printf("failed to close rows: %v", closeErr)
+		}
+	}()
+
+	results := make([]priceContext, len(chunk))
+	for rows.Next() {
+		var idx int
+		var result priceContext
+		if err := rows.Scan(&idx, &result.runId, &result.runPrice, &result.nextPrice, &result.anomaly.previous, &result.anomaly.previousNormal, &result.anomaly.area); err != nil {
+			return nil, fmt.Errorf("failed to scan row: %w", err)
+		}
+		results[idx] = result
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating over rows: %w", err)
+	}
+
+	return results, nil
+}
+
+// bulkInsertPrices is InsertPrices for large batches: prices are looked up,
+// confirmed and written a chunk at a time, rather than a row at a time.
+func (repo *sqlRepository) bulkInsertPrices(ctx context.Context, batch []models.ForecourtPrices) (int, int, error) {
+	anomalies, err := repo.newAnomalyDetector()
+	if err != nil {
+		return 0, 0, err
+	}
+
+	defer repo.metrics.Record(time.Now(), "bulkInsertPrices")
+	tx, err := repo.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			if rbErr := tx.Rollback(); rbErr != nil {
+				log.Printf("error rolling back transaction: %v", rbErr)
+			}
+		}
+	}()
+
+	lookup, err := prepareBulkStatement(tx, repo.q.bulkPriceContext)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer lookup.close()
+
+	insert, err := prepareBulkStatement(tx, repo.q.insertPrices)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer insert.close()
+
+	confirm, err := prepareBulkStatement(tx, repo.q.bulkConfirmPrices)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer confirm.close()
+
+	quarantine, err := repo.prepareQuarantine(ctx, tx)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer quarantine.close()
+
+	batchInfo, _ := BatchInfoFrom(ctx)
+	bounds := repo.PriceBounds()
+
+	dropped := 0
+	prices := make([]bulkPrice, 0, len(batch)*4)
+	for _, forecourtPrices := range batch {
+		for _, fuelPrice := range forecourtPrices.FuelPrices {
+			fuelPrice.FuelType = models.CanonicalFuelType(fuelPrice.FuelType)
+			var drop bool
+			if drop, err = quarantine.screen(ctx, &fuelPrice, forecourtPrices.NodeId, forecourtPrices.Source, bounds, batchInfo); err != nil {
+				return 0, 0, err
+			}
+			if drop {
+				dropped++
+				continue
+			}
+			prices = append(prices, bulkPrice{nodeId: forecourtPrices.NodeId, source: forecourtPrices.Source, price: fuelPrice})
+		}
+	}
+
+	count := 0
+	key := func(p bulkPrice) string { return p.nodeId + "\x00" + p.price.FuelType }
+	for chunk := range bulkChunks(prices, key) {
+		var contexts []priceContext
+		if contexts, err = lookupPrices(ctx, lookup, chunk); err != nil {
+			return 0, 0, err
+		}
+
+		var inserts, confirms []any
+		var folds []bulkPrice
+		for i, p := range chunk {
+			c := contexts[i]
+			tuple, price := p.price.ToTuple(p.nodeId, p.source)
+			count++
+
+			if c.runId.Valid && sameValue(price, c.runPrice.Float64) {
+				confirms = append(confirms, c.runId.Int64, p.price.PriceLastUpdated)
+				continue
+			}
+
+			anomaly, reference := anomalies.evaluate(p.price.FuelType, price, c.anomaly)
+			if anomaly != "" {
+				log.Printf("WARNING: %s price of %0.2fp for node_id: %s flagged as %s anomaly", p.price.FuelType, p.price.Price, p.nodeId, anomaly)
+			}
+			inserts = append(append(inserts, tuple...), nullString(anomaly), reference, p.price.PriceLastUpdated)
+
+			// A price which lands out of turn ahead of a run of the same price
+			// takes that run over, once it has been written.
+			if c.nextPrice.Valid && sameValue(price, c.nextPrice.Float64) {
+				folds = append(folds, p)
+			}
+		}
+
+		if err = confirm.exec(ctx, confirms); err != nil {
+			return 0, 0, err
+		}
+		if err = insert.exec(ctx, inserts); err != nil {
+			return 0, 0, err
+		}
+		for _, p := range folds {
+			if err = repo.foldNextRun(ctx, tx, p.nodeId, p.price.FuelType, p.price.PriceLastUpdated); err != nil {
+				return 0, 0, err
+			}
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return 0, 0, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return count, dropped, nil
+}
@@ -0,0 +1,255 @@
+package internal
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"slices"
+	"time"
+
+	"github.com/rm-hull/fuel-prices-api/internal/models"
+)
+
+type cleanseAction int
+
+const (
+	cleanseKeep cleanseAction = iota
+	cleanseUpdate
+	cleanseDelete
+)
+
+// cleanseRule corrects or deletes a stored price, given the bounds for its
+// fuel type.
+type cleanseRule struct {
+	name        string
+	description string
+	apply       func(price float64, bounds models.PriceBounds) (float64, cleanseAction)
+}
+
+// placeholderPrices are values keyed in when a price was not known. They must
+// match those selected by cleanse_candidates.sql.
+var placeholderPrices = []float64{0.999, 99.9, 999.9, 999.99}
+
+// cleanseRules are applied in order to each stored price which is either a
+// placeholder or outside the bounds for its fuel type, so a price may be
+// changed by more than one rule.
+var cleanseRules = []cleanseRule{
+	{
+		name:        "placeholder",
+		description: "Delete placeholder prices such as 999.9",
+		apply: func(price float64, _ models.PriceBounds) (float64, cleanseAction) {
+			if slices.ContainsFunc(placeholderPrices, func(p float64) bool { return sameValue(p, price) }) {
+				return price, cleanseDelete
+			}
+			return price, cleanseKeep
+		},
+	},
+	{
+		name:        "rescale",
+		description: "Convert prices given in pounds or tenths of a penny into pence, as on import",
+		apply: func(price float64, _ models.PriceBounds) (float64, cleanseAction) {
+			if cleansed, msg := models.CleansePrice(price); msg != "" {
+				return cleansed, cleanseUpdate
+			}
+			return price, cleanseKeep
+		},
+	},
+	{
+		name:        "shifted-decimal",
+		description: "Multiply implausible prices from 12p to 20p by ten, when that makes them plausible",
+		apply: func(price float64, bounds models.PriceBounds) (float64, cleanseAction) {
+			if price >= 12 && price < 20 && !bounds.Contains(price) && bounds.Contains(price*10) {
+				return price * 10, cleanseUpdate
+			}
+			return price, cleanseKeep
+		},
+	},
+	{
+		name:        "missing-hundred",
+		description: "Add 100p to implausible prices from 20p to 100p, when that makes them plausible",
+		apply: func(price float64, bounds models.PriceBounds) (float64, cleanseAction) {
+			if price >= 20 && price < 100 && !bounds.Contains(price) && bounds.Contains(price+100) {
+				return price + 100, cleanseUpdate
+			}
+			return price, cleanseKeep
+		},
+	},
+	{
+		name:        "out-of-bounds",
+		description: "Delete prices which are still outside the bounds for their fuel type",
+		apply: func(price float64, bounds models.PriceBounds) (float64, cleanseAction) {
+			if !bounds.Contains(price) {
+				return price, cleanseDelete
+			}
+			return price, cleanseKeep
+		},
+	},
+}
+
+type cleanseCandidate struct {
+	nodeId                        string
+	fuelType                      string
+	priceLastUpdated              time.Time
+	priceChangeEffectiveTimestamp *time.Time
+	source                        string
+	price                         float64
+}
+
+// Cleanse applies the cleanse rules to stored prices within a single
+// transaction, recording every change in the cleanse_audit table. A dry run
+// rolls the transaction back, so reports what would change without doing so.
+func (repo *sqlRepository) Cleanse(ctx context.Context, dryRun bool) (*models.CleanseReport, error) {
+	defer repo.metrics.Record(time.Now(), "cleanse")
+	report := &models.CleanseReport{
+		RunId:  time.Now().UTC().Format("20060102T150405.000Z"),
+		DryRun: dryRun,
+		Rules:  make([]models.CleanseRuleResult, len(cleanseRules)),
+	}
+	for i, rule := range cleanseRules {
+		report.Rules[i] = models.CleanseRuleResult{Rule: rule.name, Description: rule.description}
+	}
+
+	tx, err := repo.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() {
+		if err != nil || dryRun {
+			if rbErr := tx.Rollback(); rbErr != nil {
+				log.Printf("error rolling back transaction: %v", rbErr)
+			}
+		}
+	}()
+
+	var candidates []cleanseCandidate
+	candidates, err = repo.cleanseCandidates(ctx, tx)
+	if err != nil {
+		return nil, err
+	}
+	report.Examined = len(candidates)
+
+	var update, remove, audit *sql.Stmt
+	for _, stmt := range []struct {
+		sql  string
+		dest **sql.Stmt
+	}{
+		{repo.q.cleanseUpdate, &update},
+		{repo.q.deletePrice, &remove},
+		{repo.q.insertCleanseAudit, &audit},
+	} {
+		if *stmt.dest, err = tx.PrepareContext(ctx, stmt.sql); err != nil {
+			return nil, fmt.Errorf("failed to prepare statement: %w", err)
+		}
+		defer func() {
+			if err := (*stmt.dest).Close(); err != nil {
+				log.Printf("failed to close statement: %v", err)
+			}
+		}()
+	}
+
+	bounds := repo.PriceBounds()
+	for _, candidate := range candidates {
+		price := candidate.price
+		for i, rule := range cleanseRules {
+			newPrice, action := rule.apply(price, bounds.For(candidate.fuelType))
+			if action == cleanseKeep {
+				continue
+			}
+
+			var after *float64
+			if action == cleanseUpdate {
+				after = &newPrice
+				_, err = update.ExecContext(ctx, newPrice, candidate.nodeId, candidate.fuelType, candidate.priceLastUpdated)
+				report.Rules[i].Updated++
+			} else {
+				_, err = remove.ExecContext(ctx, candidate.nodeId, candidate.fuelType, candidate.priceLastUpdated)
+				report.Rules[i].Deleted++
+			}
+			if err != nil {
+				return nil, fmt.Errorf("failed to apply cleanse rule %s to node_id %s: %w", rule.name, candidate.nodeId, err)
+			}
+
+			_, err = audit.ExecContext(ctx,
+				report.RunId, rule.name,
+				candidate.nodeId, candidate.fuelType, candidate.priceLastUpdated,
+				candidate.priceChangeEffectiveTimestamp, candidate.source,
+				price, after,
+			)
+			if err != nil {
+				return nil, fmt.Errorf("failed to write cleanse audit: %w", err)
+			}
+
+			if action == cleanseDelete {
+				break
+			}
+			price = newPrice
+		}
+	}
+
+	if !dryRun {
+		if err = tx.Commit(); err != nil {
+			return nil, fmt.Errorf("failed to commit transaction: %w", err)
+		}
+		repo.cache.Storage.Flush()
+	}
+
+	return report, nil
+}
+
+// cleanseCandidates returns the stored prices which are placeholders or out of
+// bounds for their fuel type, and so may be changed by the cleanse rules.
+func (repo *sqlRepository) cleanseCandidates(ctx context.Context, tx *sql.Tx) ([]cleanseCandidate, error) {
+	fuelTypes, err := queryStrings(ctx, tx, repo.q.fuelTypes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query fuel types: %w", err)
+	}
+
+	bounds := repo.PriceBounds()
+	var candidates []cleanseCandidate
+	for _, fuelType := range fuelTypes {
+		b := bounds.For(fuelType)
+		rows, err := tx.QueryContext(ctx, repo.q.cleanseCandidates, fuelType, b.Min, b.Max)
+		if err != nil {
+			return nil, fmt.Errorf("failed to execute cleanse candidates query: %w", err)
+		}
+		for rows.Next() {
+			var c cleanseCandidate
+			if err := rows.Scan(&c.nodeId, &c.fuelType, &c.priceLastUpdated, &c.priceChangeEffectiveTimestamp, &c.source, &c.price); err != nil {
+				_ = rows.Close()
+				return nil, fmt.Errorf("failed to scan row: %w", err)
+			}
+			candidates = append(candidates, c)
+		}
+		if err := rows.Err(); err != nil {
+			_ = rows.Close()
+			return nil, fmt.Errorf("error iterating over rows: %w", err)
+		}
+		if err := rows.Close(); err != nil {
+			log.Printf("failed to close rows: %v", err)
+		}
+	}
+	return candidates, nil
+}
+
+func queryStrings(ctx context.Context, tx *sql.Tx, query string) ([]string, error) {
+	rows, err := tx.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if closeErr := rows.Close(); closeErr != nil {
+			log.Printf("failed to close rows: %v", closeErr)
+		}
+	}()
+
+	var result []string
+	for rows.Next() {
+		var s string
+		if err := rows.Scan(&s); err != nil {
+			return nil, err
+		}
+		result = append(result, s)
+	}
+	return result, rows.Err()
+}
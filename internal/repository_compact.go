@@ -0,0 +1,208 @@
+package internal
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/rm-hull/fuel-prices-api/internal/models"
+)
+
+// priceRuns folds incoming prices which repeat a station's current price
+// into the stored row for that price, rather than adding another row.
+type priceRuns struct {
+	current *sql.Stmt
+	confirm *sql.Stmt
+}
+
+func (repo *sqlRepository) preparePriceRuns(ctx context.Context, tx *sql.Tx) (*priceRuns, error) {
+	current, err := tx.PrepareContext(ctx, repo.q.currentPriceRun)
+	if err != nil {
+		return nil, fmt.Errorf("failed to prepare price run statement: %w", err)
+	}
+	confirm, err := tx.PrepareContext(ctx, repo.q.confirmPrice)
+	if err != nil {
+		_ = current.Close()
+		return nil, fmt.Errorf("failed to prepare price run statement: %w", err)
+	}
+	return &priceRuns{current: current, confirm: confirm}, nil
+}
+
+func (r *priceRuns) close() {
+	for _, stmt := range []*sql.Stmt{r.current, r.confirm} {
+		if err := stmt.Close(); err != nil {
+			log.Printf("failed to close statement: %v", err)
+		}
+	}
+}
+
+// lookup returns when the price in force at the fuel price's timestamp was
+// first seen, and what it was, or a zero time if there is none.
+func (r *priceRuns) lookup(ctx context.Context, nodeId string, fp *models.FuelPrice) (time.Time, float64, error) {
+	var firstSeen time.Time
+	var price float64
+	err := r.current.QueryRowContext(ctx, nodeId, fp.FuelType, fp.PriceLastUpdated).Scan(&firstSeen, &price)
+	if errors.Is(err, sql.ErrNoRows) {
+		return time.Time{}, 0, nil
+	} else if err != nil {
+		return time.Time{}, 0, fmt.Errorf("failed to look up current price for node_id %s: %w", nodeId, err)
+	}
+	return firstSeen, price, nil
+}
+
+// confirmed records the fuel price as a confirmation of the price in force,
+// if it is the same, and reports whether it was.
+func (r *priceRuns) confirmed(ctx context.Context, nodeId string, fp *models.FuelPrice, price float64) (bool, error) {
+	firstSeen, current, err := r.lookup(ctx, nodeId, fp)
+	if err != nil || firstSeen.IsZero() || !sameValue(price, current) {
+		return false, err
+	}
+
+	if _, err := r.confirm.ExecContext(ctx, fp.PriceLastUpdated, nodeId, fp.FuelType, firstSeen); err != nil {
+		return false, fmt.Errorf("failed to confirm price for node_id %s: %w", nodeId, err)
+	}
+	return true, nil
+}
+
+// priceRun is a run of the same price as stored: when it was first seen, and
+// the latest time it was confirmed.
+type priceRun struct {
+	firstSeen     time.Time
+	price         float64
+	lastConfirmed time.Time
+}
+
+// lookupPriceRun returns the run found by the query for the given time, or nil
+// if there is none.
+func lookupPriceRun(ctx context.Context, tx *sql.Tx, query, nodeId, fuelType string, at time.Time) (*priceRun, error) {
+	var run priceRun
+	var lastConfirmed sql.NullTime
+	err := tx.QueryRowContext(ctx, query, nodeId, fuelType, at).Scan(&run.firstSeen, &run.price, &lastConfirmed)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to look up price run for node_id %s: %w", nodeId, err)
+	}
+	run.lastConfirmed = run.firstSeen
+	if lastConfirmed.Valid {
+		run.lastConfirmed = lastConfirmed.Time
+	}
+	return &run, nil
+}
+
+// foldNextRun folds the run which follows the given time into the one in force
+// at it, if they are for the same price, as they can be once a price has been
+// added or removed between them out of turn.
+func (repo *sqlRepository) foldNextRun(ctx context.Context, tx *sql.Tx, nodeId, fuelType string, at time.Time) error {
+	current, err := lookupPriceRun(ctx, tx, repo.q.priceRun, nodeId, fuelType, at)
+	if err != nil || current == nil {
+		return err
+	}
+	next, err := lookupPriceRun(ctx, tx, repo.q.nextPriceRun, nodeId, fuelType, at)
+	if err != nil || next == nil || !sameValue(current.price, next.price) {
+		return err
+	}
+
+	if _, err := tx.ExecContext(ctx, repo.q.deletePrice, nodeId, fuelType, next.firstSeen); err != nil {
+		return fmt.Errorf("failed to fold price run for node_id %s: %w", nodeId, err)
+	}
+	if _, err := tx.ExecContext(ctx, repo.q.confirmPrice, next.lastConfirmed, nodeId, fuelType, current.firstSeen); err != nil {
+		return fmt.Errorf("failed to confirm price for node_id %s: %w", nodeId, err)
+	}
+	return nil
+}
+
+// unfoldPrice takes a stored price back out of its run, as when a rescaled
+// price is rejected. Confirmations are not kept one by one, so a run last
+// confirmed by the price falls back to when it was first seen, and one started
+// by it but confirmed since is restarted at its latest confirmation.
+func (repo *sqlRepository) unfoldPrice(ctx context.Context, tx *sql.Tx, nodeId, fuelType string, at time.Time, price float64) error {
+	run, err := lookupPriceRun(ctx, tx, repo.q.priceRun, nodeId, fuelType, at)
+	if err != nil || run == nil || !sameValue(price, run.price) {
+		return err
+	}
+
+	switch {
+	case run.lastConfirmed.Before(at):
+		// The price no longer counts towards the run.
+		return nil
+	case run.lastConfirmed.After(at) && !run.firstSeen.Equal(at):
+		// Confirmed since, so the run stands as it is.
+		return nil
+	case run.lastConfirmed.After(at):
+		_, err = tx.ExecContext(ctx, repo.q.updatePriceRun, run.lastConfirmed, run.lastConfirmed, nodeId, fuelType, run.firstSeen)
+	case run.firstSeen.Equal(at):
+		if _, err = tx.ExecContext(ctx, repo.q.deletePrice, nodeId, fuelType, at); err == nil {
+			return repo.foldNextRun(ctx, tx, nodeId, fuelType, at)
+		}
+	default:
+		_, err = tx.ExecContext(ctx, repo.q.updatePriceRun, run.firstSeen, run.firstSeen, nodeId, fuelType, run.firstSeen)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to unfold price for node_id %s: %w", nodeId, err)
+	}
+	return nil
+}
+
+// Compact converts stored price history into one row per change of price,
+// as recorded on import: each run of the same price is folded into its first
+// row, which keeps the latest time the price was confirmed. The database is
+// then vacuumed to reclaim the space.
+func (repo *sqlRepository) Compact(ctx context.Context, dryRun bool) (*models.CompactReport, error) {
+
+	defer repo.metrics.Record(time.Now(), "compact")
+	report := &models.CompactReport{DryRun: dryRun}
+
+	tx, err := repo.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() {
+		if err != nil || dryRun {
+			if rbErr := tx.Rollback(); rbErr != nil {
+				log.Printf("error rolling back transaction: %v", rbErr)
+			}
+		}
+	}()
+
+	if _, err = tx.ExecContext(ctx, repo.q.compactDrop); err != nil {
+		return nil, fmt.Errorf("failed to drop price runs: %w", err)
+	}
+	if _, err = tx.ExecContext(ctx, repo.q.compactRuns); err != nil {
+		return nil, fmt.Errorf("failed to group prices into runs: %w", err)
+	}
+	if err = tx.QueryRowContext(ctx, repo.q.compactSummary).Scan(&report.Examined, &report.Kept); err != nil {
+		return nil, fmt.Errorf("failed to count price runs: %w", err)
+	}
+	report.Removed = report.Examined - report.Kept
+
+	if _, err = tx.ExecContext(ctx, repo.q.compactConfirm); err != nil {
+		return nil, fmt.Errorf("failed to update confirmed prices: %w", err)
+	}
+	if _, err = tx.ExecContext(ctx, repo.q.compactDelete); err != nil {
+		return nil, fmt.Errorf("failed to delete repeated prices: %w", err)
+	}
+	if _, err = tx.ExecContext(ctx, repo.q.compactDrop); err != nil {
+		return nil, fmt.Errorf("failed to drop price runs: %w", err)
+	}
+
+	if dryRun {
+		return report, nil
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	repo.cache.Storage.Flush()
+
+	if report.Removed > 0 {
+		if _, err := repo.db.ExecContext(ctx, repo.q.vacuum); err != nil {
+			return nil, fmt.Errorf("failed to vacuum database: %w", err)
+		}
+	}
+
+	return report, nil
+}
@@ -0,0 +1,245 @@
+package internal
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/rm-hull/fuel-prices-api/internal/models"
+)
+
+// CoordinateConfig configures how station coordinates are checked against
+// their postcode centroids.
+type CoordinateConfig struct {
+	// ThresholdKm is how far a station may be from its postcode centroid
+	// before its coordinates are flagged.
+	ThresholdKm float64
+
+	// Repair replaces flagged coordinates, either by swapping latitude and
+	// longitude back or with the postcode centroid.
+	Repair bool
+}
+
+func DefaultCoordinateConfig() CoordinateConfig {
+	return CoordinateConfig{ThresholdKm: 5}
+}
+
+// LoadCoordinateConfig reads the coordinate validation configuration from
+// FUEL_PRICES_COORDINATE_THRESHOLD_KM and FUEL_PRICES_REPAIR_COORDINATES,
+// falling back to the defaults for either which is unset.
+func LoadCoordinateConfig() (CoordinateConfig, error) {
+	cfg := DefaultCoordinateConfig()
+
+	if value := os.Getenv("FUEL_PRICES_COORDINATE_THRESHOLD_KM"); value != "" {
+		threshold, err := strconv.ParseFloat(value, 64)
+		if err != nil || threshold <= 0 {
+			return cfg, fmt.Errorf("invalid FUEL_PRICES_COORDINATE_THRESHOLD_KM %q", value)
+		}
+		cfg.ThresholdKm = threshold
+	}
+
+	if value := os.Getenv("FUEL_PRICES_REPAIR_COORDINATES"); value != "" {
+		repair, err := strconv.ParseBool(value)
+		if err != nil {
+			return cfg, fmt.Errorf("invalid FUEL_PRICES_REPAIR_COORDINATES %q", value)
+		}
+		cfg.Repair = repair
+	}
+
+	return cfg, nil
+}
+
+func (repo *sqlRepository) ConfigureCoordinateValidation(cfg CoordinateConfig) {
+	repo.configMu.Lock()
+	defer repo.configMu.Unlock()
+
+	repo.coordinateConfig = cfg
+}
+
+// coordinateCheck is the outcome of validating a station's coordinates. The
+// zero value means they were fine, or could not be checked.
+type coordinateCheck struct {
+	issue             *string
+	distanceKm        *float64
+	originalLatitude  *float64
+	originalLongitude *float64
+}
+
+func (c coordinateCheck) tuple() []any {
+	return []any{c.issue, c.distanceKm, c.originalLatitude, c.originalLongitude}
+}
+
+// coordinateValidator checks station coordinates against postcode centroids
+// within an insert transaction.
+type coordinateValidator struct {
+	cfg      CoordinateConfig
+	centroid *sql.Stmt
+}
+
+func (repo *sqlRepository) prepareCoordinateValidator(ctx context.Context, tx *sql.Tx) (*coordinateValidator, error) {
+	repo.configMu.Lock()
+	cfg := repo.coordinateConfig
+	repo.configMu.Unlock()
+
+	centroid, err := tx.PrepareContext(ctx, repo.q.postcodeCentroid)
+	if err != nil {
+		return nil, fmt.Errorf("failed to prepare postcode centroid statement: %w", err)
+	}
+	return &coordinateValidator{cfg: cfg, centroid: centroid}, nil
+}
+
+func (v *coordinateValidator) close() {
+	if err := v.centroid.Close(); err != nil {
+		log.Printf("failed to close statement: %v", err)
+	}
+}
+
+// check validates the station's coordinates, replacing them in place when
+// they are flagged and repair is enabled. Stations at 0,0 are always
+// flagged; UK stations without a known postcode are checked against the
+// bounds of the UK instead.
+func (v *coordinateValidator) check(ctx context.Context, pfs *models.PetrolFillingStation) (coordinateCheck, error) {
+	var centroid models.PostcodeCentroid
+	err := v.centroid.QueryRowContext(ctx, models.NormalisePostcode(pfs.Location.Postcode)).Scan(&centroid.Latitude, &centroid.Longitude)
+	found := err == nil
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return coordinateCheck{}, fmt.Errorf("failed to look up postcode centroid for %s: %w", pfs.NodeId, err)
+	}
+	return v.validate(pfs, centroid, found), nil
+}
+
+// validate checks the station's coordinates against the centroid of its
+// postcode, if it was found, or else against the bounds of the UK.
+func (v *coordinateValidator) validate(pfs *models.PetrolFillingStation, centroid models.PostcodeCentroid, found bool) coordinateCheck {
+	lat, lon := pfs.Location.Latitude, pfs.Location.Longitude
+	missing := lat == 0 && lon == 0
+
+	var result coordinateCheck
+	var issue string
+	switch {
+	case missing:
+		issue = models.CoordinatesMissing
+	case !found:
+		if !pfs.InUK() || models.InUK(lat, lon) {
+			return result
+		}
+		issue = models.CoordinatesOutOfBounds
+		if models.InUK(lon, lat) {
+			issue = models.CoordinatesSwapped
+		}
+	default:
+		distance := models.DistanceKm(lat, lon, centroid.Latitude, centroid.Longitude)
+		if distance <= v.cfg.ThresholdKm {
+			return result
+		}
+		result.distanceKm = &distance
+		issue = models.CoordinatesMismatch
+		if models.DistanceKm(lon, lat, centroid.Latitude, centroid.Longitude) <= v.cfg.ThresholdKm {
+			issue = models.CoordinatesSwapped
+		}
+	}
+	result.issue = &issue
+
+	if !v.cfg.Repair || (issue != models.CoordinatesSwapped && !found) {
+		return result
+	}
+
+	result.originalLatitude, result.originalLongitude = &lat, &lon
+	if issue == models.CoordinatesSwapped {
+		pfs.Location.Latitude, pfs.Location.Longitude = lon, lat
+	} else {
+		pfs.Location.Latitude, pfs.Location.Longitude = centroid.Latitude, centroid.Longitude
+	}
+	return result
+}
+
+// InsertPostcodeCentroids adds or replaces the given postcode centroids.
+func (repo *sqlRepository) InsertPostcodeCentroids(ctx context.Context, batch []models.PostcodeCentroid) (int, error) {
+	if len(batch) == 0 {
+		return 0, nil
+	}
+
+	defer repo.metrics.Record(time.Now(), "insertPostcodeCentroids")
+	tx, err := repo.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			if rbErr := tx.Rollback(); rbErr != nil {
+				log.Printf("error rolling back transaction: %v", rbErr)
+			}
+		}
+	}()
+
+	stmt, err := tx.PrepareContext(ctx, repo.q.insertPostcodeCentroid)
+	if err != nil {
+		return 0, fmt.Errorf("failed to prepare statement: %w", err)
+	}
+	defer func() {
+		if err := stmt.Close(); err != nil {
+			log.Printf("failed to close statement: %v", err)
+		}
+	}()
+
+	for _, centroid := range batch {
+		if _, err = stmt.ExecContext(ctx, models.NormalisePostcode(centroid.Postcode), centroid.Latitude, centroid.Longitude); err != nil {
+			return 0, fmt.Errorf("failed to insert postcode centroid %s: %w", centroid.Postcode, err)
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return len(batch), nil
+}
+
+// CoordinateIssues lists stations whose coordinates were flagged, optionally
+// only those of one kind of issue, or only those which were repaired.
+func (repo *sqlRepository) CoordinateIssues(issue string, repairedOnly bool, limit, offset int) ([]models.CoordinateIssue, error) {
+
+	defer repo.metrics.Record(time.Now(), "coordinateIssues")
+	rows, err := repo.db.Query(repo.q.coordinateIssues, issue, issue, repairedOnly, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute coordinate issues query: %w", err)
+	}
+	defer func() {
+		if closeErr := rows.Close(); closeErr != nil {
+			log.Printf("failed to close rows: %v", closeErr)
+		}
+	}()
+
+	results := make([]models.CoordinateIssue, 0, limit)
+	for rows.Next() {
+		var result models.CoordinateIssue
+		if err := rows.Scan(
+			&result.NodeId,
+			&result.TradingName,
+			&result.Postcode,
+			&result.Issue,
+			&result.DistanceKm,
+			&result.Latitude,
+			&result.Longitude,
+			&result.OriginalLatitude,
+			&result.OriginalLongitude,
+			&result.Source,
+			&result.UpdatedAt,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan row: %w", err)
+		}
+		result.Repaired = result.OriginalLatitude != nil
+		results = append(results, result)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating over rows: %w", err)
+	}
+
+	return results, nil
+}
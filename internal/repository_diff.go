@@ -0,0 +1,204 @@
+package internal
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"time"
+
+	"github.com/rm-hull/fuel-prices-api/internal/models"
+)
+
+// DiffPFS compares a batch of stations, after cleansing, with what is already
+// stored, without writing anything.
+func (repo *sqlRepository) DiffPFS(ctx context.Context, batch []models.PetrolFillingStation) (*models.ImportDiff, error) {
+	diff := &models.ImportDiff{ChangedAttributes: make(map[string]int)}
+	if len(batch) == 0 {
+		return diff, nil
+	}
+
+	defer repo.metrics.Record(time.Now(), "diffPFS")
+	stmt, err := repo.db.PrepareContext(ctx, repo.q.selectPfs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to prepare statement: %w", err)
+	}
+	defer func() {
+		if err := stmt.Close(); err != nil {
+			log.Printf("failed to close statement: %v", err)
+		}
+	}()
+
+	for _, pfs := range batch {
+		incoming := pfs.ToTuple()
+		rows, err := stmt.QueryContext(ctx, pfs.NodeId)
+		if err != nil {
+			return nil, fmt.Errorf("failed to query station %s: %w", pfs.NodeId, err)
+		}
+		columns, existing, err := scanRow(rows, len(incoming))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read station %s: %w", pfs.NodeId, err)
+		}
+
+		if existing == nil {
+			diff.NewStations++
+			continue
+		}
+
+		changed := false
+		for i, column := range columns {
+			if !sameValue(incoming[i], existing[i]) {
+				diff.ChangedAttributes[column]++
+				changed = true
+			}
+		}
+		if changed {
+			diff.ChangedStations++
+		} else {
+			diff.UnchangedStations++
+		}
+	}
+
+	return diff, nil
+}
+
+// DiffPrices compares a batch of prices, after cleansing, with what is already
+// stored, without writing anything. Prices which would be dropped or corrected
+// by cleansing are counted as well.
+func (repo *sqlRepository) DiffPrices(ctx context.Context, batch []models.ForecourtPrices) (*models.ImportDiff, error) {
+	diff := &models.ImportDiff{}
+	if len(batch) == 0 {
+		return diff, nil
+	}
+
+	defer repo.metrics.Record(time.Now(), "diffPrices")
+	stmt, err := repo.db.PrepareContext(ctx, repo.q.currentPriceRun)
+	if err != nil {
+		return nil, fmt.Errorf("failed to prepare statement: %w", err)
+	}
+	defer func() {
+		if err := stmt.Close(); err != nil {
+			log.Printf("failed to close statement: %v", err)
+		}
+	}()
+
+	bounds := repo.PriceBounds()
+	for _, forecourtPrices := range batch {
+		for _, fuelPrice := range forecourtPrices.FuelPrices {
+			fuelPrice.FuelType = models.CanonicalFuelType(fuelPrice.FuelType)
+			if fuelPrice.IsPriceOutOfBounds(bounds) {
+				diff.DroppedPrices++
+				continue
+			}
+			if fuelPrice.IsPriceCorrected() {
+				diff.CorrectedPrices++
+			}
+
+			_, price := fuelPrice.ToTuple(forecourtPrices.NodeId, forecourtPrices.Source)
+			var firstSeen time.Time
+			var existing float64
+			err := stmt.QueryRowContext(ctx, forecourtPrices.NodeId, fuelPrice.FuelType, fuelPrice.PriceLastUpdated).Scan(&firstSeen, &existing)
+			switch {
+			case errors.Is(err, sql.ErrNoRows):
+				diff.NewPrices++
+			case err != nil:
+				return nil, fmt.Errorf("failed to query price for node_id %s: %w", forecourtPrices.NodeId, err)
+			case sameValue(price, existing):
+				// A repeated price only confirms the one in force.
+				diff.UnchangedPrices++
+			case firstSeen.Equal(fuelPrice.PriceLastUpdated):
+				diff.ChangedPrices++
+			default:
+				diff.NewPrices++
+			}
+		}
+	}
+
+	return diff, nil
+}
+
+// scanRow reads at most one row of n columns, returning nil values if there
+// are no rows.
+func scanRow(rows *sql.Rows, n int) ([]string, []any, error) {
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(columns) != n {
+		return nil, nil, fmt.Errorf("expected %d columns, got %d", n, len(columns))
+	}
+	if !rows.Next() {
+		return columns, nil, rows.Err()
+	}
+
+	values := make([]any, n)
+	dest := make([]any, n)
+	for i := range values {
+		dest[i] = &values[i]
+	}
+	if err := rows.Scan(dest...); err != nil {
+		return nil, nil, err
+	}
+	return columns, values, rows.Err()
+}
+
+// sameValue compares a value about to be written with one read back from the
+// database, allowing for the driver's conversions.
+func sameValue(incoming, existing any) bool {
+	incoming = normaliseValue(incoming)
+	existing = normaliseValue(existing)
+
+	switch a := incoming.(type) {
+	case time.Time:
+		b, ok := existing.(time.Time)
+		return ok && a.Equal(b)
+	case float64:
+		b, ok := existing.(float64)
+		return ok && math.Abs(a-b) < 1e-9
+	default:
+		return incoming == existing
+	}
+}
+
+func normaliseValue(value any) any {
+	switch v := value.(type) {
+	case *time.Time:
+		if v == nil {
+			return nil
+		}
+		return *v
+	case bool:
+		if v {
+			return int64(1)
+		}
+		return int64(0)
+	case []byte:
+		return string(v)
+	case int:
+		return int64(v)
+	default:
+		return v
+	}
+}
+
+// DryRun adapts one of the repository's diff methods into a batch callback,
+// so that a fetch accumulates into total rather than writing to the database.
+func DryRun[T any](total *models.ImportDiff, diff func(context.Context, []T) (*models.ImportDiff, error)) BatchCallback[T] {
+	return func(ctx context.Context, batch []T) (int, int, error) {
+		batchDiff, err := diff(ctx, batch)
+		if err != nil {
+			return 0, 0, err
+		}
+		total.Add(batchDiff)
+
+		count := batchDiff.NewStations + batchDiff.ChangedStations + batchDiff.UnchangedStations +
+			batchDiff.NewPrices + batchDiff.ChangedPrices + batchDiff.UnchangedPrices
+		return count, batchDiff.DroppedPrices, nil
+	}
+}
@@ -0,0 +1,179 @@
+package internal
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/rm-hull/fuel-prices-api/internal/models"
+)
+
+// StartImportRun records the start of the run in the ledger, setting its id
+// and status.
+func (repo *sqlRepository) StartImportRun(ctx context.Context, run *models.ImportRun) error {
+
+	defer repo.metrics.Record(time.Now(), "startImportRun")
+	if run.StartedAt.IsZero() {
+		run.StartedAt = time.Now().UTC()
+	}
+	if err := repo.db.QueryRowContext(ctx, repo.q.startImportRun, run.Job, run.Source, run.TriggeredBy, run.StartedAt.UTC()).Scan(&run.Id); err != nil {
+		return fmt.Errorf("failed to start import run: %w", err)
+	}
+	run.Status = models.ImportRunning
+	return nil
+}
+
+// RecordImportBatch adds a batch applied during the run, and its records to
+// the run's running totals.
+func (repo *sqlRepository) RecordImportBatch(ctx context.Context, runId int64, batch models.ImportRunBatch) error {
+
+	defer repo.metrics.Record(time.Now(), "recordImportBatch")
+	tx, err := repo.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			if rbErr := tx.Rollback(); rbErr != nil {
+				log.Printf("error rolling back transaction: %v", rbErr)
+			}
+		}
+	}()
+
+	var fetchedAt sql.NullTime
+	if batch.FetchedAt != nil {
+		fetchedAt = nullTime(batch.FetchedAt.UTC())
+	}
+	if _, err = tx.ExecContext(ctx, repo.q.insertImportBatch, runId, batch.Number, batch.Path, batch.Records, batch.Dropped, fetchedAt); err != nil {
+		return fmt.Errorf("failed to record batch %d of import run %d: %w", batch.Number, runId, err)
+	}
+	if _, err = tx.ExecContext(ctx, repo.q.updateImportProgress, batch.Records, batch.Dropped, runId); err != nil {
+		return fmt.Errorf("failed to update import run %d: %w", runId, err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// FinishImportRun records the outcome of the run, taking its inserted and
+// dropped totals from run, and updates the import metrics.
+func (repo *sqlRepository) FinishImportRun(ctx context.Context, run *models.ImportRun, runErr error) error {
+
+	defer repo.metrics.Record(time.Now(), "finishImportRun")
+	finishedAt := time.Now().UTC()
+	duration := finishedAt.Sub(run.StartedAt)
+	durationMs := duration.Milliseconds()
+
+	run.Status = models.ImportSucceeded
+	run.Error = nil
+	if runErr != nil {
+		message := runErr.Error()
+		run.Status = models.ImportFailed
+		run.Error = &message
+	}
+	run.FinishedAt = &finishedAt
+	run.DurationMs = &durationMs
+
+	repo.imports.RecordRun(run.Job, run.Source, finishedAt, duration, runErr == nil)
+
+	if _, err := repo.db.ExecContext(ctx, repo.q.finishImportRun, run.Status, finishedAt, durationMs, run.Inserted, run.Dropped, run.Error, run.Id); err != nil {
+		return fmt.Errorf("failed to finish import run %d: %w", run.Id, err)
+	}
+	return nil
+}
+
+func (repo *sqlRepository) ImportRuns(filter models.ImportRunFilter, limit, offset int) ([]models.ImportRun, error) {
+
+	defer repo.metrics.Record(time.Now(), "importRuns")
+	rows, err := repo.db.Query(repo.q.listImportRuns,
+		filter.Job, filter.Job,
+		filter.Source, filter.Source,
+		filter.Status, filter.Status,
+		filter.TriggeredBy, filter.TriggeredBy,
+		filter.Since.UTC(),
+		limit, offset,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute import runs query: %w", err)
+	}
+	defer func() {
+		if closeErr := rows.Close(); closeErr != nil {
+			log.Printf("failed to close rows: %v", closeErr)
+		}
+	}()
+
+	results := make([]models.ImportRun, 0, limit)
+	for rows.Next() {
+		var result models.ImportRun
+		if err := scanImportRun(rows, &result); err != nil {
+			return nil, fmt.Errorf("failed to scan row: %w", err)
+		}
+		results = append(results, result)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating over rows: %w", err)
+	}
+
+	return results, nil
+}
+
+// ImportRun returns the run with the given id, along with its batches.
+func (repo *sqlRepository) ImportRun(id int64) (*models.ImportRun, error) {
+
+	defer repo.metrics.Record(time.Now(), "importRun")
+	var result models.ImportRun
+	err := scanImportRun(repo.db.QueryRow(repo.q.importRun, id), &result)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("import run %d: %w", id, ErrNotFound)
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to look up import run %d: %w", id, err)
+	}
+
+	rows, err := repo.db.Query(repo.q.importRunBatches, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute import run batches query: %w", err)
+	}
+	defer func() {
+		if closeErr := rows.Close(); closeErr != nil {
+			log.Printf("failed to close rows: %v", closeErr)
+		}
+	}()
+
+	result.BatchDetails = make([]models.ImportRunBatch, 0, result.Batches)
+	for rows.Next() {
+		var batch models.ImportRunBatch
+		if err := rows.Scan(&batch.Number, &batch.Path, &batch.Records, &batch.Dropped, &batch.FetchedAt, &batch.AppliedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan row: %w", err)
+		}
+		result.BatchDetails = append(result.BatchDetails, batch)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating over rows: %w", err)
+	}
+
+	return &result, nil
+}
+
+func scanImportRun(row interface{ Scan(...any) error }, result *models.ImportRun) error {
+	return row.Scan(
+		&result.Id,
+		&result.Job,
+		&result.Source,
+		&result.TriggeredBy,
+		&result.Status,
+		&result.StartedAt,
+		&result.FinishedAt,
+		&result.DurationMs,
+		&result.Batches,
+		&result.Inserted,
+		&result.Dropped,
+		&result.Error,
+	)
+}
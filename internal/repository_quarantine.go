@@ -0,0 +1,248 @@
+package internal
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/rm-hull/fuel-prices-api/internal/metrics"
+	"github.com/rm-hull/fuel-prices-api/internal/models"
+)
+
+var (
+	// ErrNotFound is returned when a requested record does not exist.
+	ErrNotFound = errors.New("not found")
+
+	// ErrAlreadyReviewed is returned when reviewing a quarantined price which
+	// has already been approved or rejected.
+	ErrAlreadyReviewed = errors.New("already reviewed")
+)
+
+// quarantineWriter adds prices to the quarantine table within an insert
+// transaction.
+type quarantineWriter struct {
+	insert  *sql.Stmt
+	status  *sql.Stmt
+	metrics *metrics.PriceCheckMetrics
+}
+
+func (repo *sqlRepository) prepareQuarantine(ctx context.Context, tx *sql.Tx) (*quarantineWriter, error) {
+	insert, err := tx.PrepareContext(ctx, repo.q.insertQuarantine)
+	if err != nil {
+		return nil, fmt.Errorf("failed to prepare quarantine statement: %w", err)
+	}
+	status, err := tx.PrepareContext(ctx, repo.q.quarantineStatus)
+	if err != nil {
+		_ = insert.Close()
+		return nil, fmt.Errorf("failed to prepare quarantine status statement: %w", err)
+	}
+	return &quarantineWriter{insert: insert, status: status, metrics: repo.checks}, nil
+}
+
+func (q *quarantineWriter) close() {
+	for _, stmt := range []*sql.Stmt{q.insert, q.status} {
+		if err := stmt.Close(); err != nil {
+			log.Printf("failed to close statement: %v", err)
+		}
+	}
+}
+
+// add quarantines the price, unless it already has been for the same reason,
+// and returns the review status of the quarantined record.
+func (q *quarantineWriter) add(ctx context.Context, fp *models.FuelPrice, nodeId, source, reason string, batch BatchInfo) (string, error) {
+	if source == "" {
+		source = models.SourceFuelFinder
+	}
+	args := append(fp.QuarantineTuple(nodeId, source, reason), nullString(batch.Path), nullInt(batch.Number), nullTime(batch.FetchedAt))
+	result, err := q.insert.ExecContext(ctx, args...)
+	if err != nil {
+		return "", fmt.Errorf("failed to quarantine price: %w", err)
+	}
+	if n, err := result.RowsAffected(); err == nil && n > 0 {
+		q.metrics.RecordQuarantined(reason, source)
+		return models.QuarantinePending, nil
+	}
+
+	var status string
+	if err := q.status.QueryRowContext(ctx, nodeId, fp.FuelType, fp.PriceLastUpdated, reason).Scan(&status); err != nil {
+		return "", fmt.Errorf("failed to look up quarantined price: %w", err)
+	}
+	return status, nil
+}
+
+// screen quarantines the fuel price if it fails the price checks, and reports
+// whether it must be dropped rather than written: out-of-bounds prices always
+// are, other prices only once they have been rejected.
+func (q *quarantineWriter) screen(ctx context.Context, fp *models.FuelPrice, nodeId, source string, bounds *models.PriceBoundsSet, batch BatchInfo) (bool, error) {
+	reason := fp.QuarantineReason(bounds)
+	if reason == "" {
+		return false, nil
+	}
+
+	status, err := q.add(ctx, fp, nodeId, source, reason, batch)
+	if err != nil {
+		return false, err
+	}
+	if reason == models.QuarantineOutOfBounds {
+		log.Printf("WARNING: %s price of %0.2fp looks like an input-entry error; quarantining fuel_price record for node_id: %s", fp.FuelType, fp.Price, nodeId)
+		return true, nil
+	}
+	return status == models.QuarantineRejected, nil
+}
+
+func (repo *sqlRepository) QuarantinedPrices(status string, limit, offset int) ([]models.QuarantinedPrice, error) {
+
+	defer repo.metrics.Record(time.Now(), "quarantinedPrices")
+	rows, err := repo.db.Query(repo.q.listQuarantine, status, status, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute quarantine query: %w", err)
+	}
+	defer func() {
+		if closeErr := rows.Close(); closeErr != nil {
+			log.Printf("failed to close rows: %v", closeErr)
+		}
+	}()
+
+	results := make([]models.QuarantinedPrice, 0, limit)
+	for rows.Next() {
+		var result models.QuarantinedPrice
+		if err := scanQuarantinedPrice(rows, &result); err != nil {
+			return nil, fmt.Errorf("failed to scan row: %w", err)
+		}
+		results = append(results, result)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating over rows: %w", err)
+	}
+
+	return results, nil
+}
+
+// ReviewQuarantinedPrice approves or rejects a pending quarantined price.
+// Approving promotes its corrected price into fuel_prices; rejecting a
+// rescaled price takes the rescaled value which was stored in its place back
+// out of its run of prices.
+func (repo *sqlRepository) ReviewQuarantinedPrice(ctx context.Context, id int64, approve bool) (*models.QuarantinedPrice, error) {
+
+	defer repo.metrics.Record(time.Now(), "reviewQuarantinedPrice")
+	tx, err := repo.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			if rbErr := tx.Rollback(); rbErr != nil {
+				log.Printf("error rolling back transaction: %v", rbErr)
+			}
+		}
+	}()
+
+	decision := models.QuarantineRejected
+	if approve {
+		decision = models.QuarantineApproved
+	}
+
+	var result models.QuarantinedPrice
+	err = scanQuarantinedPrice(tx.QueryRowContext(ctx, repo.q.reviewQuarantine, decision, id), &result)
+	if errors.Is(err, sql.ErrNoRows) {
+		var status string
+		if err = tx.QueryRowContext(ctx, repo.q.quarantineStatusById, id).Scan(&status); errors.Is(err, sql.ErrNoRows) {
+			err = fmt.Errorf("quarantined price %d: %w", id, ErrNotFound)
+			return nil, err
+		} else if err != nil {
+			return nil, fmt.Errorf("failed to look up quarantined price %d: %w", id, err)
+		}
+		err = fmt.Errorf("quarantined price %d is %s: %w", id, status, ErrAlreadyReviewed)
+		return nil, err
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to review quarantined price %d: %w", id, err)
+	}
+
+	switch {
+	case approve:
+		if err = repo.promotePrice(ctx, tx, &result); err != nil {
+			return nil, fmt.Errorf("failed to promote quarantined price %d: %w", id, err)
+		}
+	case result.Reason == models.QuarantineRescaled:
+		if err = repo.unfoldPrice(ctx, tx, result.NodeId, result.FuelType, result.PriceLastUpdated, result.CorrectedPrice); err != nil {
+			return nil, fmt.Errorf("failed to remove rescaled price %d: %w", id, err)
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	repo.checks.RecordReview(decision)
+	return &result, nil
+}
+
+// promotePrice stores the corrected price of an approved quarantined price as
+// it would have been on import: as a confirmation of the price in force if it
+// is the same, and otherwise as a change of price.
+func (repo *sqlRepository) promotePrice(ctx context.Context, tx *sql.Tx, result *models.QuarantinedPrice) error {
+	runs, err := repo.preparePriceRuns(ctx, tx)
+	if err != nil {
+		return err
+	}
+	defer runs.close()
+
+	fp := models.FuelPrice{FuelType: result.FuelType, Price: result.CorrectedPrice, PriceLastUpdated: result.PriceLastUpdated}
+	confirmed, err := runs.confirmed(ctx, result.NodeId, &fp, result.CorrectedPrice)
+	if err != nil || confirmed {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, repo.q.insertPrices,
+		result.NodeId,
+		result.FuelType,
+		result.PriceLastUpdated,
+		result.CorrectedPrice,
+		result.PriceChangeEffectiveTimestamp,
+		result.Source,
+		nil,
+		nil,
+		result.PriceLastUpdated,
+	)
+	if err != nil {
+		return err
+	}
+	return repo.foldNextRun(ctx, tx, result.NodeId, result.FuelType, result.PriceLastUpdated)
+}
+
+func scanQuarantinedPrice(row interface{ Scan(...any) error }, result *models.QuarantinedPrice) error {
+	return row.Scan(
+		&result.Id,
+		&result.NodeId,
+		&result.FuelType,
+		&result.PriceLastUpdated,
+		&result.PriceChangeEffectiveTimestamp,
+		&result.OriginalPrice,
+		&result.CorrectedPrice,
+		&result.Reason,
+		&result.Source,
+		&result.BatchPath,
+		&result.BatchNumber,
+		&result.BatchFetchedAt,
+		&result.Status,
+		&result.QuarantinedAt,
+		&result.ReviewedAt,
+	)
+}
+
+func nullString(s string) sql.NullString {
+	return sql.NullString{String: s, Valid: s != ""}
+}
+
+func nullInt(n int) sql.NullInt64 {
+	return sql.NullInt64{Int64: int64(n), Valid: n != 0}
+}
+
+func nullTime(t time.Time) sql.NullTime {
+	return sql.NullTime{Time: t, Valid: !t.IsZero()}
+}
@@ -0,0 +1,217 @@
+package internal
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"strconv"
+	"time"
+
+	"github.com/rm-hull/fuel-prices-api/internal/models"
+)
+
+// stationChangeLog records the attributes of stations which change within an
+// insert transaction, by comparing each with the row it is about to replace.
+// Inactive stations being seen again are logged as a change to "active".
+type stationChangeLog struct {
+	existing   *sql.Stmt
+	insert     *sql.Stmt
+	reappeared *sql.Stmt
+}
+
+func (repo *sqlRepository) prepareStationChangeLog(ctx context.Context, tx *sql.Tx) (*stationChangeLog, error) {
+	existing, err := tx.PrepareContext(ctx, repo.q.selectPfs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to prepare station statement: %w", err)
+	}
+	insert, err := tx.PrepareContext(ctx, repo.q.insertStationChange)
+	if err != nil {
+		_ = existing.Close()
+		return nil, fmt.Errorf("failed to prepare station change statement: %w", err)
+	}
+	reappeared, err := tx.PrepareContext(ctx, repo.q.recordStationReappeared)
+	if err != nil {
+		_ = existing.Close()
+		_ = insert.Close()
+		return nil, fmt.Errorf("failed to prepare station change statement: %w", err)
+	}
+	return &stationChangeLog{existing: existing, insert: insert, reappeared: reappeared}, nil
+}
+
+func (l *stationChangeLog) close() {
+	for _, stmt := range []*sql.Stmt{l.existing, l.insert, l.reappeared} {
+		if err := stmt.Close(); err != nil {
+			log.Printf("failed to close statement: %v", err)
+		}
+	}
+}
+
+// record logs each attribute of an existing station which differs from the
+// given tuple. New stations have nothing to log.
+func (l *stationChangeLog) record(ctx context.Context, nodeId, source string, incoming []any) error {
+	rows, err := l.existing.QueryContext(ctx, nodeId)
+	if err != nil {
+		return fmt.Errorf("failed to query station %s: %w", nodeId, err)
+	}
+	columns, existing, err := scanRow(rows, len(incoming))
+	if err != nil {
+		return fmt.Errorf("failed to read station %s: %w", nodeId, err)
+	}
+	if existing == nil {
+		return nil
+	}
+
+	if err := l.reappear(ctx, nodeId); err != nil {
+		return err
+	}
+	return l.log(ctx, nodeId, source, columns, existing, incoming)
+}
+
+// reappear logs the station becoming active again, if it was inactive.
+func (l *stationChangeLog) reappear(ctx context.Context, nodeId string) error {
+	if _, err := l.reappeared.ExecContext(ctx, nodeId); err != nil {
+		return fmt.Errorf("failed to record station %s reappearing: %w", nodeId, err)
+	}
+	return nil
+}
+
+// log records each of the named columns whose existing value differs from
+// the incoming one.
+func (l *stationChangeLog) log(ctx context.Context, nodeId, source string, columns []string, existing, incoming []any) error {
+	if source == "" {
+		source = models.SourceFuelFinder
+	}
+	for i, column := range columns {
+		if column == "node_id" || sameValue(incoming[i], existing[i]) {
+			continue
+		}
+		_, err := l.insert.ExecContext(ctx, nodeId, column, formatValue(existing[i]), formatValue(incoming[i]), source)
+		if err != nil {
+			return fmt.Errorf("failed to record change to %s for station %s: %w", column, nodeId, err)
+		}
+	}
+	return nil
+}
+
+// formatValue renders a column value as text for the station change log.
+func formatValue(value any) sql.NullString {
+	switch v := normaliseValue(value).(type) {
+	case nil:
+		return sql.NullString{}
+	case time.Time:
+		return sql.NullString{String: v.UTC().Format(time.RFC3339), Valid: true}
+	case float64:
+		return sql.NullString{String: strconv.FormatFloat(v, 'f', -1, 64), Valid: true}
+	case int64:
+		return sql.NullString{String: strconv.FormatInt(v, 10), Valid: true}
+	case string:
+		return sql.NullString{String: v, Valid: true}
+	default:
+		return sql.NullString{String: fmt.Sprint(v), Valid: true}
+	}
+}
+
+func (repo *sqlRepository) StationChanges(nodeId, attribute string, limit int) ([]models.StationChange, error) {
+
+	defer repo.metrics.Record(time.Now(), "stationChanges")
+	rows, err := repo.db.Query(repo.q.stationChanges, nodeId, attribute, attribute, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute station changes query: %w", err)
+	}
+	defer func() {
+		if closeErr := rows.Close(); closeErr != nil {
+			log.Printf("failed to close rows: %v", closeErr)
+		}
+	}()
+
+	results := make([]models.StationChange, 0, 10)
+	for rows.Next() {
+		var result models.StationChange
+		if err := rows.Scan(&result.Attribute, &result.OldValue, &result.NewValue, &result.Source, &result.ChangedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan row: %w", err)
+		}
+		results = append(results, result)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating over rows: %w", err)
+	}
+
+	return results, nil
+}
+
+// MarkMissingStations counts a missed refresh against each of the source's
+// stations which has not been seen since the given full refresh started, and
+// marks those which have now missed threshold refreshes in a row as inactive.
+// It returns the number of stations newly marked inactive.
+func (repo *sqlRepository) MarkMissingStations(ctx context.Context, source string, refreshStarted time.Time, threshold int) (int, error) {
+
+	defer repo.metrics.Record(time.Now(), "markMissingStations")
+	tx, err := repo.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			if rbErr := tx.Rollback(); rbErr != nil {
+				log.Printf("error rolling back transaction: %v", rbErr)
+			}
+		}
+	}()
+
+	if _, err = tx.ExecContext(ctx, repo.q.countMissedRefreshes, source, refreshStarted.UTC()); err != nil {
+		return 0, fmt.Errorf("failed to count missed refreshes: %w", err)
+	}
+	if _, err = tx.ExecContext(ctx, repo.q.recordStationVanished, source, threshold); err != nil {
+		return 0, fmt.Errorf("failed to record vanished stations: %w", err)
+	}
+
+	var result sql.Result
+	if result, err = tx.ExecContext(ctx, repo.q.deactivateStations, source, threshold); err != nil {
+		return 0, fmt.Errorf("failed to deactivate stations: %w", err)
+	}
+	var deactivated int64
+	if deactivated, err = result.RowsAffected(); err != nil {
+		return 0, fmt.Errorf("failed to count deactivated stations: %w", err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	if deactivated > 0 {
+		// The cached stats would otherwise still include the stations.
+		repo.cache.Storage.Flush()
+	}
+	return int(deactivated), nil
+}
+
+func (repo *sqlRepository) StationActivity(since time.Time, limit, offset int) ([]models.StationActivity, error) {
+
+	defer repo.metrics.Record(time.Now(), "stationActivity")
+	rows, err := repo.db.Query(repo.q.stationActivity, since.UTC(), limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute station activity query: %w", err)
+	}
+	defer func() {
+		if closeErr := rows.Close(); closeErr != nil {
+			log.Printf("failed to close rows: %v", closeErr)
+		}
+	}()
+
+	results := make([]models.StationActivity, 0, limit)
+	for rows.Next() {
+		var result models.StationActivity
+		if err := rows.Scan(&result.NodeId, &result.TradingName, &result.Postcode, &result.Event, &result.Source, &result.ChangedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan row: %w", err)
+		}
+		results = append(results, result)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating over rows: %w", err)
+	}
+
+	return results, nil
+}
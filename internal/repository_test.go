@@ -1,6 +1,11 @@
 package internal
 
 import (
+	"database/sql"
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"net/url"
 	"os"
 	"testing"
 	"time"
@@ -10,7 +15,15 @@ import (
 	"github.com/stretchr/testify/require"
 )
 
-func setupTestDB(t *testing.T) FuelPricesRepository {
+// testPostgresEnv names a PostgreSQL/PostGIS database, such as a postgis/postgis
+// container, to run the repository tests against instead of SQLite.
+const testPostgresEnv = "FUEL_PRICES_TEST_POSTGRES_DSN"
+
+func setupTestDB(t testing.TB) FuelPricesRepository {
+	if dsn := os.Getenv(testPostgresEnv); dsn != "" {
+		return setupTestPostgres(t, dsn)
+	}
+
 	tmpFile, err := os.CreateTemp("", "fuel_prices_test-*.db")
 	require.NoError(t, err)
 	dbPath := tmpFile.Name()
@@ -31,6 +44,42 @@ func setupTestDB(t *testing.T) FuelPricesRepository {
 	return NewFuelPricesRepository(db, &models.Retailers{})
 }
 
+// setupTestPostgres migrates a schema of the test's own, which is dropped
+// afterwards, so tests neither see nor disturb each other's data.
+func setupTestPostgres(t testing.TB, dsn string) FuelPricesRepository {
+	admin, err := Connect(dsn)
+	require.NoError(t, err)
+
+	schema := fmt.Sprintf("test_%016x", rand.Uint64())
+	_, err = admin.Exec("CREATE SCHEMA " + schema)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_, err := admin.Exec("DROP SCHEMA " + schema + " CASCADE")
+		assert.NoError(t, err)
+		require.NoError(t, admin.Close())
+	})
+
+	u, err := url.Parse(dsn)
+	require.NoError(t, err)
+	params := u.Query()
+	params.Set("search_path", schema+",public")
+	u.RawQuery = params.Encode()
+	dbPath := u.String()
+
+	db, err := Connect(dbPath)
+	require.NoError(t, err)
+	db.SetMaxOpenConns(1)
+
+	err = Migrate("../migrations", dbPath)
+	require.NoError(t, err)
+
+	t.Cleanup(func() {
+		require.NoError(t, db.Close())
+	})
+
+	return NewFuelPricesRepository(db, &models.Retailers{})
+}
+
 func TestFetchPricesIntegration(t *testing.T) {
 	repo := setupTestDB(t)
 
@@ -54,7 +103,7 @@ func TestFetchPricesIntegration(t *testing.T) {
 		FuelTypes: []string{"E10"},
 	}
 
-	_, _, err := repo.InsertPFS([]models.PetrolFillingStation{pfs1, pfs2})
+	_, _, err := repo.InsertPFS(t.Context(), []models.PetrolFillingStation{pfs1, pfs2})
 	require.NoError(t, err)
 
 	prices := []models.ForecourtPrices{
@@ -77,52 +126,52 @@ func TestFetchPricesIntegration(t *testing.T) {
 		},
 	}
 
-	_, _, err = repo.InsertPrices(prices)
+	_, _, err = repo.InsertPrices(t.Context(), prices)
 	require.NoError(t, err)
 
 	t.Run("Bounding box filtering", func(t *testing.T) {
 		// Box containing only node-1
-		results, err := repo.Search([]float64{-0.2, 51.4, 0.0, 51.6}, 1)
+		results, err := repo.Search([]float64{-0.2, 51.4, 0.0, 51.6}, 1, false)
 		require.NoError(t, err)
 		assert.Len(t, results, 1)
 		assert.Equal(t, "node-1", results[0].NodeId)
 
 		// Box containing only node-2
-		results, err = repo.Search([]float64{-0.1, 51.9, 0.1, 52.1}, 1)
+		results, err = repo.Search([]float64{-0.1, 51.9, 0.1, 52.1}, 1, false)
 		require.NoError(t, err)
 		assert.Len(t, results, 1)
 		assert.Equal(t, "node-2", results[0].NodeId)
 
 		// Box containing both
-		results, err = repo.Search([]float64{-0.2, 51.0, 0.2, 53.0}, 1)
+		results, err = repo.Search([]float64{-0.2, 51.0, 0.2, 53.0}, 1, false)
 		require.NoError(t, err)
 		assert.Len(t, results, 2)
 
 		// Box containing neither
-		results, err = repo.Search([]float64{1.0, 1.0, 2.0, 2.0}, 1)
+		results, err = repo.Search([]float64{1.0, 1.0, 2.0, 2.0}, 1, false)
 		require.NoError(t, err)
 		assert.Empty(t, results)
 	})
 
 	t.Run("Latest price per fuel type (perTypeLimit=1)", func(t *testing.T) {
-		results, err := repo.Search([]float64{-0.2, 51.4, 0.0, 51.6}, 1)
+		results, err := repo.Search([]float64{-0.2, 51.4, 0.0, 51.6}, 1, false)
 		require.NoError(t, err)
 		require.Len(t, results, 1)
 
 		p := results[0].FuelPrices
 		require.Contains(t, p, "E10")
-		require.Contains(t, p, "B7")
+		require.Contains(t, p, "B7_STANDARD")
 
 		assert.Len(t, p["E10"], 1)
 		assert.Equal(t, 141.9, p["E10"][0].Price)
 		assert.True(t, p["E10"][0].UpdatedOn.Equal(now))
 
-		assert.Len(t, p["B7"], 1)
-		assert.Equal(t, 150.9, p["B7"][0].Price)
+		assert.Len(t, p["B7_STANDARD"], 1)
+		assert.Equal(t, 150.9, p["B7_STANDARD"][0].Price)
 	})
 
 	t.Run("Historical prices and deduplication (perTypeLimit=5)", func(t *testing.T) {
-		results, err := repo.Search([]float64{-0.2, 51.4, 0.0, 51.6}, 5)
+		results, err := repo.Search([]float64{-0.2, 51.4, 0.0, 51.6}, 5, false)
 		require.NoError(t, err)
 		require.Len(t, results, 1)
 
@@ -144,7 +193,7 @@ func TestFetchPricesIntegration(t *testing.T) {
 	})
 
 	t.Run("Limit per type", func(t *testing.T) {
-		results, err := repo.Search([]float64{-0.2, 51.4, 0.0, 51.6}, 2)
+		results, err := repo.Search([]float64{-0.2, 51.4, 0.0, 51.6}, 2, false)
 		require.NoError(t, err)
 		require.Len(t, results, 1)
 
@@ -155,3 +204,1112 @@ func TestFetchPricesIntegration(t *testing.T) {
 		assert.Equal(t, 142.9, p["E10"][1].Price)
 	})
 }
+
+func TestSearchRadius(t *testing.T) {
+	repo := setupTestDB(t)
+	now := time.Now().UTC().Truncate(time.Second)
+
+	_, _, err := repo.InsertPFS(t.Context(), []models.PetrolFillingStation{
+		{NodeId: "near", Location: models.Location{Latitude: 51.509, Longitude: -0.1}, FuelTypes: []string{"E10"}},         // ~1.0 KM north
+		{NodeId: "corner", Location: models.Location{Latitude: 51.515, Longitude: -0.076}, FuelTypes: []string{"E10"}},     // ~2.4 KM north-east
+		{NodeId: "far", Location: models.Location{Latitude: 51.5, Longitude: -0.05}, FuelTypes: []string{"E10"}},           // ~3.5 KM east
+		{NodeId: "closed", Location: models.Location{Latitude: 51.5, Longitude: -0.1}, FuelTypes: []string{"B7_STANDARD"}}, // at the centre
+	})
+	require.NoError(t, err)
+	_, err = repo.(*sqlRepository).db.Exec(`UPDATE petrol_filling_stations SET inactive = $1 WHERE node_id = $2`, true, "closed")
+	require.NoError(t, err)
+
+	_, _, err = repo.InsertPrices(t.Context(), []models.ForecourtPrices{
+		{NodeId: "near", FuelPrices: []models.FuelPrice{
+			{FuelType: "E10", Price: 139.9, PriceLastUpdated: now.Add(-time.Hour)},
+			{FuelType: "E10", Price: 138.9, PriceLastUpdated: now},
+		}},
+		{NodeId: "corner", FuelPrices: []models.FuelPrice{{FuelType: "E10", Price: 141.9, PriceLastUpdated: now}}},
+		{NodeId: "far", FuelPrices: []models.FuelPrice{{FuelType: "E10", Price: 136.9, PriceLastUpdated: now}}},
+	})
+	require.NoError(t, err)
+
+	nodeIds := func(results []models.SearchResult) []string {
+		ids := make([]string, 0, len(results))
+		for _, result := range results {
+			ids = append(ids, result.NodeId)
+		}
+		return ids
+	}
+
+	// The corner station is inside the circle's bounding box, but not the circle.
+	results, err := repo.SearchRadius(51.5, -0.1, 2000, 1, false)
+	require.NoError(t, err)
+	require.Equal(t, []string{"near"}, nodeIds(results))
+	require.Len(t, results[0].FuelPrices["E10"], 1)
+	assert.Equal(t, 138.9, results[0].FuelPrices["E10"][0].Price)
+
+	results, err = repo.SearchRadius(51.5, -0.1, 2000, 2, true)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"near", "closed"}, nodeIds(results))
+	for _, result := range results {
+		if result.NodeId == "near" {
+			assert.Len(t, result.FuelPrices["E10"], 2)
+		}
+	}
+
+	results, err = repo.SearchRadius(51.5, -0.1, 5000, 1, false)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"near", "corner", "far"}, nodeIds(results))
+
+	results, err = repo.SearchRadius(52.5, -0.1, 5000, 1, true)
+	require.NoError(t, err)
+	assert.Empty(t, results)
+}
+
+func TestFetchWatermarks(t *testing.T) {
+	repo := setupTestDB(t)
+
+	watermarks, err := repo.FetchWatermarks()
+	require.NoError(t, err)
+	assert.Empty(t, watermarks)
+
+	first := time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+	second := first.Add(time.Hour)
+
+	require.NoError(t, repo.SaveFetchWatermark("pfs", first))
+	require.NoError(t, repo.SaveFetchWatermark("pfs/fuel-prices", first))
+	require.NoError(t, repo.SaveFetchWatermark("pfs/fuel-prices", second))
+	// An overlapping fetch which started earlier does not move it back.
+	require.NoError(t, repo.SaveFetchWatermark("pfs/fuel-prices", first.Add(30*time.Minute)))
+
+	watermarks, err = repo.FetchWatermarks()
+	require.NoError(t, err)
+	assert.Len(t, watermarks, 2)
+	assert.True(t, watermarks["pfs"].Equal(first))
+	assert.True(t, watermarks["pfs/fuel-prices"].Equal(second))
+
+	require.NoError(t, repo.ResetFetchWatermarks())
+	watermarks, err = repo.FetchWatermarks()
+	require.NoError(t, err)
+	assert.Empty(t, watermarks)
+}
+
+func TestDiffAgainstDatabase(t *testing.T) {
+	repo := setupTestDB(t)
+	now := time.Now().UTC().Truncate(time.Second)
+	closed := now.Add(-24 * time.Hour)
+
+	stations := []models.PetrolFillingStation{
+		{NodeId: "node-1", TradingName: "Station 1", PermanentClosureDate: &closed, Location: models.Location{Latitude: 51.5, Longitude: -0.1}},
+		{NodeId: "node-2", TradingName: "Station 2", IsMotorwayServiceStation: true, Location: models.Location{Latitude: 52.0, Longitude: 0.0}},
+	}
+	_, _, err := repo.InsertPFS(t.Context(), stations)
+	require.NoError(t, err)
+
+	_, _, err = repo.InsertPrices(t.Context(), []models.ForecourtPrices{
+		{NodeId: "node-1", FuelPrices: []models.FuelPrice{
+			{FuelType: "E10", Price: 140.9, PriceLastUpdated: now.Add(-time.Hour)},
+			{FuelType: "B7_STANDARD", Price: 150.9, PriceLastUpdated: now.Add(-time.Hour)},
+		}},
+	})
+	require.NoError(t, err)
+
+	stations[1].TradingName = "Station Two"
+	stations[1].Location.Latitude = 52.1
+	diff, err := repo.DiffPFS(t.Context(), append(stations, models.PetrolFillingStation{NodeId: "node-3"}))
+	require.NoError(t, err)
+	assert.Equal(t, 1, diff.NewStations)
+	assert.Equal(t, 1, diff.ChangedStations)
+	assert.Equal(t, 1, diff.UnchangedStations)
+	assert.Equal(t, map[string]int{"trading_name": 1, "latitude": 1}, diff.ChangedAttributes)
+
+	diff, err = repo.DiffPrices(t.Context(), []models.ForecourtPrices{
+		{NodeId: "node-1", FuelPrices: []models.FuelPrice{
+			{FuelType: "E10", Price: 140.9, PriceLastUpdated: now.Add(-time.Hour)},
+			{FuelType: "B7_STANDARD", Price: 1.519, PriceLastUpdated: now.Add(-time.Hour)}, // corrected from pounds
+			{FuelType: "E10", Price: 141.9, PriceLastUpdated: now},
+			{FuelType: "E5", Price: 14.9, PriceLastUpdated: now}, // dropped
+		}},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 1, diff.NewPrices)
+	assert.Equal(t, 1, diff.ChangedPrices)
+	assert.Equal(t, 1, diff.UnchangedPrices)
+	assert.Equal(t, 1, diff.CorrectedPrices)
+	assert.Equal(t, 1, diff.DroppedPrices)
+}
+
+func TestQuarantine(t *testing.T) {
+	repo := setupTestDB(t)
+	now := time.Now().UTC().Truncate(time.Second)
+
+	_, _, err := repo.InsertPFS(t.Context(), []models.PetrolFillingStation{
+		{NodeId: "node-1", Location: models.Location{Latitude: 51.5, Longitude: -0.1}},
+	})
+	require.NoError(t, err)
+
+	prices := []models.ForecourtPrices{
+		{NodeId: "node-1", FuelPrices: []models.FuelPrice{
+			{FuelType: "E10", Price: 140.9, PriceLastUpdated: now},
+			{FuelType: "B7_STANDARD", Price: 1.519, PriceLastUpdated: now}, // rescaled from pounds
+			{FuelType: "E5", Price: 14.9, PriceLastUpdated: now},           // out of bounds
+		}},
+	}
+	ctx := WithBatchInfo(t.Context(), BatchInfo{Path: "/api/v1/pfs/fuel-prices", Number: 3, FetchedAt: now})
+	count, dropped, err := repo.InsertPrices(ctx, prices)
+	require.NoError(t, err)
+	assert.Equal(t, 2, count)
+	assert.Equal(t, 1, dropped)
+
+	pending, err := repo.QuarantinedPrices(models.QuarantinePending, 10, 0)
+	require.NoError(t, err)
+	require.Len(t, pending, 2)
+
+	byReason := make(map[string]models.QuarantinedPrice)
+	for _, q := range pending {
+		byReason[q.Reason] = q
+	}
+	rescaled := byReason[models.QuarantineRescaled]
+	assert.Equal(t, "B7_STANDARD", rescaled.FuelType)
+	assert.Equal(t, 1.519, rescaled.OriginalPrice)
+	assert.InDelta(t, 151.9, rescaled.CorrectedPrice, 0.001)
+	assert.Equal(t, models.SourceFuelFinder, rescaled.Source)
+	require.NotNil(t, rescaled.BatchPath)
+	assert.Equal(t, "/api/v1/pfs/fuel-prices", *rescaled.BatchPath)
+	require.NotNil(t, rescaled.BatchNumber)
+	assert.Equal(t, 3, *rescaled.BatchNumber)
+
+	outOfBounds := byReason[models.QuarantineOutOfBounds]
+	assert.Equal(t, "E5", outOfBounds.FuelType)
+	assert.Equal(t, 14.9, outOfBounds.OriginalPrice)
+
+	// Re-importing the same batch does not quarantine the prices twice.
+	_, _, err = repo.InsertPrices(t.Context(), prices)
+	require.NoError(t, err)
+	pending, err = repo.QuarantinedPrices(models.QuarantinePending, 10, 0)
+	require.NoError(t, err)
+	assert.Len(t, pending, 2)
+
+	approved, err := repo.ReviewQuarantinedPrice(t.Context(), outOfBounds.Id, true)
+	require.NoError(t, err)
+	assert.Equal(t, models.QuarantineApproved, approved.Status)
+	assert.NotNil(t, approved.ReviewedAt)
+
+	history, err := repo.PriceHistory("node-1", "E5")
+	require.NoError(t, err)
+	require.Len(t, history, 1)
+	assert.Equal(t, 14.9, history[0].Price)
+
+	_, err = repo.ReviewQuarantinedPrice(t.Context(), rescaled.Id, false)
+	require.NoError(t, err)
+
+	history, err = repo.PriceHistory("node-1", "B7_STANDARD")
+	require.NoError(t, err)
+	assert.Empty(t, history)
+
+	// A rejected rescaled price stays out when it is sent again.
+	_, _, err = repo.InsertPrices(t.Context(), prices)
+	require.NoError(t, err)
+	history, err = repo.PriceHistory("node-1", "B7_STANDARD")
+	require.NoError(t, err)
+	assert.Empty(t, history)
+
+	_, err = repo.ReviewQuarantinedPrice(t.Context(), rescaled.Id, true)
+	assert.ErrorIs(t, err, ErrAlreadyReviewed)
+
+	_, err = repo.ReviewQuarantinedPrice(t.Context(), 999, true)
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	all, err := repo.QuarantinedPrices("", 10, 0)
+	require.NoError(t, err)
+	assert.Len(t, all, 2)
+}
+
+func TestQuarantineReviewAfterFold(t *testing.T) {
+	repo := setupTestDB(t)
+	db := repo.(*sqlRepository).db
+	now := time.Now().UTC().Truncate(time.Second)
+
+	_, _, err := repo.InsertPFS(t.Context(), []models.PetrolFillingStation{{NodeId: "node-1"}})
+	require.NoError(t, err)
+
+	insert := func(fuelType string, price float64, hoursAgo int) {
+		_, _, err := repo.InsertPrices(t.Context(), []models.ForecourtPrices{
+			{NodeId: "node-1", FuelPrices: []models.FuelPrice{{FuelType: fuelType, Price: price, PriceLastUpdated: now.Add(time.Duration(-hoursAgo) * time.Hour)}}},
+		})
+		require.NoError(t, err)
+	}
+	review := func(fuelType string, hoursAgo int, approve bool) {
+		pending, err := repo.QuarantinedPrices(models.QuarantinePending, 100, 0)
+		require.NoError(t, err)
+		for _, q := range pending {
+			if q.FuelType == fuelType && q.PriceLastUpdated.Equal(now.Add(time.Duration(-hoursAgo)*time.Hour)) {
+				_, err := repo.ReviewQuarantinedPrice(t.Context(), q.Id, approve)
+				require.NoError(t, err)
+				return
+			}
+		}
+		t.Fatalf("no pending %s price from %d hours ago", fuelType, hoursAgo)
+	}
+	runs := func(fuelType string) [][3]any {
+		rows, err := db.Query(`SELECT price, price_last_updated, last_confirmed_at FROM fuel_prices WHERE node_id = $1 AND fuel_type = $2 ORDER BY price_last_updated`, "node-1", fuelType)
+		require.NoError(t, err)
+		defer func() { require.NoError(t, rows.Close()) }()
+		var results [][3]any
+		for rows.Next() {
+			var price float64
+			var firstSeen, lastConfirmed time.Time
+			require.NoError(t, rows.Scan(&price, &firstSeen, &lastConfirmed))
+			results = append(results, [3]any{price, now.Sub(firstSeen).Hours(), now.Sub(lastConfirmed).Hours()})
+		}
+		require.NoError(t, rows.Err())
+		return results
+	}
+
+	// A rejected rescaled price which only confirmed the run no longer does.
+	insert("B7_STANDARD", 151.9, 2)
+	insert("B7_STANDARD", 1.519, 1)
+	assert.Equal(t, [][3]any{{151.9, 2.0, 1.0}}, runs("B7_STANDARD"))
+	review("B7_STANDARD", 1, false)
+	assert.Equal(t, [][3]any{{151.9, 2.0, 2.0}}, runs("B7_STANDARD"))
+
+	// A rejected rescaled price which started a run leaves the run to begin
+	// with its later, genuine confirmation.
+	insert("E10", 140.9, 3)
+	insert("E10", 1.429, 2)
+	insert("E10", 142.9, 1)
+	assert.Equal(t, [][3]any{{140.9, 3.0, 3.0}, {142.9, 2.0, 1.0}}, runs("E10"))
+	review("E10", 2, false)
+	assert.Equal(t, [][3]any{{140.9, 3.0, 3.0}, {142.9, 1.0, 1.0}}, runs("E10"))
+
+	// Without it, the runs either side are one and the same.
+	insert("E5_PREMIUM", 160.9, 3)
+	insert("E5_PREMIUM", 1.629, 2)
+	insert("E5_PREMIUM", 160.9, 1)
+	assert.Len(t, runs("E5_PREMIUM"), 3)
+	review("E5_PREMIUM", 2, false)
+	assert.Equal(t, [][3]any{{160.9, 3.0, 1.0}}, runs("E5_PREMIUM"))
+
+	// An approved price which repeats the price in force confirms it.
+	insert("B7_PREMIUM", 1.619, 2)
+	insert("B7_PREMIUM", 1.619, 1)
+	review("B7_PREMIUM", 1, true)
+	assert.Equal(t, [][3]any{{161.9, 2.0, 1.0}}, runs("B7_PREMIUM"))
+
+	// Approving out-of-turn folds the run which follows into the price.
+	insert("E5", 14.9, 2)
+	insert("E5", 14.9, 1)
+	assert.Empty(t, runs("E5"))
+	review("E5", 1, true)
+	review("E5", 2, true)
+	assert.Equal(t, [][3]any{{14.9, 2.0, 1.0}}, runs("E5"))
+}
+
+func TestInsertPricesOutOfOrder(t *testing.T) {
+	for name, threshold := range map[string]int{"individual": math.MaxInt, "bulk": 0} {
+		t.Run(name, func(t *testing.T) {
+			repo := setupTestDB(t)
+			repo.(*sqlRepository).bulkThreshold = threshold
+			db := repo.(*sqlRepository).db
+			now := time.Now().UTC().Truncate(time.Second)
+
+			_, _, err := repo.InsertPFS(t.Context(), []models.PetrolFillingStation{{NodeId: "node-1"}})
+			require.NoError(t, err)
+
+			insert := func(price float64, hoursAgo int) {
+				_, _, err := repo.InsertPrices(t.Context(), []models.ForecourtPrices{
+					{NodeId: "node-1", FuelPrices: []models.FuelPrice{{FuelType: "E10", Price: price, PriceLastUpdated: now.Add(time.Duration(-hoursAgo) * time.Hour)}}},
+				})
+				require.NoError(t, err)
+			}
+			runs := func() [][3]any {
+				rows, err := db.Query(`SELECT price, price_last_updated, last_confirmed_at FROM fuel_prices WHERE node_id = $1 AND fuel_type = $2 ORDER BY price_last_updated`, "node-1", "E10")
+				require.NoError(t, err)
+				defer func() { require.NoError(t, rows.Close()) }()
+				var results [][3]any
+				for rows.Next() {
+					var price float64
+					var firstSeen, lastConfirmed time.Time
+					require.NoError(t, rows.Scan(&price, &firstSeen, &lastConfirmed))
+					results = append(results, [3]any{price, now.Sub(firstSeen).Hours(), now.Sub(lastConfirmed).Hours()})
+				}
+				require.NoError(t, rows.Err())
+				return results
+			}
+
+			insert(140.9, 6)
+			insert(142.9, 4)
+			assert.Equal(t, [][3]any{{140.9, 6.0, 6.0}, {142.9, 4.0, 4.0}}, runs())
+
+			// A late price matching the run which follows it takes that run over.
+			insert(142.9, 5)
+			assert.Equal(t, [][3]any{{140.9, 6.0, 6.0}, {142.9, 5.0, 4.0}}, runs())
+
+			// As does one which lands between two runs of the same price.
+			insert(140.9, 2)
+			insert(141.9, 1)
+			insert(140.9, 3)
+			assert.Equal(t, [][3]any{{140.9, 6.0, 6.0}, {142.9, 5.0, 4.0}, {140.9, 3.0, 2.0}, {141.9, 1.0, 1.0}}, runs())
+		})
+	}
+}
+
+func TestPriceBounds(t *testing.T) {
+	repo := setupTestDB(t)
+	now := time.Now().UTC().Truncate(time.Second)
+
+	stations := make([]models.PetrolFillingStation, 0, minPercentileSamples)
+	prices := make([]models.ForecourtPrices, 0, minPercentileSamples)
+	for i := range minPercentileSamples {
+		nodeId := fmt.Sprintf("node-%d", i)
+		stations = append(stations, models.PetrolFillingStation{NodeId: nodeId, Location: models.Location{Postcode: "SW1A 1AA"}})
+		prices = append(prices, models.ForecourtPrices{NodeId: nodeId, FuelPrices: []models.FuelPrice{
+			{FuelType: "LPG", Price: 80 + float64(i), PriceLastUpdated: now},
+			{FuelType: "E10", Price: 140, PriceLastUpdated: now},
+		}})
+	}
+	_, _, err := repo.InsertPFS(t.Context(), stations)
+	require.NoError(t, err)
+
+	// LPG prices are kept by the built-in bounds.
+	count, dropped, err := repo.InsertPrices(t.Context(), prices)
+	require.NoError(t, err)
+	assert.Equal(t, 2*minPercentileSamples, count)
+	assert.Equal(t, 0, dropped)
+
+	// Euro cent prices from outside the UK don't widen the derived bounds.
+	_, _, err = repo.InsertPFS(t.Context(), []models.PetrolFillingStation{
+		{NodeId: "prix-carburants:1", Location: models.Location{Postcode: "75001"}, Source: "prix-carburants", CountryCode: "FR"},
+	})
+	require.NoError(t, err)
+	_, _, err = repo.InsertPrices(t.Context(), []models.ForecourtPrices{
+		{NodeId: "prix-carburants:1", Source: "prix-carburants", FuelPrices: []models.FuelPrice{
+			{FuelType: "LPG", Price: 60, PriceLastUpdated: now},
+		}},
+	})
+	require.NoError(t, err)
+
+	err = repo.ConfigurePriceBounds(BoundsConfig{
+		Overrides:   map[string]models.PriceBounds{"E10": {Min: 150, Max: 200, Source: models.BoundsConfigured}},
+		Percentiles: []float64{0, 100},
+	})
+	require.NoError(t, err)
+
+	bounds := repo.PriceBounds()
+	assert.Equal(t, models.PriceBounds{Min: 150, Max: 200, Source: models.BoundsConfigured}, bounds.For("E10"))
+	lpg := bounds.For("LPG")
+	assert.Equal(t, models.BoundsDerived, lpg.Source)
+	assert.InDelta(t, 80*(1-percentileMargin), lpg.Min, 0.001)
+	assert.InDelta(t, 109*(1+percentileMargin), lpg.Max, 0.001)
+	assert.Equal(t, models.BoundsDefault, bounds.For("B7_STANDARD").Source)
+	assert.NotNil(t, bounds.LastUpdated)
+
+	count, dropped, err = repo.InsertPrices(t.Context(), []models.ForecourtPrices{
+		{NodeId: "node-1", FuelPrices: []models.FuelPrice{
+			{FuelType: "E10", Price: 140, PriceLastUpdated: now.Add(time.Minute)},
+			{FuelType: "LPG", Price: 95, PriceLastUpdated: now.Add(time.Minute)},
+		}},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 1, count)
+	assert.Equal(t, 1, dropped)
+}
+
+func TestLoadBoundsConfig(t *testing.T) {
+	t.Setenv("FUEL_PRICES_BOUNDS", "lpg=50:200, *=90:320")
+	t.Setenv("FUEL_PRICES_BOUNDS_PERCENTILES", "1:99")
+
+	cfg, err := LoadBoundsConfig()
+	require.NoError(t, err)
+	assert.Equal(t, map[string]models.PriceBounds{
+		"LPG": {Min: 50, Max: 200, Source: models.BoundsConfigured},
+		"*":   {Min: 90, Max: 320, Source: models.BoundsConfigured},
+	}, cfg.Overrides)
+	assert.Equal(t, []float64{1, 99}, cfg.Percentiles)
+
+	t.Setenv("FUEL_PRICES_BOUNDS", "LPG=200:50")
+	_, err = LoadBoundsConfig()
+	assert.Error(t, err)
+
+	t.Setenv("FUEL_PRICES_BOUNDS", "")
+	t.Setenv("FUEL_PRICES_BOUNDS_PERCENTILES", "99")
+	_, err = LoadBoundsConfig()
+	assert.Error(t, err)
+}
+
+func TestAnomalyDetection(t *testing.T) {
+	setup := setupTestDB(t)
+	now := time.Now().UTC().Truncate(time.Second)
+
+	stations := make([]models.PetrolFillingStation, 0, 6)
+	prices := make([]models.ForecourtPrices, 0, 6)
+	for i := range 6 {
+		nodeId := fmt.Sprintf("node-%d", i)
+		stations = append(stations, models.PetrolFillingStation{NodeId: nodeId, Location: models.Location{Postcode: "SW1A 1AA"}})
+		prices = append(prices, models.ForecourtPrices{NodeId: nodeId, FuelPrices: []models.FuelPrice{
+			{FuelType: "E10", Price: 140 + float64(i), PriceLastUpdated: now.Add(-2 * time.Hour)},
+		}})
+	}
+	_, _, err := setup.InsertPFS(t.Context(), stations)
+	require.NoError(t, err)
+	_, _, err = setup.InsertPrices(t.Context(), prices)
+	require.NoError(t, err)
+
+	// A fresh repository, so that the postcode area statistics are not the
+	// ones cached before any prices were inserted.
+	repo := NewFuelPricesRepository(setup.(*sqlRepository).db, &models.Retailers{})
+
+	count, dropped, err := repo.InsertPrices(t.Context(), []models.ForecourtPrices{
+		{NodeId: "node-0", FuelPrices: []models.FuelPrice{{FuelType: "E10", Price: 170, PriceLastUpdated: now.Add(-time.Hour)}}},
+		{NodeId: "node-1", FuelPrices: []models.FuelPrice{{FuelType: "E10", Price: 160, PriceLastUpdated: now.Add(-time.Hour)}}},
+		{NodeId: "node-2", FuelPrices: []models.FuelPrice{{FuelType: "E10", Price: 143, PriceLastUpdated: now.Add(-time.Hour)}}},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 3, count)
+	assert.Equal(t, 0, dropped)
+
+	anomalies, err := repo.Anomalies(now.Add(-24*time.Hour), 10, 0)
+	require.NoError(t, err)
+	require.Len(t, anomalies, 2)
+
+	byNode := make(map[string]models.PriceAnomaly)
+	for _, anomaly := range anomalies {
+		byNode[anomaly.NodeId] = anomaly
+	}
+	assert.Equal(t, models.AnomalyJump, byNode["node-0"].Anomaly)
+	require.NotNil(t, byNode["node-0"].Reference)
+	assert.Equal(t, 140.0, *byNode["node-0"].Reference)
+	assert.Equal(t, models.AnomalyAreaOutlier, byNode["node-1"].Anomaly)
+
+	snapshot, err := repo.SnapshotStats()
+	require.NoError(t, err)
+	for _, s := range snapshot.Snapshot {
+		if s.Scope == "National" && s.FuelType == "E10" {
+			assert.Equal(t, 145.0, s.HighestPrice)
+		}
+	}
+
+	results, err := repo.Search([]float64{-90, -180, 90, 180}, 1, false)
+	require.NoError(t, err)
+	for _, result := range results {
+		if result.NodeId == "node-0" {
+			assert.Equal(t, models.AnomalyJump, result.FuelPrices["E10"][0].Anomaly)
+		}
+	}
+
+	// Repeating the new price confirms it as a genuine change, so it is no
+	// longer flagged.
+	repo.ConfigureAnomalyDetection(AnomalyConfig{JumpThreshold: 0.15})
+	_, _, err = repo.InsertPrices(t.Context(), []models.ForecourtPrices{
+		{NodeId: "node-0", FuelPrices: []models.FuelPrice{{FuelType: "E10", Price: 170, PriceLastUpdated: now}}},
+	})
+	require.NoError(t, err)
+	anomalies, err = repo.Anomalies(now.Add(-24*time.Hour), 10, 0)
+	require.NoError(t, err)
+	require.Len(t, anomalies, 1)
+	assert.Equal(t, "node-1", anomalies[0].NodeId)
+}
+
+func TestCleanse(t *testing.T) {
+	repo := setupTestDB(t)
+	db := repo.(*sqlRepository).db
+	now := time.Now().UTC().Truncate(time.Second)
+
+	_, _, err := repo.InsertPFS(t.Context(), []models.PetrolFillingStation{{NodeId: "node-1"}})
+	require.NoError(t, err)
+
+	// Written directly, as InsertPrices would not let most of them through.
+	stored := map[string]float64{
+		"E10":         999.9,
+		"E5":          1.459,
+		"B7_STANDARD": 14.59,
+		"B7_PREMIUM":  45.9,
+		"E5_PREMIUM":  300.5,
+		"LPG":         85.9,
+		"E85":         145.9,
+		"HVO":         0.999,
+	}
+	for fuelType, price := range stored {
+		_, err := db.Exec(repo.(*sqlRepository).q.insertPrices, "node-1", fuelType, now, price, nil, models.SourceFuelFinder, nil, nil, now)
+		require.NoError(t, err)
+	}
+
+	countRule := func(report *models.CleanseReport) map[string][2]int {
+		result := make(map[string][2]int)
+		for _, rule := range report.Rules {
+			result[rule.Rule] = [2]int{rule.Updated, rule.Deleted}
+		}
+		return result
+	}
+	expected := map[string][2]int{
+		"placeholder":     {0, 2},
+		"rescale":         {1, 0},
+		"shifted-decimal": {1, 0},
+		"missing-hundred": {1, 0},
+		"out-of-bounds":   {0, 1},
+	}
+
+	report, err := repo.Cleanse(t.Context(), true)
+	require.NoError(t, err)
+	assert.True(t, report.DryRun)
+	assert.Equal(t, 6, report.Examined)
+	assert.Equal(t, expected, countRule(report))
+
+	var audited int
+	require.NoError(t, db.QueryRow("SELECT COUNT(*) FROM cleanse_audit").Scan(&audited))
+	assert.Equal(t, 0, audited)
+
+	history, err := repo.PriceHistory("node-1", "E10")
+	require.NoError(t, err)
+	require.Len(t, history, 1)
+	assert.Equal(t, 999.9, history[0].Price)
+
+	report, err = repo.Cleanse(t.Context(), false)
+	require.NoError(t, err)
+	assert.Equal(t, expected, countRule(report))
+
+	for fuelType, want := range map[string]float64{"E5": 145.9, "B7_STANDARD": 145.9, "B7_PREMIUM": 145.9, "LPG": 85.9, "E85": 145.9} {
+		history, err := repo.PriceHistory("node-1", fuelType)
+		require.NoError(t, err)
+		require.Len(t, history, 1, fuelType)
+		assert.InDelta(t, want, history[0].Price, 0.001, fuelType)
+	}
+	for _, fuelType := range []string{"E10", "E5_PREMIUM", "HVO"} {
+		history, err := repo.PriceHistory("node-1", fuelType)
+		require.NoError(t, err)
+		assert.Empty(t, history, fuelType)
+	}
+
+	var before float64
+	var after sql.NullFloat64
+	require.NoError(t, db.QueryRow("SELECT COUNT(*) FROM cleanse_audit WHERE run_id = $1", report.RunId).Scan(&audited))
+	assert.Equal(t, 6, audited)
+	require.NoError(t, db.QueryRow("SELECT price_before, price_after FROM cleanse_audit WHERE fuel_type = 'B7_PREMIUM'").Scan(&before, &after))
+	assert.Equal(t, 45.9, before)
+	assert.InDelta(t, 145.9, after.Float64, 0.001)
+
+	// Running again finds nothing left to do.
+	report, err = repo.Cleanse(t.Context(), false)
+	require.NoError(t, err)
+	assert.Equal(t, 0, report.Examined)
+}
+
+func TestStationChanges(t *testing.T) {
+	repo := setupTestDB(t)
+
+	station := models.PetrolFillingStation{
+		NodeId:      "node-1",
+		TradingName: "Station 1",
+		BrandName:   "ESSO",
+		Location:    models.Location{Latitude: 51.5, Longitude: -0.1},
+	}
+	_, _, err := repo.InsertPFS(t.Context(), []models.PetrolFillingStation{station})
+	require.NoError(t, err)
+
+	// An unchanged station logs nothing.
+	_, _, err = repo.InsertPFS(t.Context(), []models.PetrolFillingStation{station})
+	require.NoError(t, err)
+	changes, err := repo.StationChanges("node-1", "", 10)
+	require.NoError(t, err)
+	assert.Empty(t, changes)
+
+	station.BrandName = "BP"
+	station.TemporaryClosure = true
+	_, _, err = repo.InsertPFS(t.Context(), []models.PetrolFillingStation{station})
+	require.NoError(t, err)
+
+	changes, err = repo.StationChanges("node-1", "", 10)
+	require.NoError(t, err)
+	require.Len(t, changes, 2)
+
+	byAttribute := make(map[string]models.StationChange)
+	for _, change := range changes {
+		byAttribute[change.Attribute] = change
+	}
+	brand := byAttribute["brand_name"]
+	require.NotNil(t, brand.OldValue)
+	require.NotNil(t, brand.NewValue)
+	assert.Equal(t, "ESSO", *brand.OldValue)
+	assert.Equal(t, "BP", *brand.NewValue)
+	assert.Equal(t, models.SourceFuelFinder, brand.Source)
+	assert.False(t, brand.ChangedAt.IsZero())
+
+	closure := byAttribute["temporary_closure"]
+	assert.Equal(t, "0", *closure.OldValue)
+	assert.Equal(t, "1", *closure.NewValue)
+
+	changes, err = repo.StationChanges("node-1", "brand_name", 10)
+	require.NoError(t, err)
+	assert.Len(t, changes, 1)
+}
+
+func TestMarkMissingStations(t *testing.T) {
+	repo := setupTestDB(t)
+
+	stations := []models.PetrolFillingStation{
+		{NodeId: "node-1", TradingName: "Station 1", Location: models.Location{Latitude: 51.5, Longitude: -0.1}},
+		{NodeId: "node-2", TradingName: "Station 2", Location: models.Location{Latitude: 51.5, Longitude: -0.1}},
+	}
+	_, _, err := repo.InsertPFS(t.Context(), stations)
+	require.NoError(t, err)
+
+	// node-2 drops out of the next two refreshes, and is marked inactive
+	// on missing the second.
+	for i := range 2 {
+		started := time.Now().Add(time.Duration(i+1) * time.Hour)
+		deactivated, err := repo.MarkMissingStations(t.Context(), models.SourceFuelFinder, started, 2)
+		require.NoError(t, err)
+		assert.Equal(t, i, deactivated)
+		_, _, err = repo.InsertPFS(t.Context(), stations[:1])
+		require.NoError(t, err)
+	}
+
+	results, err := repo.Search([]float64{-0.2, 51.4, 0.0, 51.6}, 1, false)
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.Equal(t, "node-1", results[0].NodeId)
+
+	results, err = repo.Search([]float64{-0.2, 51.4, 0.0, 51.6}, 1, true)
+	require.NoError(t, err)
+	require.Len(t, results, 2)
+
+	activity, err := repo.StationActivity(time.Now().Add(-time.Hour), 10, 0)
+	require.NoError(t, err)
+	require.Len(t, activity, 1)
+	assert.Equal(t, models.StationVanished, activity[0].Event)
+
+	_, _, err = repo.InsertPFS(t.Context(), stations[1:])
+	require.NoError(t, err)
+
+	results, err = repo.Search([]float64{-0.2, 51.4, 0.0, 51.6}, 1, false)
+	require.NoError(t, err)
+	assert.Len(t, results, 2)
+
+	activity, err = repo.StationActivity(time.Now().Add(-time.Hour), 10, 0)
+	require.NoError(t, err)
+	require.Len(t, activity, 2)
+	assert.Equal(t, models.StationReappeared, activity[0].Event)
+	assert.Equal(t, "node-2", activity[0].NodeId)
+}
+
+func TestCoordinateValidation(t *testing.T) {
+	repo := setupTestDB(t)
+	repo.ConfigureCoordinateValidation(CoordinateConfig{ThresholdKm: 5, Repair: true})
+
+	_, err := repo.InsertPostcodeCentroids(t.Context(), []models.PostcodeCentroid{
+		{Postcode: "SW1A 1AA", Latitude: 51.501, Longitude: -0.1416},
+		{Postcode: "EH1 1YZ", Latitude: 55.9533, Longitude: -3.1883},
+	})
+	require.NoError(t, err)
+
+	stations := []models.PetrolFillingStation{
+		{NodeId: "ok", Location: models.Location{Postcode: "sw1a1aa", Latitude: 51.502, Longitude: -0.14}},
+		{NodeId: "swapped", Location: models.Location{Postcode: "SW1A 1AA", Latitude: -0.1416, Longitude: 51.501}},
+		{NodeId: "missing", Location: models.Location{Postcode: "EH1 1YZ"}},
+		{NodeId: "mismatch", Location: models.Location{Postcode: "EH1 1YZ", Latitude: 51.5, Longitude: -0.1}},
+		{NodeId: "unknown", Location: models.Location{Postcode: "ZZ9 9ZZ", Latitude: 51.5, Longitude: -0.1}},
+		// Without a postcode centroid, UK stations are checked against the
+		// bounds of the UK, along with their latitude and longitude swapped.
+		{NodeId: "out-of-bounds", Location: models.Location{Postcode: "ZZ9 9ZZ", Latitude: 40.4, Longitude: -3.7}},
+		{NodeId: "swapped-unknown", Location: models.Location{Postcode: "ZZ9 9ZZ", Latitude: -1.5, Longitude: 53.8}},
+		{NodeId: "france", Location: models.Location{Postcode: "75001", Latitude: 48.85, Longitude: 2.35}, CountryCode: "FR"},
+	}
+	_, _, err = repo.InsertPFS(t.Context(), stations)
+	require.NoError(t, err)
+
+	issues, err := repo.CoordinateIssues("", false, 10, 0)
+	require.NoError(t, err)
+	require.Len(t, issues, 5)
+
+	byNode := make(map[string]models.CoordinateIssue)
+	for _, issue := range issues {
+		byNode[issue.NodeId] = issue
+	}
+
+	swapped := byNode["swapped"]
+	assert.Equal(t, models.CoordinatesSwapped, swapped.Issue)
+	assert.True(t, swapped.Repaired)
+	assert.InDelta(t, 51.501, swapped.Latitude, 1e-9)
+	assert.InDelta(t, -0.1416, swapped.Longitude, 1e-9)
+	require.NotNil(t, swapped.OriginalLatitude)
+	assert.InDelta(t, -0.1416, *swapped.OriginalLatitude, 1e-9)
+
+	missing := byNode["missing"]
+	assert.Equal(t, models.CoordinatesMissing, missing.Issue)
+	assert.Nil(t, missing.DistanceKm)
+	assert.InDelta(t, 55.9533, missing.Latitude, 1e-9)
+
+	mismatch := byNode["mismatch"]
+	assert.Equal(t, models.CoordinatesMismatch, mismatch.Issue)
+	require.NotNil(t, mismatch.DistanceKm)
+	assert.Greater(t, *mismatch.DistanceKm, 500.0)
+
+	outOfBounds := byNode["out-of-bounds"]
+	assert.Equal(t, models.CoordinatesOutOfBounds, outOfBounds.Issue)
+	assert.Nil(t, outOfBounds.DistanceKm)
+	assert.False(t, outOfBounds.Repaired)
+	assert.InDelta(t, 40.4, outOfBounds.Latitude, 1e-9)
+
+	swappedUnknown := byNode["swapped-unknown"]
+	assert.Equal(t, models.CoordinatesSwapped, swappedUnknown.Issue)
+	assert.True(t, swappedUnknown.Repaired)
+	assert.InDelta(t, 53.8, swappedUnknown.Latitude, 1e-9)
+	assert.InDelta(t, -1.5, swappedUnknown.Longitude, 1e-9)
+
+	issues, err = repo.CoordinateIssues(models.CoordinatesSwapped, true, 10, 0)
+	require.NoError(t, err)
+	assert.Len(t, issues, 2)
+
+	// Without repair, stations are flagged but keep their coordinates.
+	repo.ConfigureCoordinateValidation(CoordinateConfig{ThresholdKm: 5})
+	_, _, err = repo.InsertPFS(t.Context(), stations)
+	require.NoError(t, err)
+
+	issues, err = repo.CoordinateIssues("", true, 10, 0)
+	require.NoError(t, err)
+	assert.Empty(t, issues)
+
+	issues, err = repo.CoordinateIssues(models.CoordinatesSwapped, false, 10, 0)
+	require.NoError(t, err)
+	require.Len(t, issues, 2)
+	for _, issue := range issues {
+		assert.Less(t, issue.Latitude, 0.0)
+	}
+}
+
+func TestFuelTypeNormalisation(t *testing.T) {
+	repo := setupTestDB(t)
+
+	now := time.Now().UTC().Truncate(time.Second)
+	_, _, err := repo.InsertPFS(t.Context(), []models.PetrolFillingStation{
+		{NodeId: "node-1", Location: models.Location{Latitude: 51.5, Longitude: -0.1}, FuelTypes: []string{"e10", "Diesel"}},
+		{NodeId: "node-2", Location: models.Location{Latitude: 51.5, Longitude: -0.1}, FuelTypes: []string{"B7"}},
+	})
+	require.NoError(t, err)
+
+	_, _, err = repo.InsertPrices(t.Context(), []models.ForecourtPrices{
+		{NodeId: "node-1", FuelPrices: []models.FuelPrice{
+			{FuelType: "e10", Price: 135.9, PriceLastUpdated: now},
+			{FuelType: "Diesel", Price: 145.9, PriceLastUpdated: now},
+		}},
+		{NodeId: "node-2", FuelPrices: []models.FuelPrice{
+			{FuelType: "b7", Price: 146.9, PriceLastUpdated: now},
+			{FuelType: "hydrogen", Price: 150.0, PriceLastUpdated: now},
+		}},
+	})
+	require.NoError(t, err)
+
+	results, err := repo.Search([]float64{-0.2, 51.4, 0.0, 51.6}, 1, false)
+	require.NoError(t, err)
+	require.Len(t, results, 2)
+	for _, result := range results {
+		assert.Contains(t, result.FuelPrices, "B7_STANDARD")
+	}
+
+	counts, err := repo.FuelTypeCounts()
+	require.NoError(t, err)
+	assert.Equal(t, map[string]int{"E10": 1, "B7_STANDARD": 2, "HYDROGEN": 1}, counts)
+
+	catalogue := models.FuelTypeCatalogue(counts)
+	assert.Equal(t, "E10", catalogue[0].Code)
+	last := catalogue[len(catalogue)-1]
+	assert.Equal(t, "HYDROGEN", last.Code)
+	assert.Equal(t, 1, last.Stations)
+}
+
+func TestCanonicalFuelTypesMigration(t *testing.T) {
+	repo := setupTestDB(t)
+	db := repo.(*sqlRepository).db
+	now := time.Now().UTC().Truncate(time.Second)
+
+	migration := "../migrations/000018_canonical_fuel_types.up.sql"
+	if os.Getenv(testPostgresEnv) != "" {
+		migration = "../migrations/postgres/000002_canonical_fuel_types.up.sql"
+	}
+	script, err := os.ReadFile(migration)
+	require.NoError(t, err)
+
+	_, _, err = repo.InsertPFS(t.Context(), []models.PetrolFillingStation{{NodeId: "node-1"}})
+	require.NoError(t, err)
+	_, err = db.Exec(`UPDATE petrol_filling_stations SET fuel_types_json = $1`, `["e10","Diesel","HYDROGEN"]`)
+	require.NoError(t, err)
+
+	// Written directly, as prices stored before fuel types were canonicalised,
+	// with a price for every alias in the catalogue.
+	insert := func(nodeId, fuelType string, ts time.Time, price float64) {
+		_, err := db.Exec(repo.(*sqlRepository).q.insertPrices, nodeId, fuelType, ts, price, nil, models.SourceFuelFinder, nil, nil, ts)
+		require.NoError(t, err)
+	}
+	codes := map[string]string{}
+	for _, fuelType := range models.FuelTypeCatalogue(nil) {
+		for _, alias := range fuelType.Aliases {
+			codes[alias] = fuelType.Code
+			insert("alias-"+alias, alias, now, 150.0)
+		}
+	}
+	insert("node-1", "Diesel", now, 145.9)
+	insert("node-1", "B7_STANDARD", now, 146.9)
+	insert("node-1", "b7", now.Add(time.Hour), 147.9)
+	insert("node-1", "hydrogen", now, 160.0)
+
+	_, err = db.Exec(string(script))
+	require.NoError(t, err)
+
+	for alias, code := range codes {
+		var stored string
+		err := db.QueryRow(`SELECT fuel_type FROM fuel_prices WHERE node_id = $1`, "alias-"+alias).Scan(&stored)
+		require.NoError(t, err)
+		assert.Equal(t, code, stored, alias)
+	}
+
+	// The price already stored under the canonical code is the one kept.
+	history, err := repo.PriceHistory("node-1", "B7_STANDARD")
+	require.NoError(t, err)
+	require.Len(t, history, 2)
+	assert.Equal(t, []float64{146.9, 147.9}, []float64{history[0].Price, history[1].Price})
+
+	history, err = repo.PriceHistory("node-1", "HYDROGEN")
+	require.NoError(t, err)
+	assert.Len(t, history, 1)
+
+	var fuelTypesJSON string
+	err = db.QueryRow(`SELECT fuel_types_json FROM petrol_filling_stations WHERE node_id = $1`, "node-1").Scan(&fuelTypesJSON)
+	require.NoError(t, err)
+	assert.Equal(t, `["E10","B7_STANDARD","HYDROGEN"]`, fuelTypesJSON)
+
+	// Running it again finds nothing left to rename.
+	_, err = db.Exec(string(script))
+	require.NoError(t, err)
+	history, err = repo.PriceHistory("node-1", "B7_STANDARD")
+	require.NoError(t, err)
+	assert.Len(t, history, 2)
+}
+
+func TestCompact(t *testing.T) {
+	repo := setupTestDB(t)
+	db := repo.(*sqlRepository).db
+	now := time.Now().UTC().Truncate(time.Second)
+
+	_, _, err := repo.InsertPFS(t.Context(), []models.PetrolFillingStation{{NodeId: "node-1"}})
+	require.NoError(t, err)
+
+	// Written directly, as history stored before runs were folded on import.
+	for i, price := range []float64{140.9, 140.9, 142.9, 142.9, 142.9, 140.9} {
+		ts := now.Add(time.Duration(i-5) * time.Hour)
+		_, err := db.Exec(repo.(*sqlRepository).q.insertPrices, "node-1", "E10", ts, price, nil, models.SourceFuelFinder, nil, nil, ts)
+		require.NoError(t, err)
+	}
+
+	report, err := repo.Compact(t.Context(), true)
+	require.NoError(t, err)
+	assert.Equal(t, &models.CompactReport{DryRun: true, Examined: 6, Kept: 3, Removed: 3}, report)
+
+	history, err := repo.PriceHistory("node-1", "E10")
+	require.NoError(t, err)
+	assert.Len(t, history, 6)
+
+	report, err = repo.Compact(t.Context(), false)
+	require.NoError(t, err)
+	assert.Equal(t, &models.CompactReport{Examined: 6, Kept: 3, Removed: 3}, report)
+
+	history, err = repo.PriceHistory("node-1", "E10")
+	require.NoError(t, err)
+	require.Len(t, history, 3)
+	assert.Equal(t, []float64{140.9, 142.9, 140.9}, []float64{history[0].Price, history[1].Price, history[2].Price})
+
+	var lastConfirmed time.Time
+	err = db.QueryRow(`SELECT last_confirmed_at FROM fuel_prices WHERE node_id = $1 AND price_last_updated = $2`, "node-1", now.Add(-3*time.Hour)).Scan(&lastConfirmed)
+	require.NoError(t, err)
+	assert.True(t, lastConfirmed.Equal(now.Add(-time.Hour)))
+
+	// Compacting again finds nothing more to remove.
+	report, err = repo.Compact(t.Context(), false)
+	require.NoError(t, err)
+	assert.Equal(t, 0, report.Removed)
+
+	// A repeated price on import only confirms the current run.
+	_, _, err = repo.InsertPrices(t.Context(), []models.ForecourtPrices{
+		{NodeId: "node-1", FuelPrices: []models.FuelPrice{{FuelType: "E10", Price: 140.9, PriceLastUpdated: now.Add(time.Hour)}}},
+	})
+	require.NoError(t, err)
+	history, err = repo.PriceHistory("node-1", "E10")
+	require.NoError(t, err)
+	assert.Len(t, history, 3)
+
+	err = db.QueryRow(`SELECT last_confirmed_at FROM fuel_prices WHERE node_id = $1 AND price_last_updated = $2`, "node-1", now).Scan(&lastConfirmed)
+	require.NoError(t, err)
+	assert.True(t, lastConfirmed.Equal(now.Add(time.Hour)))
+}
+
+func TestBulkStatementRows(t *testing.T) {
+	s, err := prepareBulkStatement(nil, sqliteQueries.bulkConfirmPrices)
+	require.NoError(t, err)
+	assert.Equal(t, 2, s.width)
+	assert.Equal(t, "(?, ?)", s.repeat(2))
+
+	// PostgreSQL's numbered parameters carry on from the rows before.
+	s, err = prepareBulkStatement(nil, postgresQueries.bulkConfirmPrices)
+	require.NoError(t, err)
+	assert.Equal(t, 2, s.width)
+	assert.Equal(t, "($1::bigint, $2::timestamptz)", s.repeat(0))
+	assert.Equal(t, "($5::bigint, $6::timestamptz)", s.repeat(2))
+}
+
+func TestBulkInsert(t *testing.T) {
+	now := time.Now().UTC().Truncate(time.Second)
+
+	// A chunk and a half of stations, one of which is renamed later in the
+	// batch, and prices which are confirmed, then change, with one jump.
+	stations := benchmarkStations(bulkInsertRows * 3 / 2)
+	renamed := stations[0]
+	renamed.TradingName = "Renamed"
+	stations = append(stations, renamed)
+
+	changed := benchmarkPrices(stations[:len(stations)-1], now.Add(2*time.Hour), 141.9)
+	changed[1].FuelPrices[0].Price = 199.9
+	batches := [][]models.ForecourtPrices{
+		benchmarkPrices(stations[:len(stations)-1], now, 140.9),
+		benchmarkPrices(stations[:len(stations)-1], now.Add(time.Hour), 140.9),
+		changed,
+	}
+	batches[0] = append(batches[0], models.ForecourtPrices{NodeId: "node-0", FuelPrices: []models.FuelPrice{
+		{FuelType: "E10", Price: 140.9, PriceLastUpdated: now.Add(time.Minute)},
+	}})
+
+	// Each batch is inserted a row at a time and in bulk, which must agree.
+	repos := make([]FuelPricesRepository, 2)
+	for i, threshold := range []int{math.MaxInt, 0} {
+		repos[i] = setupTestDB(t)
+		repos[i].(*sqlRepository).bulkThreshold = threshold
+		repos[i].ConfigureAnomalyDetection(AnomalyConfig{JumpThreshold: 0.15})
+
+		inserted, _, err := repos[i].InsertPFS(t.Context(), stations)
+		require.NoError(t, err)
+		assert.Equal(t, len(stations), inserted)
+
+		for _, batch := range batches {
+			_, _, err := repos[i].InsertPrices(t.Context(), batch)
+			require.NoError(t, err)
+		}
+	}
+
+	changes, err := repos[1].StationChanges("node-0", "trading_name", 10)
+	require.NoError(t, err)
+	assert.Len(t, changes, 1)
+
+	for _, nodeId := range []string{"node-0", "node-1", "node-300"} {
+		expected, err := repos[0].PriceHistory(nodeId, "E10")
+		require.NoError(t, err)
+		require.Len(t, expected, 2)
+
+		actual, err := repos[1].PriceHistory(nodeId, "E10")
+		require.NoError(t, err)
+		assert.Equal(t, expected, actual)
+	}
+
+	expectedAnomalies, err := repos[0].Anomalies(now, 10, 0)
+	require.NoError(t, err)
+	require.Len(t, expectedAnomalies, 1)
+	actualAnomalies, err := repos[1].Anomalies(now, 10, 0)
+	require.NoError(t, err)
+	assert.Equal(t, expectedAnomalies, actualAnomalies)
+
+	bbox := []float64{-1.0, 50.0, 1.0, 53.0}
+	expected, err := repos[0].Search(bbox, 1, false)
+	require.NoError(t, err)
+	actual, err := repos[1].Search(bbox, 1, false)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, expected, actual)
+}
+
+func benchmarkStations(n int) []models.PetrolFillingStation {
+	stations := make([]models.PetrolFillingStation, n)
+	for i := range stations {
+		stations[i] = models.PetrolFillingStation{
+			NodeId:      fmt.Sprintf("node-%d", i),
+			TradingName: fmt.Sprintf("Station %d", i),
+			Location: models.Location{
+				Postcode:  "AB1 2CD",
+				Latitude:  51.0 + float64(i%100)/100,
+				Longitude: -0.5 + float64(i/100)/100,
+			},
+			FuelTypes: []string{"E10", "E5", "B7_STANDARD", "B7_PREMIUM"},
+		}
+	}
+	return stations
+}
+
+func benchmarkPrices(stations []models.PetrolFillingStation, ts time.Time, price float64) []models.ForecourtPrices {
+	prices := make([]models.ForecourtPrices, len(stations))
+	for i, pfs := range stations {
+		fuelPrices := make([]models.FuelPrice, len(pfs.FuelTypes))
+		for j, fuelType := range pfs.FuelTypes {
+			fuelPrices[j] = models.FuelPrice{FuelType: fuelType, Price: price + float64(j)*5, PriceLastUpdated: ts}
+		}
+		prices[i] = models.ForecourtPrices{NodeId: pfs.NodeId, FuelPrices: fuelPrices}
+	}
+	return prices
+}
+
+// benchmarkInsertPaths compares writing each row on its own with the bulk
+// insert path.
+func benchmarkInsertPaths(b *testing.B, run func(b *testing.B, repo FuelPricesRepository)) {
+	for _, bc := range []struct {
+		name      string
+		threshold int
+	}{
+		{"PerRow", math.MaxInt},
+		{"Bulk", 0},
+	} {
+		b.Run(bc.name, func(b *testing.B) {
+			repo := setupTestDB(b)
+			repo.(*sqlRepository).bulkThreshold = bc.threshold
+			run(b, repo)
+		})
+	}
+}
+
+func BenchmarkInsertPFS(b *testing.B) {
+	stations := benchmarkStations(5000)
+	benchmarkInsertPaths(b, func(b *testing.B, repo FuelPricesRepository) {
+		for b.Loop() {
+			_, _, err := repo.InsertPFS(b.Context(), stations)
+			require.NoError(b, err)
+		}
+		b.ReportMetric(float64(b.N*len(stations))/b.Elapsed().Seconds(), "rows/s")
+	})
+}
+
+func BenchmarkInsertPrices(b *testing.B) {
+	stations := benchmarkStations(5000)
+	benchmarkInsertPaths(b, func(b *testing.B, repo FuelPricesRepository) {
+		_, _, err := repo.InsertPFS(b.Context(), stations)
+		require.NoError(b, err)
+
+		// Each iteration is a change of price, so that every row is written.
+		start := time.Now().UTC().Truncate(time.Second)
+		i := 0
+		for b.Loop() {
+			prices := benchmarkPrices(stations, start.Add(time.Duration(i)*time.Minute), 140.9+float64(i%2))
+			_, _, err := repo.InsertPrices(b.Context(), prices)
+			require.NoError(b, err)
+			i++
+		}
+		b.ReportMetric(float64(b.N*len(stations)*4)/b.Elapsed().Seconds(), "rows/s")
+	})
+}
+
+func BenchmarkConfirmPrices(b *testing.B) {
+	stations := benchmarkStations(5000)
+	benchmarkInsertPaths(b, func(b *testing.B, repo FuelPricesRepository) {
+		_, _, err := repo.InsertPFS(b.Context(), stations)
+		require.NoError(b, err)
+
+		// Each iteration repeats the same prices, as most of a full refresh does.
+		start := time.Now().UTC().Truncate(time.Second)
+		i := 0
+		for b.Loop() {
+			prices := benchmarkPrices(stations, start.Add(time.Duration(i)*time.Minute), 140.9)
+			_, _, err := repo.InsertPrices(b.Context(), prices)
+			require.NoError(b, err)
+			i++
+		}
+		b.ReportMetric(float64(b.N*len(stations)*4)/b.Elapsed().Seconds(), "rows/s")
+	})
+}
@@ -0,0 +1,123 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"sync/atomic"
+	"time"
+)
+
+// retryPolicy controls how failed upstream requests are retried. Delays grow
+// exponentially from BaseDelay up to MaxDelay, with full jitter applied. A
+// Retry-After header on a 429 or 503 response takes precedence over the
+// computed delay, unless it asks us to wait longer than MaxRetryAfter.
+type retryPolicy struct {
+	MaxAttempts   int
+	BaseDelay     time.Duration
+	MaxDelay      time.Duration
+	MaxRetryAfter time.Duration
+	RunBudget     int
+}
+
+func defaultRetryPolicy() retryPolicy {
+	return retryPolicy{
+		MaxAttempts:   5,
+		BaseDelay:     500 * time.Millisecond,
+		MaxDelay:      30 * time.Second,
+		MaxRetryAfter: 2 * time.Minute,
+		RunBudget:     20,
+	}
+}
+
+// retryBudget caps the total number of retries across all the requests made
+// during a single fetch run, so a persistently failing upstream cannot keep a
+// run alive indefinitely. A nil budget is unlimited, whereas the zero value
+// permits no retries at all.
+type retryBudget struct {
+	remaining atomic.Int32
+}
+
+func (policy retryPolicy) newBudget() *retryBudget {
+	budget := &retryBudget{}
+	budget.remaining.Store(int32(policy.RunBudget))
+	return budget
+}
+
+func (budget *retryBudget) take() bool {
+	if budget == nil {
+		return true
+	}
+	return budget.remaining.Add(-1) >= 0
+}
+
+// backoff returns the delay before the given retry attempt (starting at 1).
+func (policy retryPolicy) backoff(attempt int) time.Duration {
+	delay := policy.MaxDelay
+	if shift := attempt - 1; shift < 32 {
+		if d := policy.BaseDelay << shift; d > 0 && d < policy.MaxDelay {
+			delay = d
+		}
+	}
+	if delay <= 0 {
+		return 0
+	}
+	return rand.N(delay) + 1
+}
+
+// retryReason classifies an error from a single request attempt, returning
+// the label to record against the retry metric and whether the request is
+// worth retrying at all.
+func retryReason(err error) (string, bool) {
+	var stErr *HTTPStatusError
+	if errors.As(err, &stErr) {
+		switch stErr.StatusCode {
+		case http.StatusRequestTimeout,
+			http.StatusTooManyRequests,
+			http.StatusInternalServerError,
+			http.StatusBadGateway,
+			http.StatusServiceUnavailable,
+			http.StatusGatewayTimeout:
+			return strconv.Itoa(stErr.StatusCode), true
+		}
+		return strconv.Itoa(stErr.StatusCode), false
+	}
+	return "transport", true
+}
+
+// parseRetryAfter interprets a Retry-After header, which may either be a
+// number of seconds or an HTTP date. Zero is returned if the header is
+// missing or cannot be parsed.
+func parseRetryAfter(value string, now time.Time) time.Duration {
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0
+		}
+		return time.Duration(seconds) * time.Second
+	}
+	if at, err := http.ParseTime(value); err == nil {
+		if delay := at.Sub(now); delay > 0 {
+			return delay
+		}
+	}
+	return 0
+}
+
+// sleep waits for the given delay, returning early with the context's error
+// if it is cancelled first.
+func sleep(ctx context.Context, delay time.Duration) error {
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,101 @@
+package routes
+
+import (
+	"crypto/subtle"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rm-hull/fuel-prices-api/internal"
+	"github.com/rm-hull/fuel-prices-api/internal/models"
+)
+
+// AdminAuth requires admin requests to carry the given bearer token. With an
+// empty token the admin endpoints are disabled, and every request refused.
+func AdminAuth(token string) gin.HandlerFunc {
+	expected := []byte("Bearer " + token)
+	return func(c *gin.Context) {
+		if token == "" {
+			c.AbortWithStatusJSON(http.StatusServiceUnavailable, gin.H{"error": "Admin endpoints are disabled"})
+			return
+		}
+		if subtle.ConstantTimeCompare([]byte(c.GetHeader("Authorization")), expected) != 1 {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+			return
+		}
+		c.Next()
+	}
+}
+
+func SchemaDrift(client internal.FuelPricesClient) func(c *gin.Context) {
+	return func(c *gin.Context) {
+		c.JSON(http.StatusOK, client.SchemaDrift())
+	}
+}
+
+func QuarantinedPrices(repo internal.FuelPricesRepository) func(c *gin.Context) {
+	return func(c *gin.Context) {
+		status := c.DefaultQuery("status", models.QuarantinePending)
+		switch status {
+		case "all":
+			status = ""
+		case models.QuarantinePending, models.QuarantineApproved, models.QuarantineRejected:
+		default:
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid status parameter"})
+			return
+		}
+
+		limit, err := strconv.Atoi(c.DefaultQuery("limit", "100"))
+		if err != nil || limit < 1 || limit > 1000 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid limit parameter"})
+			return
+		}
+
+		offset, err := strconv.Atoi(c.DefaultQuery("offset", "0"))
+		if err != nil || offset < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid offset parameter"})
+			return
+		}
+
+		results, err := repo.QuarantinedPrices(status, limit, offset)
+		if err != nil {
+			log.Printf("error while fetching quarantined prices: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "An internal server error occurred"})
+			return
+		}
+
+		c.JSON(http.StatusOK, models.QuarantineResponse{
+			Results: results,
+			Limit:   limit,
+			Offset:  offset,
+		})
+	}
+}
+
+func ReviewQuarantinedPrice(repo internal.FuelPricesRepository, approve bool) func(c *gin.Context) {
+	return func(c *gin.Context) {
+		id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id parameter"})
+			return
+		}
+
+		result, err := repo.ReviewQuarantinedPrice(c.Request.Context(), id, approve)
+		switch {
+		case errors.Is(err, internal.ErrNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		case errors.Is(err, internal.ErrAlreadyReviewed):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		case err != nil:
+			log.Printf("error while reviewing quarantined price: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "An internal server error occurred"})
+			return
+		}
+
+		c.JSON(http.StatusOK, result)
+	}
+}
@@ -0,0 +1,47 @@
+package routes
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAdminAuth(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	for _, tc := range []struct {
+		name          string
+		token         string
+		authorization string
+		want          int
+	}{
+		{name: "unconfigured", token: "", authorization: "", want: http.StatusServiceUnavailable},
+		{name: "unconfigured with empty bearer", token: "", authorization: "Bearer ", want: http.StatusServiceUnavailable},
+		{name: "missing token", token: "secret", authorization: "", want: http.StatusUnauthorized},
+		{name: "wrong token", token: "secret", authorization: "Bearer wrong", want: http.StatusUnauthorized},
+		{name: "right token", token: "secret", authorization: "Bearer secret", want: http.StatusOK},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			approved := false
+			r := gin.New()
+			admin := r.Group("/v1/fuel-prices/admin", AdminAuth(tc.token))
+			admin.POST("/quarantine/:id/approve", func(c *gin.Context) {
+				approved = true
+				c.Status(http.StatusOK)
+			})
+
+			req := httptest.NewRequest(http.MethodPost, "/v1/fuel-prices/admin/quarantine/1/approve", nil)
+			if tc.authorization != "" {
+				req.Header.Set("Authorization", tc.authorization)
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, tc.want, w.Code)
+			assert.Equal(t, tc.want == http.StatusOK, approved)
+		})
+	}
+}
@@ -0,0 +1,50 @@
+package routes
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rm-hull/fuel-prices-api/internal"
+	"github.com/rm-hull/fuel-prices-api/internal/models"
+)
+
+func Anomalies(repo internal.FuelPricesRepository) func(c *gin.Context) {
+	return func(c *gin.Context) {
+		since := time.Now().UTC().Add(-7 * 24 * time.Hour)
+		if value := c.Query("since"); value != "" {
+			t, err := time.Parse(time.RFC3339, value)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "invalid since parameter, expected RFC3339"})
+				return
+			}
+			since = t
+		}
+
+		limit, err := strconv.Atoi(c.DefaultQuery("limit", "100"))
+		if err != nil || limit < 1 || limit > 1000 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid limit parameter"})
+			return
+		}
+
+		offset, err := strconv.Atoi(c.DefaultQuery("offset", "0"))
+		if err != nil || offset < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid offset parameter"})
+			return
+		}
+
+		results, err := repo.Anomalies(since, limit, offset)
+		if err != nil {
+			log.Printf("error while fetching price anomalies: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "An internal server error occurred"})
+			return
+		}
+
+		c.JSON(http.StatusOK, models.AnomaliesResponse{
+			Results:     results,
+			Attribution: internal.ATTRIBUTION,
+		})
+	}
+}
@@ -0,0 +1,57 @@
+package routes
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rm-hull/fuel-prices-api/internal"
+	"github.com/rm-hull/fuel-prices-api/internal/models"
+)
+
+func CoordinateIssues(repo internal.FuelPricesRepository) func(c *gin.Context) {
+	return func(c *gin.Context) {
+		issue := c.Query("issue")
+		switch issue {
+		case "", models.CoordinatesMissing, models.CoordinatesSwapped, models.CoordinatesMismatch, models.CoordinatesOutOfBounds:
+		default:
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid issue parameter"})
+			return
+		}
+
+		repairedOnly := false
+		if value := c.Query("repaired"); value != "" {
+			var err error
+			repairedOnly, err = strconv.ParseBool(value)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "invalid repaired parameter"})
+				return
+			}
+		}
+
+		limit, err := strconv.Atoi(c.DefaultQuery("limit", "100"))
+		if err != nil || limit < 1 || limit > 1000 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid limit parameter"})
+			return
+		}
+
+		offset, err := strconv.Atoi(c.DefaultQuery("offset", "0"))
+		if err != nil || offset < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid offset parameter"})
+			return
+		}
+
+		results, err := repo.CoordinateIssues(issue, repairedOnly, limit, offset)
+		if err != nil {
+			log.Printf("error while fetching coordinate issues: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "An internal server error occurred"})
+			return
+		}
+
+		c.JSON(http.StatusOK, models.CoordinateIssuesResponse{
+			Results:     results,
+			Attribution: internal.ATTRIBUTION,
+		})
+	}
+}
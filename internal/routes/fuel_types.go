@@ -0,0 +1,26 @@
+package routes
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rm-hull/fuel-prices-api/internal"
+	"github.com/rm-hull/fuel-prices-api/internal/models"
+)
+
+func FuelTypes(repo internal.FuelPricesRepository) func(c *gin.Context) {
+	return func(c *gin.Context) {
+		counts, err := repo.FuelTypeCounts()
+		if err != nil {
+			log.Printf("error while fetching fuel type counts: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "An internal server error occurred"})
+			return
+		}
+
+		c.JSON(http.StatusOK, models.FuelTypesResponse{
+			Results:     models.FuelTypeCatalogue(counts),
+			Attribution: internal.ATTRIBUTION,
+		})
+	}
+}
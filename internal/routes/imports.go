@@ -0,0 +1,102 @@
+package routes
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rm-hull/fuel-prices-api/internal"
+	"github.com/rm-hull/fuel-prices-api/internal/models"
+)
+
+func ImportRuns(repo internal.FuelPricesRepository) func(c *gin.Context) {
+	return func(c *gin.Context) {
+		filter := models.ImportRunFilter{
+			Job:         c.Query("job"),
+			Source:      c.Query("source"),
+			Status:      c.Query("status"),
+			TriggeredBy: c.Query("triggered_by"),
+		}
+
+		switch filter.Job {
+		case "", models.ImportJobStations, models.ImportJobPrices:
+		default:
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid job parameter"})
+			return
+		}
+
+		switch filter.Status {
+		case "", models.ImportRunning, models.ImportSucceeded, models.ImportFailed:
+		default:
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid status parameter"})
+			return
+		}
+
+		switch filter.TriggeredBy {
+		case "", models.ImportTriggerCron, models.ImportTriggerCommand:
+		default:
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid triggered_by parameter"})
+			return
+		}
+
+		if value := c.Query("since"); value != "" {
+			t, err := time.Parse(time.RFC3339, value)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "invalid since parameter, expected RFC3339"})
+				return
+			}
+			filter.Since = t
+		}
+
+		limit, err := strconv.Atoi(c.DefaultQuery("limit", "100"))
+		if err != nil || limit < 1 || limit > 1000 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid limit parameter"})
+			return
+		}
+
+		offset, err := strconv.Atoi(c.DefaultQuery("offset", "0"))
+		if err != nil || offset < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid offset parameter"})
+			return
+		}
+
+		results, err := repo.ImportRuns(filter, limit, offset)
+		if err != nil {
+			log.Printf("error while fetching import runs: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "An internal server error occurred"})
+			return
+		}
+
+		c.JSON(http.StatusOK, models.ImportRunsResponse{
+			Results: results,
+			Limit:   limit,
+			Offset:  offset,
+		})
+	}
+}
+
+func ImportRun(repo internal.FuelPricesRepository) func(c *gin.Context) {
+	return func(c *gin.Context) {
+		id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id parameter"})
+			return
+		}
+
+		result, err := repo.ImportRun(id)
+		switch {
+		case errors.Is(err, internal.ErrNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		case err != nil:
+			log.Printf("error while fetching import run: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "An internal server error occurred"})
+			return
+		}
+
+		c.JSON(http.StatusOK, result)
+	}
+}
@@ -14,7 +14,7 @@ func PriceHistory(repo internal.FuelPricesRepository, client internal.FuelPrices
 	return func(c *gin.Context) {
 
 		nodeId := c.Param("node_id")
-		fuelType := c.Param("fuel_type")
+		fuelType := models.CanonicalFuelType(c.Param("fuel_type"))
 
 		fuelTypes, err := repo.FuelTypes()
 		if err != nil {
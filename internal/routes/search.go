@@ -25,32 +25,74 @@ func Search(repo internal.FuelPricesRepository, client internal.FuelPricesClient
 			return
 		}
 
-		limitStr := c.Query("limit")
-		limit := 1 // default to 1 (most recent only) if not provided
-		if limitStr != "" {
-			l, lerr := strconv.Atoi(limitStr)
-			if lerr != nil || l < 0 {
-				c.JSON(http.StatusBadRequest, gin.H{"error": "invalid limit parameter"})
-				return
-			}
-			limit = l
+		search(c, client, func(limit int, includeInactive bool) ([]models.SearchResult, error) {
+			return repo.Search(bbox, limit, includeInactive)
+		})
+	}
+}
+
+// Nearby searches for the stations within a radius, in metres, of a point.
+func Nearby(repo internal.FuelPricesRepository, client internal.FuelPricesClient) func(c *gin.Context) {
+	return func(c *gin.Context) {
+		lat, lon, radius, err := parseRadius(c.Query("lat"), c.Query("lon"), c.Query("radius"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
 		}
 
-		results, err := repo.Search(bbox, limit)
+		search(c, client, func(limit int, includeInactive bool) ([]models.SearchResult, error) {
+			return repo.SearchRadius(lat, lon, radius, limit, includeInactive)
+		})
+	}
+}
+
+// search runs a search with the options common to every kind, and responds
+// with its results.
+func search(c *gin.Context, client internal.FuelPricesClient, find func(limit int, includeInactive bool) ([]models.SearchResult, error)) {
+	var err error
+	limitStr := c.Query("limit")
+	limit := 1 // default to 1 (most recent only) if not provided
+	if limitStr != "" {
+		l, lerr := strconv.Atoi(limitStr)
+		if lerr != nil || l < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid limit parameter"})
+			return
+		}
+		limit = l
+	}
 
+	includeAnomalies := false
+	if value := c.Query("include_anomalies"); value != "" {
+		includeAnomalies, err = strconv.ParseBool(value)
 		if err != nil {
-			log.Printf("error while fetching fuel prices: %v", err)
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "An internal server error occurred"})
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid include_anomalies parameter"})
 			return
 		}
+	}
 
-		c.JSON(http.StatusOK, models.SearchResponse{
-			Results:     results,
-			Attribution: internal.ATTRIBUTION,
-			Statistics:  stats.Derive(results, 3),
-			LastUpdated: client.LastUpdated(),
-		})
+	includeInactive := false
+	if value := c.Query("include_inactive"); value != "" {
+		includeInactive, err = strconv.ParseBool(value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid include_inactive parameter"})
+			return
+		}
+	}
+
+	results, err := find(limit, includeInactive)
+
+	if err != nil {
+		log.Printf("error while fetching fuel prices: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "An internal server error occurred"})
+		return
 	}
+
+	c.JSON(http.StatusOK, models.SearchResponse{
+		Results:     results,
+		Attribution: internal.ATTRIBUTION,
+		Statistics:  stats.Derive(results, 3, includeAnomalies),
+		LastUpdated: client.LastUpdated(),
+	})
 }
 
 func parseBBox(bboxStr string) ([]float64, error) {
@@ -78,3 +120,19 @@ func parseBBox(bboxStr string) ([]float64, error) {
 
 	return bbox, nil
 }
+
+func parseRadius(latStr, lonStr, radiusStr string) (float64, float64, float64, error) {
+	lat, err := strconv.ParseFloat(strings.TrimSpace(latStr), 64)
+	if err != nil || lat < -90 || lat > 90 {
+		return 0, 0, 0, fmt.Errorf("invalid lat value '%s': must be between -90 and 90", latStr)
+	}
+	lon, err := strconv.ParseFloat(strings.TrimSpace(lonStr), 64)
+	if err != nil || lon < -180 || lon > 180 {
+		return 0, 0, 0, fmt.Errorf("invalid lon value '%s': must be between -180 and 180", lonStr)
+	}
+	radius, err := strconv.ParseFloat(strings.TrimSpace(radiusStr), 64)
+	if err != nil || radius <= 0 || radius > MAX_BOUNDS/2 {
+		return 0, 0, 0, fmt.Errorf("radius must be a positive number of meters, no more than %d KM", MAX_BOUNDS/2000)
+	}
+	return lat, lon, radius, nil
+}
@@ -42,3 +42,9 @@ func DistributionStats(repo internal.FuelPricesRepository) func(c *gin.Context)
 		})
 	}
 }
+
+func PriceBounds(repo internal.FuelPricesRepository) func(c *gin.Context) {
+	return func(c *gin.Context) {
+		c.JSON(http.StatusOK, repo.PriceBounds())
+	}
+}
@@ -0,0 +1,250 @@
+package schema
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+)
+
+type Kind string
+
+const (
+	UnknownField Kind = "unknown_field"
+	MissingField Kind = "missing_field"
+	TypeMismatch Kind = "type_mismatch"
+)
+
+// Issue is a single kind of discrepancy between an upstream response and the
+// model it is decoded into. Field is a dotted path, where [] stands for every
+// element of an array and * for every value of a map.
+type Issue struct {
+	Kind     Kind
+	Field    string
+	Expected string
+	Actual   string
+}
+
+// Spec describes what a single record in a batch should look like.
+type Spec struct {
+	Type     reflect.Type
+	Required []string
+}
+
+// SpecFor builds a spec for records decoded into T, where each of the required
+// fields must be present, non-null and, for strings, non-empty.
+func SpecFor[T any](required ...string) Spec {
+	return Spec{Type: reflect.TypeFor[T](), Required: required}
+}
+
+var timeType = reflect.TypeFor[time.Time]()
+
+// Inspect checks every record in a raw batch response against the spec,
+// returning each issue found along with the number of records it affects.
+// The batch may either be a bare JSON array or an envelope with a data field,
+// in the same way the client accepts.
+func Inspect(raw []byte, spec Spec) (map[Issue]int, error) {
+	raw = bytes.TrimSpace(raw)
+	if len(raw) > 0 && raw[0] == '{' {
+		var envelope struct {
+			Data json.RawMessage `json:"data"`
+		}
+		if err := json.Unmarshal(raw, &envelope); err != nil {
+			return nil, fmt.Errorf("failed to parse response envelope: %w", err)
+		}
+		raw = envelope.Data
+	}
+
+	var records []any
+	if err := json.Unmarshal(raw, &records); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+
+	found := make(map[Issue]int)
+	for _, record := range records {
+		issues := make(map[Issue]struct{})
+		walk(record, spec.Type, "", issues)
+		for _, field := range spec.Required {
+			if missing(record, strings.Split(field, ".")) {
+				issues[Issue{Kind: MissingField, Field: field}] = struct{}{}
+			}
+		}
+		for issue := range issues {
+			found[issue]++
+		}
+	}
+	return found, nil
+}
+
+func walk(value any, t reflect.Type, field string, issues map[Issue]struct{}) {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if value == nil {
+		return
+	}
+
+	mismatch := func() {
+		issues[Issue{Kind: TypeMismatch, Field: field, Expected: expected(t), Actual: actual(value)}] = struct{}{}
+	}
+
+	if t == timeType {
+		if _, ok := value.(string); !ok {
+			mismatch()
+		}
+		return
+	}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		object, ok := value.(map[string]any)
+		if !ok {
+			mismatch()
+			return
+		}
+		fields := jsonFields(t)
+		for key, v := range object {
+			f, known := fields[key]
+			if !known {
+				issues[Issue{Kind: UnknownField, Field: join(field, key), Actual: actual(v)}] = struct{}{}
+				continue
+			}
+			walk(v, f.Type, join(field, key), issues)
+		}
+
+	case reflect.Map:
+		object, ok := value.(map[string]any)
+		if !ok {
+			mismatch()
+			return
+		}
+		for _, v := range object {
+			walk(v, t.Elem(), join(field, "*"), issues)
+		}
+
+	case reflect.Slice, reflect.Array:
+		array, ok := value.([]any)
+		if !ok {
+			mismatch()
+			return
+		}
+		for _, v := range array {
+			walk(v, t.Elem(), field+"[]", issues)
+		}
+
+	case reflect.String:
+		if _, ok := value.(string); !ok {
+			mismatch()
+		}
+
+	case reflect.Bool:
+		if _, ok := value.(bool); !ok {
+			mismatch()
+		}
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		if _, ok := value.(float64); !ok {
+			mismatch()
+		}
+	}
+}
+
+func missing(value any, path []string) bool {
+	if len(path) == 0 {
+		switch v := value.(type) {
+		case nil:
+			return true
+		case string:
+			return v == ""
+		}
+		return false
+	}
+
+	object, ok := value.(map[string]any)
+	if !ok {
+		return true
+	}
+
+	key, each := strings.CutSuffix(path[0], "[]")
+	v, present := object[key]
+	if !present {
+		return true
+	}
+	if !each {
+		return missing(v, path[1:])
+	}
+
+	array, ok := v.([]any)
+	if !ok {
+		return true
+	}
+	for _, element := range array {
+		if missing(element, path[1:]) {
+			return true
+		}
+	}
+	return false
+}
+
+func jsonFields(t reflect.Type) map[string]reflect.StructField {
+	fields := make(map[string]reflect.StructField, t.NumField())
+	for f := range t.Fields() {
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		switch name {
+		case "-":
+			continue
+		case "":
+			name = f.Name
+		}
+		fields[name] = f
+	}
+	return fields
+}
+
+func join(field, key string) string {
+	if field == "" {
+		return key
+	}
+	return field + "." + key
+}
+
+func expected(t reflect.Type) string {
+	if t == timeType {
+		return "string"
+	}
+	switch t.Kind() {
+	case reflect.Struct, reflect.Map:
+		return "object"
+	case reflect.Slice, reflect.Array:
+		return "array"
+	case reflect.Bool:
+		return "boolean"
+	case reflect.String:
+		return "string"
+	default:
+		return "number"
+	}
+}
+
+func actual(value any) string {
+	switch value.(type) {
+	case nil:
+		return "null"
+	case map[string]any:
+		return "object"
+	case []any:
+		return "array"
+	case bool:
+		return "boolean"
+	case string:
+		return "string"
+	case float64:
+		return "number"
+	default:
+		return fmt.Sprintf("%T", value)
+	}
+}
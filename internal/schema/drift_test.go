@@ -0,0 +1,86 @@
+package schema
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type location struct {
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+}
+
+type price struct {
+	FuelType string     `json:"fuel_type"`
+	Price    float64    `json:"price"`
+	Updated  *time.Time `json:"updated,omitempty"`
+}
+
+type record struct {
+	NodeId   string            `json:"node_id"`
+	Location location          `json:"location"`
+	Prices   []price           `json:"prices"`
+	Hours    map[string]string `json:"hours"`
+}
+
+var spec = SpecFor[record]("node_id", "location.latitude", "prices[].fuel_type")
+
+func TestInspect_NoIssues(t *testing.T) {
+	issues, err := Inspect([]byte(`[
+		{"node_id": "1", "location": {"latitude": 51.5, "longitude": -0.1}, "prices": [{"fuel_type": "E10", "price": 139.9, "updated": "2026-01-01T00:00:00Z"}], "hours": {"monday": "24h"}}
+	]`), spec)
+	require.NoError(t, err)
+	assert.Empty(t, issues)
+}
+
+func TestInspect_ReportsDrift(t *testing.T) {
+	issues, err := Inspect([]byte(`{"data": [
+		{"node_id": "1", "location": {"latitude": "51.5", "longitude": -0.1, "altitude": 12}, "prices": [{"fuel_type": "E10", "price": 139.9, "updated": 1767225600}]},
+		{"node_id": "", "location": {"longitude": -0.1, "altitude": 30}, "prices": [{"price": 139.9}], "hours": {"monday": false}},
+		{"node_id": "3", "location": null, "prices": [], "brand": "SHELL"}
+	], "metadata": {"total_batches": 1}}`), spec)
+	require.NoError(t, err)
+
+	assert.Equal(t, map[Issue]int{
+		{Kind: TypeMismatch, Field: "location.latitude", Expected: "number", Actual: "string"}: 1,
+		{Kind: TypeMismatch, Field: "prices[].updated", Expected: "string", Actual: "number"}:  1,
+		{Kind: TypeMismatch, Field: "hours.*", Expected: "string", Actual: "boolean"}:          1,
+		{Kind: UnknownField, Field: "location.altitude", Actual: "number"}:                     2,
+		{Kind: UnknownField, Field: "brand", Actual: "string"}:                                 1,
+		{Kind: MissingField, Field: "node_id"}:                                                 1,
+		{Kind: MissingField, Field: "location.latitude"}:                                       2,
+		{Kind: MissingField, Field: "prices[].fuel_type"}:                                      1,
+	}, issues)
+}
+
+func TestInspect_InvalidJSON(t *testing.T) {
+	_, err := Inspect([]byte(`[{"node_id": `), spec)
+	assert.Error(t, err)
+}
+
+func TestTracker(t *testing.T) {
+	tracker := NewTracker()
+	unknown := Issue{Kind: UnknownField, Field: "brand", Actual: "string"}
+	missing := Issue{Kind: MissingField, Field: "node_id"}
+
+	tracker.Record("pfs", 1, map[Issue]int{unknown: 3, missing: 1})
+	tracker.Record("pfs", 2, map[Issue]int{unknown: 2})
+	tracker.Record("pfs/fuel-prices", 1, map[Issue]int{unknown: 1})
+
+	report := tracker.Report()
+	require.Len(t, report, 3)
+
+	assert.Equal(t, "pfs", report[0].Path)
+	assert.Equal(t, "missing_field", report[0].Kind)
+	assert.Equal(t, 1, report[0].Occurrences)
+
+	assert.Equal(t, "unknown_field", report[1].Kind)
+	assert.Equal(t, 5, report[1].Occurrences)
+	assert.Equal(t, 2, report[1].Batches)
+	assert.False(t, report[1].LastSeen.Before(report[1].FirstSeen))
+
+	assert.Equal(t, "pfs/fuel-prices", report[2].Path)
+}
@@ -0,0 +1,76 @@
+package schema
+
+import (
+	"cmp"
+	"log"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/rm-hull/fuel-prices-api/internal/models"
+)
+
+type trackerKey struct {
+	path string
+	Issue
+}
+
+// Tracker accumulates the issues found in upstream responses since the
+// process started, keyed by upstream path.
+type Tracker struct {
+	mu    sync.Mutex
+	drift map[trackerKey]*models.SchemaDrift
+}
+
+func NewTracker() *Tracker {
+	return &Tracker{drift: make(map[trackerKey]*models.SchemaDrift)}
+}
+
+// Record adds the issues found in a single batch from the given upstream path.
+// Each issue is logged the first time it is seen.
+func (tracker *Tracker) Record(path string, batchNo int, issues map[Issue]int) {
+	tracker.mu.Lock()
+	defer tracker.mu.Unlock()
+
+	now := time.Now().UTC()
+	for issue, occurrences := range issues {
+		key := trackerKey{path: path, Issue: issue}
+		drift, seen := tracker.drift[key]
+		if !seen {
+			log.Printf("WARNING: schema drift in %s batch %d: %s %q (expected: %q, actual: %q)",
+				path, batchNo, issue.Kind, issue.Field, issue.Expected, issue.Actual)
+			drift = &models.SchemaDrift{
+				Path:      path,
+				Kind:      string(issue.Kind),
+				Field:     issue.Field,
+				Expected:  issue.Expected,
+				Actual:    issue.Actual,
+				FirstSeen: now,
+			}
+			tracker.drift[key] = drift
+		}
+		drift.Occurrences += occurrences
+		drift.Batches++
+		drift.LastSeen = now
+	}
+}
+
+// Report returns everything recorded so far, ordered by path, kind and field.
+func (tracker *Tracker) Report() []models.SchemaDrift {
+	tracker.mu.Lock()
+	defer tracker.mu.Unlock()
+
+	report := make([]models.SchemaDrift, 0, len(tracker.drift))
+	for _, drift := range tracker.drift {
+		report = append(report, *drift)
+	}
+	slices.SortFunc(report, func(a, b models.SchemaDrift) int {
+		return cmp.Or(
+			cmp.Compare(a.Path, b.Path),
+			cmp.Compare(a.Kind, b.Kind),
+			cmp.Compare(a.Field, b.Field),
+			cmp.Compare(a.Actual, b.Actual),
+		)
+	})
+	return report
+}
@@ -0,0 +1,145 @@
+// Package sources provides data sources which load stations and prices from
+// local files in other national or retailer formats. Importing it registers
+// each kind with the internal package's data source registry.
+package sources
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/rm-hull/fuel-prices-api/internal"
+	"github.com/rm-hull/fuel-prices-api/internal/cma"
+	"github.com/rm-hull/fuel-prices-api/internal/models"
+)
+
+// batchSize is the number of records handed to each callback, mirroring the
+// batching of the GOV.UK API so that inserts stay reasonably sized.
+const batchSize = 500
+
+func init() {
+	internal.RegisterDataSource("cma", newFileSource(".json", decodeCMA))
+	internal.RegisterDataSource("prix-carburants", newFileSource(".xml", decodePrixCarburants))
+	internal.RegisterDataSource("tankerkoenig", newFileSource(".json", decodeTankerkoenig))
+}
+
+// decoder maps a single file onto stations and prices, tagged with source.
+type decoder func(filename, source string) ([]models.PetrolFillingStation, []models.ForecourtPrices, error)
+
+// fileSource loads every file with the given extension under the configured
+// path, which may also be a single file. Files are re-read on each fetch, so
+// replacing them is enough to pick up new data.
+type fileSource struct {
+	cfg    internal.SourceConfig
+	ext    string
+	decode decoder
+}
+
+func newFileSource(ext string, decode decoder) internal.DataSourceFactory {
+	return func(cfg internal.SourceConfig) (internal.DataSource, error) {
+		if cfg.Path == "" {
+			return nil, fmt.Errorf("no path configured for data source %s", cfg.Name)
+		}
+		return &fileSource{cfg: cfg, ext: ext, decode: decode}, nil
+	}
+}
+
+func (src *fileSource) Name() string {
+	return src.cfg.Name
+}
+
+func (src *fileSource) Schedules() (string, string) {
+	return src.cfg.StationsSchedule, src.cfg.PricesSchedule
+}
+
+func (src *fileSource) GetFillingStations(ctx context.Context, callback internal.BatchCallback[models.PetrolFillingStation]) (int, int, error) {
+	return load(ctx, src, func(stations []models.PetrolFillingStation, _ []models.ForecourtPrices) []models.PetrolFillingStation {
+		return stations
+	}, callback)
+}
+
+func (src *fileSource) GetFuelPrices(ctx context.Context, callback internal.BatchCallback[models.ForecourtPrices]) (int, int, error) {
+	return load(ctx, src, func(_ []models.PetrolFillingStation, prices []models.ForecourtPrices) []models.ForecourtPrices {
+		return prices
+	}, callback)
+}
+
+func load[T any](
+	ctx context.Context,
+	src *fileSource,
+	pick func([]models.PetrolFillingStation, []models.ForecourtPrices) []T,
+	callback internal.BatchCallback[T],
+) (int, int, error) {
+	files, err := files(src.cfg.Path, src.ext)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	count := 0
+	totalDropped := 0
+	for _, file := range files {
+		stations, prices, err := src.decode(file, src.cfg.Name)
+		if err != nil {
+			return 0, 0, fmt.Errorf("failed to decode %s: %w", file, err)
+		}
+
+		batchNo := 0
+		fetchedAt := time.Now()
+		for batch := range slices.Chunk(pick(stations, prices), batchSize) {
+			if err := ctx.Err(); err != nil {
+				return 0, 0, err
+			}
+			batchNo++
+			info := internal.BatchInfo{Path: file, Number: batchNo, FetchedAt: fetchedAt, Full: true}
+			numRecords, dropped, err := callback(internal.WithBatchInfo(ctx, info), batch)
+			if err != nil {
+				return 0, 0, fmt.Errorf("callback error: %w", err)
+			}
+			count += numRecords
+			totalDropped += dropped
+		}
+	}
+	return count, totalDropped, nil
+}
+
+// files returns path if it is a file, or else every file in it with the
+// given extension, sorted.
+func files(path, ext string) ([]string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return []string{path}, nil
+	}
+
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		return nil, err
+	}
+	files := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		if !entry.IsDir() && strings.EqualFold(filepath.Ext(entry.Name()), ext) {
+			files = append(files, filepath.Join(path, entry.Name()))
+		}
+	}
+	return files, nil
+}
+
+func decodeCMA(filename, source string) ([]models.PetrolFillingStation, []models.ForecourtPrices, error) {
+	feed, err := cma.Load(filename)
+	if err != nil {
+		return nil, nil, err
+	}
+	return feed.ToModels(source)
+}
+
+// euroCents converts a price in euros to cents, to one decimal place.
+func euroCents(euros float64) float64 {
+	return math.Round(euros*1000) / 10
+}
@@ -0,0 +1,156 @@
+package sources
+
+import (
+	"bufio"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+	_ "time/tzdata"
+
+	"github.com/rm-hull/fuel-prices-api/internal/models"
+	"golang.org/x/text/encoding/charmap"
+)
+
+// The French government's prix-carburants open data, as published in
+// PrixCarburants_instantane.xml and the annual archives.
+
+type pdvListe struct {
+	Pdv []pdv `xml:"pdv"`
+}
+
+type pdv struct {
+	Id        string   `xml:"id,attr"`
+	Latitude  string   `xml:"latitude,attr"`
+	Longitude string   `xml:"longitude,attr"`
+	Cp        string   `xml:"cp,attr"`
+	Pop       string   `xml:"pop,attr"`
+	Adresse   string   `xml:"adresse"`
+	Ville     string   `xml:"ville"`
+	Services  []string `xml:"services>service"`
+	Prix      []prix   `xml:"prix"`
+}
+
+type prix struct {
+	Nom    string `xml:"nom,attr"`
+	Maj    string `xml:"maj,attr"`
+	Valeur string `xml:"valeur,attr"`
+}
+
+var paris = mustLoadLocation("Europe/Paris")
+
+func mustLoadLocation(name string) *time.Location {
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		panic(err)
+	}
+	return loc
+}
+
+func decodePrixCarburants(filename, source string) ([]models.PetrolFillingStation, []models.ForecourtPrices, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer func() {
+		_ = file.Close()
+	}()
+
+	decoder := xml.NewDecoder(bufio.NewReader(file))
+	decoder.CharsetReader = charsetReader
+
+	var liste pdvListe
+	if err := decoder.Decode(&liste); err != nil {
+		return nil, nil, fmt.Errorf("failed to parse XML: %w", err)
+	}
+
+	stations := make([]models.PetrolFillingStation, 0, len(liste.Pdv))
+	prices := make([]models.ForecourtPrices, 0, len(liste.Pdv))
+	for _, pdv := range liste.Pdv {
+		if pdv.Id == "" {
+			continue
+		}
+
+		nodeId := source + ":" + pdv.Id
+		pfs := models.PetrolFillingStation{
+			NodeId:                   nodeId,
+			IsMotorwayServiceStation: pdv.Pop == "A",
+			Location: models.Location{
+				AddressLine1: strings.TrimSpace(pdv.Adresse),
+				City:         strings.TrimSpace(pdv.Ville),
+				Country:      "France",
+				Postcode:     pdv.Cp,
+				Latitude:     geodecimal(pdv.Latitude),
+				Longitude:    geodecimal(pdv.Longitude),
+			},
+			Amenities:   pdv.Services,
+			Source:      source,
+			CountryCode: "FR",
+		}
+		forecourt := models.ForecourtPrices{NodeId: nodeId, Source: source}
+
+		for _, p := range pdv.Prix {
+			valeur, err := strconv.ParseFloat(p.Valeur, 64)
+			if err != nil || valeur <= 0 {
+				continue
+			}
+			if valeur > 10 {
+				valeur /= 1000 // older files give prices in thousandths of a euro
+			}
+			maj, err := parseMaj(p.Maj)
+			if err != nil {
+				return nil, nil, fmt.Errorf("station %s: %w", pdv.Id, err)
+			}
+
+			fuelType := models.CanonicalFuelType(p.Nom)
+			pfs.FuelTypes = append(pfs.FuelTypes, fuelType)
+			forecourt.FuelPrices = append(forecourt.FuelPrices, models.FuelPrice{
+				FuelType:         fuelType,
+				Price:            euroCents(valeur),
+				PriceLastUpdated: maj,
+			})
+		}
+
+		stations = append(stations, pfs)
+		prices = append(prices, forecourt)
+	}
+
+	return stations, prices, nil
+}
+
+// geodecimal parses a coordinate, which is given in hundred-thousandths of a
+// degree unless it already contains a decimal point.
+func geodecimal(value string) float64 {
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return 0
+	}
+	if !strings.Contains(value, ".") {
+		f /= 100000
+	}
+	return f
+}
+
+func parseMaj(value string) (time.Time, error) {
+	for _, layout := range []string{time.DateTime, "2006-01-02T15:04:05"} {
+		if t, err := time.ParseInLocation(layout, value, paris); err == nil {
+			return t.UTC(), nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("unrecognised price timestamp: %q", value)
+}
+
+// charsetReader handles the ISO-8859-1 encoding the feed is published in,
+// which encoding/xml does not support itself.
+func charsetReader(charset string, input io.Reader) (io.Reader, error) {
+	switch strings.ToLower(charset) {
+	case "iso-8859-1", "latin1", "latin-1":
+		return charmap.ISO8859_1.NewDecoder().Reader(input), nil
+	case "windows-1252":
+		return charmap.Windows1252.NewDecoder().Reader(input), nil
+	}
+	return nil, fmt.Errorf("unsupported charset: %s", charset)
+}
@@ -0,0 +1,143 @@
+package sources
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/rm-hull/fuel-prices-api/internal"
+	"github.com/rm-hull/fuel-prices-api/internal/cma"
+	"github.com/rm-hull/fuel-prices-api/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Encoded as ISO-8859-1, as published: \xe8 is an e with a grave accent.
+const prixCarburantsXML = "<?xml version=\"1.0\" encoding=\"ISO-8859-1\" standalone=\"yes\"?>\n" +
+	"<pdv_liste>\n" +
+	"  <pdv id=\"1000001\" latitude=\"4620114\" longitude=\"519791\" cp=\"01000\" pop=\"A\">\n" +
+	"    <adresse>596 AVENUE DE TREVOUX</adresse>\n" +
+	"    <ville>SAINT-DENIS-L\xe8S-BOURG</ville>\n" +
+	"    <services><service>Vente de gaz domestique</service></services>\n" +
+	"    <prix nom=\"Gazole\" id=\"1\" maj=\"2024-01-02 07:21:00\" valeur=\"1.799\"/>\n" +
+	"    <prix nom=\"SP98\" id=\"6\" maj=\"2024-01-02T07:21:00\" valeur=\"1949\"/>\n" +
+	"  </pdv>\n" +
+	"</pdv_liste>\n"
+
+const cmaJSON = `{"last_updated": "21/11/2023 10:45:02", "stations": [
+	{"site_id": "gbfs0001", "brand": "TESCO", "address": "Oxford Road, Leeds", "postcode": "LS1 1AA", "location": {"latitude": 53.8, "longitude": -1.55}, "prices": {"E10": 145.9}}
+]}`
+
+const tankerkoenigJSON = `{"ok": true, "stations": [
+	{"id": "474e5046-deaf-4f9b-9a32-9797b778f047", "name": "TOTAL BERLIN", "brand": "TOTAL", "street": "MARGARETE-SOMMER-STR.", "houseNumber": "2", "postCode": 1067, "place": "BERLIN", "lat": 52.53083, "lng": 13.440946, "diesel": 1.109, "e5": false, "e10": 1.319, "isOpen": true}
+]}`
+
+func newSource(t *testing.T, kind, filename, content string) internal.DataSource {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, filename), []byte(content), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "ignored.txt"), []byte("not a feed"), 0644))
+
+	source, err := internal.NewDataSource(internal.SourceConfig{Name: "test", Kind: kind, Path: dir})
+	require.NoError(t, err)
+	return source
+}
+
+func collect[T any](t *testing.T, fetch func(internal.BatchCallback[T]) (int, int, error)) []T {
+	var all []T
+	_, _, err := fetch(func(_ context.Context, batch []T) (int, int, error) {
+		all = append(all, batch...)
+		return len(batch), 0, nil
+	})
+	require.NoError(t, err)
+	return all
+}
+
+func TestPrixCarburants(t *testing.T) {
+	source := newSource(t, "prix-carburants", "PrixCarburants_instantane.xml", prixCarburantsXML)
+	assert.Equal(t, "test", source.Name())
+
+	stations := collect(t, func(cb internal.BatchCallback[models.PetrolFillingStation]) (int, int, error) {
+		return source.GetFillingStations(t.Context(), cb)
+	})
+	require.Len(t, stations, 1)
+	pfs := stations[0]
+	assert.Equal(t, "test:1000001", pfs.NodeId)
+	assert.Equal(t, "SAINT-DENIS-LèS-BOURG", pfs.Location.City)
+	assert.Equal(t, "01000", pfs.Location.Postcode)
+	assert.InDelta(t, 46.20114, pfs.Location.Latitude, 1e-9)
+	assert.InDelta(t, 5.19791, pfs.Location.Longitude, 1e-9)
+	assert.True(t, pfs.IsMotorwayServiceStation)
+	assert.Equal(t, []string{"B7_STANDARD", "E5_PREMIUM"}, pfs.FuelTypes)
+	assert.Equal(t, "test", pfs.Source)
+
+	prices := collect(t, func(cb internal.BatchCallback[models.ForecourtPrices]) (int, int, error) {
+		return source.GetFuelPrices(t.Context(), cb)
+	})
+	require.Len(t, prices, 1)
+	require.Len(t, prices[0].FuelPrices, 2)
+	assert.Equal(t, 179.9, prices[0].FuelPrices[0].Price)
+	assert.Equal(t, 194.9, prices[0].FuelPrices[1].Price)
+	assert.Equal(t, time.Date(2024, 1, 2, 6, 21, 0, 0, time.UTC), prices[0].FuelPrices[0].PriceLastUpdated)
+	assert.Equal(t, "test", prices[0].Source)
+}
+
+func TestTankerkoenig(t *testing.T) {
+	source := newSource(t, "tankerkoenig", "list.json", tankerkoenigJSON)
+
+	stations := collect(t, func(cb internal.BatchCallback[models.PetrolFillingStation]) (int, int, error) {
+		return source.GetFillingStations(t.Context(), cb)
+	})
+	require.Len(t, stations, 1)
+	pfs := stations[0]
+	assert.Equal(t, "test:474e5046-deaf-4f9b-9a32-9797b778f047", pfs.NodeId)
+	assert.Equal(t, "MARGARETE-SOMMER-STR. 2", pfs.Location.AddressLine1)
+	assert.Equal(t, "01067", pfs.Location.Postcode)
+	assert.Equal(t, []string{"B7_STANDARD", "E10"}, pfs.FuelTypes)
+
+	prices := collect(t, func(cb internal.BatchCallback[models.ForecourtPrices]) (int, int, error) {
+		return source.GetFuelPrices(t.Context(), cb)
+	})
+	require.Len(t, prices, 1)
+	require.Len(t, prices[0].FuelPrices, 2)
+	assert.Equal(t, 110.9, prices[0].FuelPrices[0].Price)
+	assert.Equal(t, 131.9, prices[0].FuelPrices[1].Price)
+	assert.False(t, prices[0].FuelPrices[0].PriceLastUpdated.IsZero())
+}
+
+// TestCMA_ImportedBothWays checks a feed imported by a configured data source
+// maps onto the same station as when imported by import-cma.
+func TestCMA_ImportedBothWays(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "fuel_prices.db")
+	db, err := internal.Connect(dbPath)
+	require.NoError(t, err)
+	require.NoError(t, internal.Migrate("../../migrations", dbPath))
+	repo := internal.NewFuelPricesRepository(db, &models.Retailers{})
+	t.Cleanup(func() {
+		require.NoError(t, repo.Close())
+	})
+
+	source := newSource(t, "cma", "tesco.json", cmaJSON)
+	_, _, err = source.GetFillingStations(t.Context(), repo.InsertPFS)
+	require.NoError(t, err)
+
+	filename := filepath.Join(t.TempDir(), "tesco.json")
+	require.NoError(t, os.WriteFile(filename, []byte(cmaJSON), 0644))
+	feed, err := cma.Load(filename)
+	require.NoError(t, err)
+	stations, _, err := feed.ToModels(cma.Source(filename))
+	require.NoError(t, err)
+	_, _, err = repo.InsertPFS(t.Context(), stations)
+	require.NoError(t, err)
+
+	results, err := repo.Search([]float64{-2, 53, -1, 54}, 1, true)
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.Equal(t, "cma:tesco:gbfs0001", results[0].NodeId)
+}
+
+func TestFileSource_RequiresPath(t *testing.T) {
+	_, err := internal.NewDataSource(internal.SourceConfig{Name: "test", Kind: "tankerkoenig"})
+	assert.Error(t, err)
+}
@@ -0,0 +1,152 @@
+package sources
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/rm-hull/fuel-prices-api/internal/models"
+)
+
+// Tankerkönig-style JSON, as returned by its list API and found in dumps of
+// it: either a bare array of stations, or an object with a stations array.
+// Prices carry no timestamp of their own, so the file's modification time is
+// used instead.
+
+type tankerkoenigList struct {
+	Stations []tankerkoenigStation `json:"stations"`
+}
+
+type tankerkoenigStation struct {
+	Id          string            `json:"id"`
+	Name        string            `json:"name"`
+	Brand       string            `json:"brand"`
+	Street      string            `json:"street"`
+	HouseNumber string            `json:"houseNumber"`
+	PostCode    tankerkoenigText  `json:"postCode"`
+	Place       string            `json:"place"`
+	Lat         float64           `json:"lat"`
+	Lng         float64           `json:"lng"`
+	Diesel      tankerkoenigPrice `json:"diesel"`
+	E5          tankerkoenigPrice `json:"e5"`
+	E10         tankerkoenigPrice `json:"e10"`
+}
+
+// tankerkoenigPrice is a price in euros, or false when the fuel is not sold.
+type tankerkoenigPrice float64
+
+func (p *tankerkoenigPrice) UnmarshalJSON(data []byte) error {
+	if string(data) == "false" || string(data) == "null" {
+		*p = 0
+		return nil
+	}
+	f, err := strconv.ParseFloat(string(bytes.Trim(data, `"`)), 64)
+	if err != nil {
+		return fmt.Errorf("invalid price %s: %w", data, err)
+	}
+	*p = tankerkoenigPrice(f)
+	return nil
+}
+
+// tankerkoenigText accepts either a string or a number, as postcodes are
+// given as numbers, losing any leading zero.
+type tankerkoenigText string
+
+func (t *tankerkoenigText) UnmarshalJSON(data []byte) error {
+	if bytes.HasPrefix(data, []byte(`"`)) {
+		var s string
+		if err := json.Unmarshal(data, &s); err != nil {
+			return err
+		}
+		*t = tankerkoenigText(s)
+		return nil
+	}
+	if string(data) == "null" {
+		*t = ""
+		return nil
+	}
+	if n, err := strconv.Atoi(string(data)); err == nil {
+		*t = tankerkoenigText(fmt.Sprintf("%05d", n))
+		return nil
+	}
+	*t = tankerkoenigText(data)
+	return nil
+}
+
+func decodeTankerkoenig(filename, source string) ([]models.PetrolFillingStation, []models.ForecourtPrices, error) {
+	info, err := os.Stat(filename)
+	if err != nil {
+		return nil, nil, err
+	}
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var list tankerkoenigList
+	data = bytes.TrimSpace(data)
+	if len(data) > 0 && data[0] == '[' {
+		err = json.Unmarshal(data, &list.Stations)
+	} else {
+		err = json.Unmarshal(data, &list)
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to parse JSON: %w", err)
+	}
+
+	updated := info.ModTime().UTC()
+	stations := make([]models.PetrolFillingStation, 0, len(list.Stations))
+	prices := make([]models.ForecourtPrices, 0, len(list.Stations))
+	for _, station := range list.Stations {
+		if station.Id == "" {
+			continue
+		}
+
+		nodeId := source + ":" + station.Id
+		brand := strings.TrimSpace(station.Brand)
+		pfs := models.PetrolFillingStation{
+			NodeId:                    nodeId,
+			TradingName:               strings.TrimSpace(station.Name),
+			IsSameTradingAndBrandName: strings.EqualFold(strings.TrimSpace(station.Name), brand),
+			BrandName:                 brand,
+			Location: models.Location{
+				AddressLine1: strings.TrimSpace(station.Street + " " + station.HouseNumber),
+				City:         strings.TrimSpace(station.Place),
+				Country:      "Germany",
+				Postcode:     string(station.PostCode),
+				Latitude:     station.Lat,
+				Longitude:    station.Lng,
+			},
+			Source:      source,
+			CountryCode: "DE",
+		}
+		forecourt := models.ForecourtPrices{NodeId: nodeId, TradingName: pfs.TradingName, Source: source}
+
+		for _, fuel := range []struct {
+			fuelType string
+			price    tankerkoenigPrice
+		}{
+			{models.CanonicalFuelType("diesel"), station.Diesel},
+			{models.CanonicalFuelType("e5"), station.E5},
+			{models.CanonicalFuelType("e10"), station.E10},
+		} {
+			if fuel.price <= 0 {
+				continue
+			}
+			pfs.FuelTypes = append(pfs.FuelTypes, fuel.fuelType)
+			forecourt.FuelPrices = append(forecourt.FuelPrices, models.FuelPrice{
+				FuelType:         fuel.fuelType,
+				Price:            euroCents(float64(fuel.price)),
+				PriceLastUpdated: updated,
+			})
+		}
+
+		stations = append(stations, pfs)
+		prices = append(prices, forecourt)
+	}
+
+	return stations, prices, nil
+}
@@ -0,0 +1,20 @@
+SELECT
+    (
+        SELECT price
+        FROM fuel_prices
+        WHERE node_id = ? AND fuel_type = ? AND price_last_updated < ?
+        ORDER BY price_last_updated DESC
+        LIMIT 1
+    ) AS previous_price,
+    (
+        SELECT price
+        FROM fuel_prices
+        WHERE node_id = ? AND fuel_type = ? AND price_last_updated < ? AND anomaly IS NULL
+        ORDER BY price_last_updated DESC
+        LIMIT 1
+    ) AS previous_normal_price,
+    (
+        SELECT UPPER(SUBSTR(TRIM(postcode), 1, LENGTH(TRIM(postcode)) - LENGTH(LTRIM(TRIM(postcode), 'ABCDEFGHIJKLMNOPQRSTUVWXYZ'))))
+        FROM petrol_filling_stations
+        WHERE node_id = ? AND country_code = 'GB'
+    ) AS postcode_area;
\ No newline at end of file
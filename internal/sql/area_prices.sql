@@ -0,0 +1,4 @@
+SELECT postcode_area, fuel_type, price
+FROM fuel_price_latest_with_area
+WHERE postcode_area IS NOT NULL AND postcode_area <> ''
+ORDER BY postcode_area, fuel_type, price;
\ No newline at end of file
@@ -0,0 +1,10 @@
+-- A repeated price confirms the change, so it is no longer a suspected jump.
+WITH confirmed(id, confirmed_at) AS (
+    VALUES (?, ?)
+)
+UPDATE fuel_prices
+SET last_confirmed_at = MAX(COALESCE(last_confirmed_at, price_last_updated), confirmed.confirmed_at),
+    anomaly = CASE WHEN anomaly = 'jump' THEN NULL ELSE anomaly END,
+    anomaly_reference = CASE WHEN anomaly = 'jump' THEN NULL ELSE anomaly_reference END
+FROM confirmed
+WHERE fuel_prices.rowid = confirmed.id;
\ No newline at end of file
@@ -0,0 +1,3 @@
+UPDATE fuel_prices
+SET price = ?
+WHERE node_id = ? AND fuel_type = ? AND price_last_updated = ?;
\ No newline at end of file
@@ -0,0 +1,2 @@
+DELETE FROM fuel_prices
+WHERE rowid IN (SELECT id FROM compact_runs WHERE id != keep_id);
\ No newline at end of file
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS temp.compact_runs;
\ No newline at end of file
@@ -0,0 +1,29 @@
+-- Groups each station's prices for a fuel type into runs of the same price,
+-- noting the first row of each run, which is kept, and the latest time the
+-- run was confirmed.
+CREATE TEMP TABLE compact_runs AS
+WITH ordered AS (
+    SELECT
+        rowid AS id,
+        node_id,
+        fuel_type,
+        price_last_updated,
+        COALESCE(last_confirmed_at, price_last_updated) AS last_confirmed_at,
+        price,
+        LAG(price) OVER (PARTITION BY node_id, fuel_type ORDER BY price_last_updated) AS prev_price
+    FROM fuel_prices
+),
+grouped AS (
+    SELECT
+        *,
+        SUM(CASE WHEN price IS DISTINCT FROM prev_price THEN 1 ELSE 0 END)
+            OVER (PARTITION BY node_id, fuel_type ORDER BY price_last_updated ROWS UNBOUNDED PRECEDING) AS grp
+    FROM ordered
+)
+SELECT
+    id,
+    FIRST_VALUE(id) OVER run AS keep_id,
+    MAX(last_confirmed_at) OVER run AS last_confirmed_at,
+    COUNT(*) OVER run AS run_length
+FROM grouped
+WINDOW run AS (PARTITION BY node_id, fuel_type, grp ORDER BY price_last_updated ROWS BETWEEN UNBOUNDED PRECEDING AND UNBOUNDED FOLLOWING);
\ No newline at end of file
@@ -0,0 +1,6 @@
+-- A repeated price confirms the change, so it is no longer a suspected jump.
+UPDATE fuel_prices
+SET last_confirmed_at = MAX(COALESCE(last_confirmed_at, price_last_updated), ?),
+    anomaly = CASE WHEN anomaly = 'jump' THEN NULL ELSE anomaly END,
+    anomaly_reference = CASE WHEN anomaly = 'jump' THEN NULL ELSE anomaly_reference END
+WHERE node_id = ? AND fuel_type = ? AND price_last_updated = ?;
\ No newline at end of file
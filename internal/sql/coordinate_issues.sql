@@ -0,0 +1,18 @@
+SELECT
+    node_id,
+    trading_name,
+    postcode,
+    coordinate_issue,
+    coordinate_distance_km,
+    latitude,
+    longitude,
+    original_latitude,
+    original_longitude,
+    source,
+    updated_at
+FROM petrol_filling_stations
+WHERE coordinate_issue IS NOT NULL
+  AND (? = '' OR coordinate_issue = ?)
+  AND (NOT ? OR original_latitude IS NOT NULL)
+ORDER BY updated_at DESC, node_id
+LIMIT ? OFFSET ?;
\ No newline at end of file
@@ -0,0 +1,4 @@
+UPDATE petrol_filling_stations
+SET missed_refreshes = missed_refreshes + 1
+WHERE source = ?
+  AND (last_seen_at IS NULL OR datetime(last_seen_at) < datetime(?));
\ No newline at end of file
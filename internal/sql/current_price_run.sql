@@ -0,0 +1,5 @@
+SELECT price_last_updated, price
+FROM fuel_prices
+WHERE node_id = ? AND fuel_type = ? AND price_last_updated <= ?
+ORDER BY price_last_updated DESC
+LIMIT 1;
\ No newline at end of file
@@ -0,0 +1,3 @@
+UPDATE petrol_filling_stations
+SET inactive = 1
+WHERE source = ? AND inactive = 0 AND missed_refreshes >= ?;
\ No newline at end of file
@@ -0,0 +1,2 @@
+DELETE FROM fuel_prices
+WHERE node_id = ? AND fuel_type = ? AND price_last_updated = ?;
\ No newline at end of file
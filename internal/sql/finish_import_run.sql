@@ -0,0 +1,8 @@
+UPDATE import_runs
+SET status = ?,
+    finished_at = ?,
+    duration_ms = ?,
+    inserted = ?,
+    dropped = ?,
+    error = ?
+WHERE id = ?;
\ No newline at end of file
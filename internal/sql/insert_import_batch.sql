@@ -0,0 +1,2 @@
+INSERT INTO import_run_batches (run_id, batch_number, path, records, dropped, fetched_at)
+VALUES (?, ?, ?, ?, ?, ?);
\ No newline at end of file
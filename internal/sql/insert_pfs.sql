@@ -21,9 +21,16 @@ INSERT INTO petrol_filling_stations (
     opening_times_json,
     amenities_json,
     fuel_types_json,
+    source,
+    country_code,
+    last_seen_at,
+    coordinate_issue,
+    coordinate_distance_km,
+    original_latitude,
+    original_longitude,
     updated_at
 )
-VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, CURRENT_TIMESTAMP)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, CURRENT_TIMESTAMP)
 ON CONFLICT(node_id) DO UPDATE SET
     mft_organisation_name = EXCLUDED.mft_organisation_name,
     public_phone_number = EXCLUDED.public_phone_number,
@@ -46,4 +53,13 @@ ON CONFLICT(node_id) DO UPDATE SET
     opening_times_json = EXCLUDED.opening_times_json,
     amenities_json = EXCLUDED.amenities_json,
     fuel_types_json = EXCLUDED.fuel_types_json,
+    source = EXCLUDED.source,
+    country_code = EXCLUDED.country_code,
+    last_seen_at = EXCLUDED.last_seen_at,
+    coordinate_issue = EXCLUDED.coordinate_issue,
+    coordinate_distance_km = EXCLUDED.coordinate_distance_km,
+    original_latitude = EXCLUDED.original_latitude,
+    original_longitude = EXCLUDED.original_longitude,
+    missed_refreshes = 0,
+    inactive = 0,
     updated_at = CURRENT_TIMESTAMP;
\ No newline at end of file
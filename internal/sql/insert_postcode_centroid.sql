@@ -0,0 +1,5 @@
+INSERT INTO postcode_centroids (postcode, latitude, longitude)
+VALUES (?, ?, ?)
+ON CONFLICT(postcode) DO UPDATE SET
+    latitude = EXCLUDED.latitude,
+    longitude = EXCLUDED.longitude;
\ No newline at end of file
@@ -4,9 +4,17 @@ INSERT INTO fuel_prices (
     price_last_updated,
     price,
     price_change_effective_timestamp,
+    source,
+    anomaly,
+    anomaly_reference,
+    last_confirmed_at,
     recorded_at
 )
-VALUES (?, ?, ?, ?, ?, CURRENT_TIMESTAMP)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, CURRENT_TIMESTAMP)
 ON CONFLICT(node_id, fuel_type, price_last_updated) DO UPDATE SET
     price = EXCLUDED.price,
-    price_change_effective_timestamp = EXCLUDED.price_change_effective_timestamp;
\ No newline at end of file
+    price_change_effective_timestamp = EXCLUDED.price_change_effective_timestamp,
+    source = EXCLUDED.source,
+    anomaly = EXCLUDED.anomaly,
+    anomaly_reference = EXCLUDED.anomaly_reference,
+    last_confirmed_at = MAX(COALESCE(fuel_prices.last_confirmed_at, EXCLUDED.last_confirmed_at), EXCLUDED.last_confirmed_at);
\ No newline at end of file
@@ -0,0 +1,15 @@
+INSERT INTO price_quarantine (
+    node_id,
+    fuel_type,
+    price_last_updated,
+    price_change_effective_timestamp,
+    original_price,
+    corrected_price,
+    reason,
+    source,
+    batch_path,
+    batch_number,
+    batch_fetched_at
+)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+ON CONFLICT(node_id, fuel_type, price_last_updated, reason) DO NOTHING;
\ No newline at end of file
@@ -0,0 +1,9 @@
+SELECT id, job, source, triggered_by, status, started_at, finished_at, duration_ms, batches, inserted, dropped, error
+FROM import_runs
+WHERE (? = '' OR job = ?)
+  AND (? = '' OR source = ?)
+  AND (? = '' OR status = ?)
+  AND (? = '' OR triggered_by = ?)
+  AND datetime(started_at) >= datetime(?)
+ORDER BY started_at DESC, id DESC
+LIMIT ? OFFSET ?;
\ No newline at end of file
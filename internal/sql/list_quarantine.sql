@@ -0,0 +1,20 @@
+SELECT
+    id,
+    node_id,
+    fuel_type,
+    price_last_updated,
+    price_change_effective_timestamp,
+    original_price,
+    corrected_price,
+    reason,
+    source,
+    batch_path,
+    batch_number,
+    batch_fetched_at,
+    status,
+    quarantined_at,
+    reviewed_at
+FROM price_quarantine
+WHERE ? = '' OR status = ?
+ORDER BY quarantined_at DESC, id DESC
+LIMIT ? OFFSET ?;
\ No newline at end of file
@@ -0,0 +1 @@
+SELECT latitude, longitude FROM postcode_centroids WHERE postcode = ?;
\ No newline at end of file
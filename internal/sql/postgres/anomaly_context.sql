@@ -0,0 +1,20 @@
+SELECT
+    (
+        SELECT price
+        FROM fuel_prices
+        WHERE node_id = $1 AND fuel_type = $2 AND price_last_updated < $3
+        ORDER BY price_last_updated DESC
+        LIMIT 1
+    ) AS previous_price,
+    (
+        SELECT price
+        FROM fuel_prices
+        WHERE node_id = $4 AND fuel_type = $5 AND price_last_updated < $6 AND anomaly IS NULL
+        ORDER BY price_last_updated DESC
+        LIMIT 1
+    ) AS previous_normal_price,
+    (
+        SELECT postcode_area(postcode)
+        FROM petrol_filling_stations
+        WHERE node_id = $7 AND country_code = 'GB'
+    ) AS postcode_area;
\ No newline at end of file
@@ -0,0 +1,37 @@
+-- The postcode centroid and existing row of each station in a batch, in place
+-- of looking them up one station at a time.
+WITH batch(idx, node_id, postcode) AS (
+    VALUES ($1::integer, $2::text, $3::text)
+)
+SELECT
+    b.idx,
+    pc.latitude,
+    pc.longitude,
+    pfs.inactive,
+    pfs.node_id,
+    pfs.mft_organisation_name,
+    pfs.public_phone_number,
+    pfs.trading_name,
+    pfs.is_same_trading_and_brand_name,
+    pfs.brand_name,
+    pfs.temporary_closure,
+    pfs.permanent_closure,
+    pfs.permanent_closure_date,
+    pfs.is_motorway_service_station,
+    pfs.is_supermarket_service_station,
+    pfs.address_line_1,
+    pfs.address_line_2,
+    pfs.city,
+    pfs.country,
+    pfs.county,
+    pfs.postcode,
+    pfs.latitude,
+    pfs.longitude,
+    pfs.opening_times_json,
+    pfs.amenities_json,
+    pfs.fuel_types_json,
+    pfs.source,
+    pfs.country_code
+FROM batch b
+LEFT JOIN postcode_centroids pc ON pc.postcode = b.postcode
+LEFT JOIN petrol_filling_stations pfs ON pfs.node_id = b.node_id;
\ No newline at end of file
@@ -0,0 +1,44 @@
+-- The price in force, the price of the run which follows it, and the prices an
+-- anomaly check compares with, for each price in a batch, in place of looking
+-- them up one price at a time.
+WITH batch(idx, node_id, fuel_type, ts) AS (
+    VALUES ($1::integer, $2::text, $3::text, $4::timestamptz)
+)
+SELECT
+    b.idx,
+    run.id,
+    run.price,
+    (
+        SELECT price
+        FROM fuel_prices
+        WHERE node_id = b.node_id AND fuel_type = b.fuel_type AND price_last_updated > b.ts
+        ORDER BY price_last_updated
+        LIMIT 1
+    ) AS next_price,
+    (
+        SELECT price
+        FROM fuel_prices
+        WHERE node_id = b.node_id AND fuel_type = b.fuel_type AND price_last_updated < b.ts
+        ORDER BY price_last_updated DESC
+        LIMIT 1
+    ) AS previous_price,
+    (
+        SELECT price
+        FROM fuel_prices
+        WHERE node_id = b.node_id AND fuel_type = b.fuel_type AND price_last_updated < b.ts AND anomaly IS NULL
+        ORDER BY price_last_updated DESC
+        LIMIT 1
+    ) AS previous_normal_price,
+    (
+        SELECT postcode_area(postcode)
+        FROM petrol_filling_stations
+        WHERE node_id = b.node_id AND country_code = 'GB'
+    ) AS postcode_area
+FROM batch b
+LEFT JOIN fuel_prices run ON run.id = (
+    SELECT id
+    FROM fuel_prices
+    WHERE node_id = b.node_id AND fuel_type = b.fuel_type AND price_last_updated <= b.ts
+    ORDER BY price_last_updated DESC
+    LIMIT 1
+);
\ No newline at end of file
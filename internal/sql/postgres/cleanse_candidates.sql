@@ -0,0 +1,5 @@
+SELECT node_id, fuel_type, price_last_updated, price_change_effective_timestamp, source, price
+FROM fuel_prices
+WHERE fuel_type = $1
+  AND (price < $2 OR price > $3 OR price IN (0.999, 99.9, 999.9, 999.99))
+ORDER BY node_id, price_last_updated;
\ No newline at end of file
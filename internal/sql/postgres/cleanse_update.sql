@@ -0,0 +1,3 @@
+UPDATE fuel_prices
+SET price = $1
+WHERE node_id = $2 AND fuel_type = $3 AND price_last_updated = $4;
\ No newline at end of file
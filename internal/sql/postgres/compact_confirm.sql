@@ -0,0 +1,8 @@
+UPDATE fuel_prices
+SET last_confirmed_at = r.last_confirmed_at,
+    anomaly = CASE WHEN fuel_prices.anomaly = 'jump' THEN NULL ELSE fuel_prices.anomaly END,
+    anomaly_reference = CASE WHEN fuel_prices.anomaly = 'jump' THEN NULL ELSE fuel_prices.anomaly_reference END
+FROM compact_runs r
+WHERE fuel_prices.id = r.id
+  AND r.id = r.keep_id
+  AND r.run_length > 1;
\ No newline at end of file
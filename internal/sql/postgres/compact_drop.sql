@@ -0,0 +1 @@
+DROP TABLE IF EXISTS pg_temp.compact_runs;
\ No newline at end of file
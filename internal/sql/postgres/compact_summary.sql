@@ -0,0 +1 @@
+SELECT COUNT(*), COUNT(DISTINCT keep_id) FROM compact_runs;
\ No newline at end of file
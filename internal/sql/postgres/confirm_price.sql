@@ -0,0 +1,6 @@
+-- A repeated price confirms the change, so it is no longer a suspected jump.
+UPDATE fuel_prices
+SET last_confirmed_at = GREATEST(COALESCE(last_confirmed_at, price_last_updated), $1),
+    anomaly = CASE WHEN anomaly = 'jump' THEN NULL ELSE anomaly END,
+    anomaly_reference = CASE WHEN anomaly = 'jump' THEN NULL ELSE anomaly_reference END
+WHERE node_id = $2 AND fuel_type = $3 AND price_last_updated = $4;
\ No newline at end of file
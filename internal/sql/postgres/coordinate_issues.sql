@@ -0,0 +1,18 @@
+SELECT
+    node_id,
+    trading_name,
+    postcode,
+    coordinate_issue,
+    coordinate_distance_km,
+    latitude,
+    longitude,
+    original_latitude,
+    original_longitude,
+    source,
+    updated_at
+FROM petrol_filling_stations
+WHERE coordinate_issue IS NOT NULL
+  AND ($1 = '' OR coordinate_issue = $2)
+  AND (NOT $3 OR original_latitude IS NOT NULL)
+ORDER BY updated_at DESC, node_id
+LIMIT $4 OFFSET $5;
\ No newline at end of file
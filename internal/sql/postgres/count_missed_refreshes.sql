@@ -0,0 +1,4 @@
+UPDATE petrol_filling_stations
+SET missed_refreshes = missed_refreshes + 1
+WHERE source = $1
+  AND (last_seen_at IS NULL OR last_seen_at < $2);
\ No newline at end of file
@@ -0,0 +1,5 @@
+SELECT price_last_updated, price
+FROM fuel_prices
+WHERE node_id = $1 AND fuel_type = $2 AND price_last_updated <= $3
+ORDER BY price_last_updated DESC
+LIMIT 1;
\ No newline at end of file
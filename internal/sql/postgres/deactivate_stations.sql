@@ -0,0 +1,3 @@
+UPDATE petrol_filling_stations
+SET inactive = TRUE
+WHERE source = $1 AND NOT inactive AND missed_refreshes >= $2;
\ No newline at end of file
@@ -0,0 +1,2 @@
+DELETE FROM fuel_prices
+WHERE node_id = $1 AND fuel_type = $2 AND price_last_updated = $3;
\ No newline at end of file
@@ -0,0 +1,2 @@
+SELECT scope, postcode_area, fuel_type, price_bucket, sample_size
+FROM fuel_price_distribution_stats;
\ No newline at end of file
@@ -0,0 +1 @@
+SELECT path, last_fetched_at FROM fetch_watermarks;
\ No newline at end of file
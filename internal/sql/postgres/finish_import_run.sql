@@ -0,0 +1,8 @@
+UPDATE import_runs
+SET status = $1,
+    finished_at = $2,
+    duration_ms = $3,
+    inserted = $4,
+    dropped = $5,
+    error = $6
+WHERE id = $7;
\ No newline at end of file
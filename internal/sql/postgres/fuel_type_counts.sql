@@ -0,0 +1,7 @@
+SELECT fp.fuel_type, COUNT(DISTINCT fp.node_id)
+FROM fuel_prices fp
+JOIN petrol_filling_stations pfs ON fp.node_id = pfs.node_id
+WHERE fp.last_confirmed_at >= now() - interval '14 days'
+  AND NOT pfs.inactive
+  AND pfs.country_code = 'GB'
+GROUP BY fp.fuel_type;
\ No newline at end of file
@@ -0,0 +1 @@
+SELECT DISTINCT fuel_type FROM fuel_prices;
\ No newline at end of file
@@ -0,0 +1,3 @@
+SELECT id, job, source, triggered_by, status, started_at, finished_at, duration_ms, batches, inserted, dropped, error
+FROM import_runs
+WHERE id = $1;
\ No newline at end of file
@@ -0,0 +1,4 @@
+SELECT batch_number, path, records, dropped, fetched_at, applied_at
+FROM import_run_batches
+WHERE run_id = $1
+ORDER BY id;
\ No newline at end of file
@@ -0,0 +1,12 @@
+INSERT INTO cleanse_audit (
+    run_id,
+    rule,
+    node_id,
+    fuel_type,
+    price_last_updated,
+    price_change_effective_timestamp,
+    source,
+    price_before,
+    price_after
+)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9);
\ No newline at end of file
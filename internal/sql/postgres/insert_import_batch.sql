@@ -0,0 +1,2 @@
+INSERT INTO import_run_batches (run_id, batch_number, path, records, dropped, fetched_at)
+VALUES ($1, $2, $3, $4, $5, $6);
\ No newline at end of file
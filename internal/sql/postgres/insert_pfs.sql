@@ -0,0 +1,65 @@
+INSERT INTO petrol_filling_stations (
+    node_id,
+    mft_organisation_name,
+    public_phone_number,
+    trading_name,
+    is_same_trading_and_brand_name,
+    brand_name,
+    temporary_closure,
+    permanent_closure,
+    permanent_closure_date,
+    is_motorway_service_station,
+    is_supermarket_service_station,
+    address_line_1,
+    address_line_2,
+    city,
+    country,
+    county,
+    postcode,
+    latitude,
+    longitude,
+    opening_times_json,
+    amenities_json,
+    fuel_types_json,
+    source,
+    country_code,
+    last_seen_at,
+    coordinate_issue,
+    coordinate_distance_km,
+    original_latitude,
+    original_longitude,
+    updated_at
+)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $21, $22, $23, $24, $25, $26, $27, $28, $29, CURRENT_TIMESTAMP)
+ON CONFLICT(node_id) DO UPDATE SET
+    mft_organisation_name = EXCLUDED.mft_organisation_name,
+    public_phone_number = EXCLUDED.public_phone_number,
+    trading_name = EXCLUDED.trading_name,
+    is_same_trading_and_brand_name = EXCLUDED.is_same_trading_and_brand_name,
+    brand_name = EXCLUDED.brand_name,
+    temporary_closure = EXCLUDED.temporary_closure,
+    permanent_closure = EXCLUDED.permanent_closure,
+    permanent_closure_date = EXCLUDED.permanent_closure_date,
+    is_motorway_service_station = EXCLUDED.is_motorway_service_station,
+    is_supermarket_service_station = EXCLUDED.is_supermarket_service_station,
+    address_line_1 = EXCLUDED.address_line_1,
+    address_line_2 = EXCLUDED.address_line_2,
+    city = EXCLUDED.city,
+    country = EXCLUDED.country,
+    county = EXCLUDED.county,
+    postcode = EXCLUDED.postcode,
+    latitude = EXCLUDED.latitude,
+    longitude = EXCLUDED.longitude,
+    opening_times_json = EXCLUDED.opening_times_json,
+    amenities_json = EXCLUDED.amenities_json,
+    fuel_types_json = EXCLUDED.fuel_types_json,
+    source = EXCLUDED.source,
+    country_code = EXCLUDED.country_code,
+    last_seen_at = EXCLUDED.last_seen_at,
+    coordinate_issue = EXCLUDED.coordinate_issue,
+    coordinate_distance_km = EXCLUDED.coordinate_distance_km,
+    original_latitude = EXCLUDED.original_latitude,
+    original_longitude = EXCLUDED.original_longitude,
+    missed_refreshes = 0,
+    inactive = FALSE,
+    updated_at = CURRENT_TIMESTAMP;
\ No newline at end of file
@@ -0,0 +1,5 @@
+INSERT INTO postcode_centroids (postcode, latitude, longitude)
+VALUES ($1, $2, $3)
+ON CONFLICT(postcode) DO UPDATE SET
+    latitude = EXCLUDED.latitude,
+    longitude = EXCLUDED.longitude;
\ No newline at end of file
@@ -0,0 +1,20 @@
+INSERT INTO fuel_prices (
+    node_id,
+    fuel_type,
+    price_last_updated,
+    price,
+    price_change_effective_timestamp,
+    source,
+    anomaly,
+    anomaly_reference,
+    last_confirmed_at,
+    recorded_at
+)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, CURRENT_TIMESTAMP)
+ON CONFLICT(node_id, fuel_type, price_last_updated) DO UPDATE SET
+    price = EXCLUDED.price,
+    price_change_effective_timestamp = EXCLUDED.price_change_effective_timestamp,
+    source = EXCLUDED.source,
+    anomaly = EXCLUDED.anomaly,
+    anomaly_reference = EXCLUDED.anomaly_reference,
+    last_confirmed_at = GREATEST(COALESCE(fuel_prices.last_confirmed_at, EXCLUDED.last_confirmed_at), EXCLUDED.last_confirmed_at);
\ No newline at end of file
@@ -0,0 +1,15 @@
+INSERT INTO price_quarantine (
+    node_id,
+    fuel_type,
+    price_last_updated,
+    price_change_effective_timestamp,
+    original_price,
+    corrected_price,
+    reason,
+    source,
+    batch_path,
+    batch_number,
+    batch_fetched_at
+)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+ON CONFLICT(node_id, fuel_type, price_last_updated, reason) DO NOTHING;
\ No newline at end of file
@@ -0,0 +1,2 @@
+INSERT INTO station_changes (node_id, attribute, old_value, new_value, source)
+VALUES ($1, $2, $3, $4, $5);
\ No newline at end of file
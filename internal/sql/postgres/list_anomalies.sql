@@ -0,0 +1,16 @@
+SELECT
+    fp.node_id,
+    pfs.trading_name,
+    pfs.postcode,
+    fp.fuel_type,
+    fp.price,
+    fp.price_last_updated,
+    fp.anomaly,
+    fp.anomaly_reference,
+    fp.source
+FROM fuel_prices fp
+LEFT JOIN petrol_filling_stations pfs ON fp.node_id = pfs.node_id
+WHERE fp.anomaly IS NOT NULL
+  AND fp.price_last_updated >= $1
+ORDER BY fp.price_last_updated DESC
+LIMIT $2 OFFSET $3;
\ No newline at end of file
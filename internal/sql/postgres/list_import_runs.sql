@@ -0,0 +1,9 @@
+SELECT id, job, source, triggered_by, status, started_at, finished_at, duration_ms, batches, inserted, dropped, error
+FROM import_runs
+WHERE ($1 = '' OR job = $2)
+  AND ($3 = '' OR source = $4)
+  AND ($5 = '' OR status = $6)
+  AND ($7 = '' OR triggered_by = $8)
+  AND started_at >= $9
+ORDER BY started_at DESC, id DESC
+LIMIT $10 OFFSET $11;
\ No newline at end of file
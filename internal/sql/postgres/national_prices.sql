@@ -0,0 +1,3 @@
+SELECT fuel_type, price
+FROM fuel_price_latest_with_area
+ORDER BY fuel_type, price;
\ No newline at end of file
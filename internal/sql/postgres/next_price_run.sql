@@ -0,0 +1,6 @@
+-- The run of the same price which follows the given time.
+SELECT price_last_updated, price, last_confirmed_at
+FROM fuel_prices
+WHERE node_id = $1 AND fuel_type = $2 AND price_last_updated > $3
+ORDER BY price_last_updated
+LIMIT 1;
\ No newline at end of file
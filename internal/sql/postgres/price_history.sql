@@ -0,0 +1,8 @@
+SELECT
+    fuel_type,
+    price,
+    price_last_updated,
+    price_change_effective_timestamp
+FROM fuel_prices
+WHERE node_id = $1 AND fuel_type = $2
+ORDER BY price_change_effective_timestamp, price_last_updated;
\ No newline at end of file
@@ -0,0 +1,7 @@
+-- The run of the same price in force at the given time, and the latest time
+-- it was confirmed.
+SELECT price_last_updated, price, last_confirmed_at
+FROM fuel_prices
+WHERE node_id = $1 AND fuel_type = $2 AND price_last_updated <= $3
+ORDER BY price_last_updated DESC
+LIMIT 1;
\ No newline at end of file
@@ -0,0 +1,3 @@
+SELECT status
+FROM price_quarantine
+WHERE node_id = $1 AND fuel_type = $2 AND price_last_updated = $3 AND reason = $4;
\ No newline at end of file
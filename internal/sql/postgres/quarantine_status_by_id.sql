@@ -0,0 +1 @@
+SELECT status FROM price_quarantine WHERE id = $1;
\ No newline at end of file
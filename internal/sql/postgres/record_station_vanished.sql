@@ -0,0 +1,4 @@
+INSERT INTO station_changes (node_id, attribute, old_value, new_value, source)
+SELECT node_id, 'active', '1', '0', source
+FROM petrol_filling_stations
+WHERE source = $1 AND NOT inactive AND missed_refreshes >= $2;
\ No newline at end of file
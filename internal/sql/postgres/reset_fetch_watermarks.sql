@@ -0,0 +1 @@
+DELETE FROM fetch_watermarks;
\ No newline at end of file
@@ -0,0 +1,27 @@
+WITH ranked_prices AS (
+  SELECT
+    fp.node_id,
+    fp.fuel_type,
+    fp.price_last_updated,
+    fp.last_confirmed_at,
+    fp.price,
+    fp.price_change_effective_timestamp,
+    fp.anomaly,
+    ROW_NUMBER() OVER (
+      PARTITION BY fp.node_id, fp.fuel_type
+      ORDER BY fp.price_last_updated DESC
+    ) AS price_recency_rank
+  FROM petrol_filling_stations pfs
+  INNER JOIN fuel_prices fp ON pfs.node_id = fp.node_id
+  WHERE pfs.location::geometry && ST_MakeEnvelope($3, $1, $4, $2, 4326)
+)
+SELECT
+  node_id,
+  fuel_type,
+  price_last_updated,
+  last_confirmed_at,
+  price,
+  price_change_effective_timestamp,
+  anomaly
+FROM ranked_prices
+WHERE price_recency_rank <= $5;
\ No newline at end of file
@@ -0,0 +1,28 @@
+-- Stations within a radius, in metres, of a point, using the geography index.
+SELECT
+    node_id,
+    mft_organisation_name,
+    public_phone_number,
+    trading_name,
+    is_same_trading_and_brand_name,
+    brand_name,
+    temporary_closure,
+    permanent_closure,
+    permanent_closure_date,
+    is_motorway_service_station,
+    is_supermarket_service_station,
+    address_line_1,
+    address_line_2,
+    city,
+    country,
+    county,
+    postcode,
+    latitude,
+    longitude,
+    opening_times_json,
+    amenities_json,
+    fuel_types_json,
+    inactive
+FROM petrol_filling_stations
+WHERE ST_DWithin(location, ST_SetSRID(ST_MakePoint($2, $1), 4326)::geography, $3)
+  AND ($4 OR NOT inactive);
\ No newline at end of file
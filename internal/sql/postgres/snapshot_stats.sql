@@ -0,0 +1,2 @@
+SELECT scope, postcode_area, fuel_type, min_price, avg_price, max_price, stddev_price, sample_size
+FROM fuel_price_snapshot_stats;
\ No newline at end of file
@@ -0,0 +1,3 @@
+INSERT INTO import_runs (job, source, triggered_by, started_at)
+VALUES ($1, $2, $3, $4)
+RETURNING id;
\ No newline at end of file
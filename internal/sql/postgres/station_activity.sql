@@ -0,0 +1,13 @@
+SELECT
+    sc.node_id,
+    pfs.trading_name,
+    pfs.postcode,
+    CASE sc.new_value WHEN '0' THEN 'vanished' ELSE 'reappeared' END AS event,
+    sc.source,
+    sc.changed_at
+FROM station_changes sc
+LEFT JOIN petrol_filling_stations pfs ON sc.node_id = pfs.node_id
+WHERE sc.attribute = 'active'
+  AND sc.changed_at >= $1
+ORDER BY sc.changed_at DESC, sc.id DESC
+LIMIT $2 OFFSET $3;
\ No newline at end of file
@@ -0,0 +1,6 @@
+SELECT attribute, old_value, new_value, source, changed_at
+FROM station_changes
+WHERE node_id = $1
+  AND ($2 = '' OR attribute = $3)
+ORDER BY changed_at DESC, id DESC
+LIMIT $4;
\ No newline at end of file
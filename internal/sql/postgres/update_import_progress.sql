@@ -0,0 +1,5 @@
+UPDATE import_runs
+SET batches = batches + 1,
+    inserted = inserted + $1,
+    dropped = dropped + $2
+WHERE id = $3;
\ No newline at end of file
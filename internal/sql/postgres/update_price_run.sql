@@ -0,0 +1,4 @@
+UPDATE fuel_prices
+SET price_last_updated = $1,
+    last_confirmed_at = $2
+WHERE node_id = $3 AND fuel_type = $4 AND price_last_updated = $5;
\ No newline at end of file
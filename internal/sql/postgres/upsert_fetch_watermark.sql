@@ -0,0 +1,6 @@
+INSERT INTO fetch_watermarks (path, last_fetched_at, updated_at)
+VALUES ($1, $2, CURRENT_TIMESTAMP)
+ON CONFLICT(path) DO UPDATE SET
+    last_fetched_at = EXCLUDED.last_fetched_at,
+    updated_at = CURRENT_TIMESTAMP
+WHERE EXCLUDED.last_fetched_at > fetch_watermarks.last_fetched_at;
\ No newline at end of file
@@ -0,0 +1 @@
+VACUUM;
\ No newline at end of file
@@ -0,0 +1,3 @@
+SELECT status
+FROM price_quarantine
+WHERE node_id = ? AND fuel_type = ? AND price_last_updated = ? AND reason = ?;
\ No newline at end of file
@@ -0,0 +1,4 @@
+INSERT INTO station_changes (node_id, attribute, old_value, new_value, source)
+SELECT node_id, 'active', '0', '1', source
+FROM petrol_filling_stations
+WHERE node_id = ? AND inactive = 1;
\ No newline at end of file
@@ -0,0 +1,4 @@
+INSERT INTO station_changes (node_id, attribute, old_value, new_value, source)
+SELECT node_id, 'active', '1', '0', source
+FROM petrol_filling_stations
+WHERE source = ? AND inactive = 0 AND missed_refreshes >= ?;
\ No newline at end of file
@@ -0,0 +1,19 @@
+UPDATE price_quarantine
+SET status = ?, reviewed_at = CURRENT_TIMESTAMP
+WHERE id = ? AND status = 'pending'
+RETURNING
+    id,
+    node_id,
+    fuel_type,
+    price_last_updated,
+    price_change_effective_timestamp,
+    original_price,
+    corrected_price,
+    reason,
+    source,
+    batch_path,
+    batch_number,
+    batch_fetched_at,
+    status,
+    quarantined_at,
+    reviewed_at;
\ No newline at end of file
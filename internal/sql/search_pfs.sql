@@ -20,7 +20,9 @@ SELECT
     longitude,
     opening_times_json,
     amenities_json,
-    fuel_types_json
+    fuel_types_json,
+    inactive
 FROM petrol_filling_stations
 WHERE latitude BETWEEN ? AND ?
-  AND longitude BETWEEN ? AND ?;
\ No newline at end of file
+  AND longitude BETWEEN ? AND ?
+  AND (? OR inactive = 0);
\ No newline at end of file
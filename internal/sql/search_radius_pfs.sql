@@ -0,0 +1,38 @@
+-- Stations within a radius, in metres, of a point. The circle's bounding box
+-- narrows the search using the index, then the haversine distance decides.
+WITH origin AS (
+    SELECT ? AS latitude, ? AS longitude, ? AS radius
+)
+SELECT
+    pfs.node_id,
+    pfs.mft_organisation_name,
+    pfs.public_phone_number,
+    pfs.trading_name,
+    pfs.is_same_trading_and_brand_name,
+    pfs.brand_name,
+    pfs.temporary_closure,
+    pfs.permanent_closure,
+    pfs.permanent_closure_date,
+    pfs.is_motorway_service_station,
+    pfs.is_supermarket_service_station,
+    pfs.address_line_1,
+    pfs.address_line_2,
+    pfs.city,
+    pfs.country,
+    pfs.county,
+    pfs.postcode,
+    pfs.latitude,
+    pfs.longitude,
+    pfs.opening_times_json,
+    pfs.amenities_json,
+    pfs.fuel_types_json,
+    pfs.inactive
+FROM origin o
+CROSS JOIN petrol_filling_stations pfs
+WHERE pfs.latitude BETWEEN o.latitude - degrees(o.radius / 6371000.0) AND o.latitude + degrees(o.radius / 6371000.0)
+  AND pfs.longitude BETWEEN o.longitude - degrees(o.radius / (6371000.0 * cos(radians(o.latitude)))) AND o.longitude + degrees(o.radius / (6371000.0 * cos(radians(o.latitude))))
+  AND 2 * 6371000.0 * asin(sqrt(
+        pow(sin(radians(pfs.latitude - o.latitude) / 2), 2) +
+        cos(radians(o.latitude)) * cos(radians(pfs.latitude)) * pow(sin(radians(pfs.longitude - o.longitude) / 2), 2)
+      )) <= o.radius
+  AND (? OR pfs.inactive = 0);
\ No newline at end of file
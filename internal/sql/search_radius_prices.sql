@@ -0,0 +1,38 @@
+-- Prices for the stations within a radius, in metres, of a point, found as
+-- in search_radius_pfs.sql.
+WITH origin AS (
+  SELECT ? AS latitude, ? AS longitude, ? AS radius
+),
+ranked_prices AS (
+  SELECT
+    fp.node_id,
+    fp.fuel_type,
+    fp.price_last_updated,
+    fp.last_confirmed_at,
+    fp.price,
+    fp.price_change_effective_timestamp,
+    fp.anomaly,
+    ROW_NUMBER() OVER (
+      PARTITION BY fp.node_id, fp.fuel_type
+      ORDER BY fp.price_last_updated DESC
+    ) AS price_recency_rank
+  FROM origin o
+  CROSS JOIN petrol_filling_stations pfs
+  INNER JOIN fuel_prices fp ON pfs.node_id = fp.node_id
+  WHERE pfs.latitude BETWEEN o.latitude - degrees(o.radius / 6371000.0) AND o.latitude + degrees(o.radius / 6371000.0)
+    AND pfs.longitude BETWEEN o.longitude - degrees(o.radius / (6371000.0 * cos(radians(o.latitude)))) AND o.longitude + degrees(o.radius / (6371000.0 * cos(radians(o.latitude))))
+    AND 2 * 6371000.0 * asin(sqrt(
+          pow(sin(radians(pfs.latitude - o.latitude) / 2), 2) +
+          cos(radians(o.latitude)) * cos(radians(pfs.latitude)) * pow(sin(radians(pfs.longitude - o.longitude) / 2), 2)
+        )) <= o.radius
+)
+SELECT
+  node_id,
+  fuel_type,
+  price_last_updated,
+  last_confirmed_at,
+  price,
+  price_change_effective_timestamp,
+  anomaly
+FROM ranked_prices
+WHERE price_recency_rank <= ?;
\ No newline at end of file
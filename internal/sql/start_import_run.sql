@@ -0,0 +1,3 @@
+INSERT INTO import_runs (job, source, triggered_by, started_at)
+VALUES (?, ?, ?, ?)
+RETURNING id;
\ No newline at end of file
@@ -0,0 +1,6 @@
+SELECT attribute, old_value, new_value, source, changed_at
+FROM station_changes
+WHERE node_id = ?
+  AND (? = '' OR attribute = ?)
+ORDER BY changed_at DESC, id DESC
+LIMIT ?;
\ No newline at end of file
@@ -0,0 +1,5 @@
+UPDATE import_runs
+SET batches = batches + 1,
+    inserted = inserted + ?,
+    dropped = dropped + ?
+WHERE id = ?;
\ No newline at end of file
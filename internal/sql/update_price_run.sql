@@ -0,0 +1,4 @@
+UPDATE fuel_prices
+SET price_last_updated = ?,
+    last_confirmed_at = ?
+WHERE node_id = ? AND fuel_type = ? AND price_last_updated = ?;
\ No newline at end of file
@@ -0,0 +1,6 @@
+INSERT INTO fetch_watermarks (path, last_fetched_at, updated_at)
+VALUES (?, ?, CURRENT_TIMESTAMP)
+ON CONFLICT(path) DO UPDATE SET
+    last_fetched_at = EXCLUDED.last_fetched_at,
+    updated_at = CURRENT_TIMESTAMP
+WHERE julianday(EXCLUDED.last_fetched_at) > julianday(fetch_watermarks.last_fetched_at);
\ No newline at end of file
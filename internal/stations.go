@@ -0,0 +1,69 @@
+package internal
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/rm-hull/fuel-prices-api/internal/models"
+)
+
+// DefaultInactiveAfterMissed is how many full refreshes in a row a station
+// can be missing from before it is marked inactive. Fuel Finder is fully
+// refreshed once a day, by the cron job scheduled by StartCron.
+const DefaultInactiveAfterMissed = 3
+
+// LoadInactiveThreshold reads FUEL_PRICES_INACTIVE_AFTER_MISSED, falling
+// back to DefaultInactiveAfterMissed when it is unset.
+func LoadInactiveThreshold() (int, error) {
+	value := os.Getenv("FUEL_PRICES_INACTIVE_AFTER_MISSED")
+	if value == "" {
+		return DefaultInactiveAfterMissed, nil
+	}
+	threshold, err := strconv.Atoi(value)
+	if err != nil || threshold < 1 {
+		return 0, fmt.Errorf("invalid FUEL_PRICES_INACTIVE_AFTER_MISSED %q: must be a positive integer", value)
+	}
+	return threshold, nil
+}
+
+// RefreshStations fetches the filling stations from a data source into the
+// repository. When every batch fetched was part of a full refresh, stations
+// which were not seen are then counted as having missed it, and any which
+// have missed threshold refreshes in a row are marked inactive.
+func RefreshStations(ctx context.Context, source DataSource, repo FuelPricesRepository, threshold int) (int, int, error) {
+	started := time.Now().UTC().Truncate(time.Second)
+	full := true
+	sources := make(map[string]struct{})
+
+	numPFS, dropped, err := source.GetFillingStations(ctx, func(ctx context.Context, batch []models.PetrolFillingStation) (int, int, error) {
+		if info, ok := BatchInfoFrom(ctx); !ok || !info.Full {
+			full = false
+		}
+		for _, pfs := range batch {
+			name := pfs.Source
+			if name == "" {
+				name = models.SourceFuelFinder
+			}
+			sources[name] = struct{}{}
+		}
+		return repo.InsertPFS(ctx, batch)
+	})
+	if err != nil || !full {
+		return numPFS, dropped, err
+	}
+
+	for name := range sources {
+		deactivated, err := repo.MarkMissingStations(ctx, name, started, threshold)
+		if err != nil {
+			return numPFS, dropped, err
+		}
+		if deactivated > 0 {
+			log.Printf("Marked %d stations from %s as inactive", deactivated, name)
+		}
+	}
+	return numPFS, dropped, nil
+}
@@ -3,6 +3,7 @@ package stats
 import (
 	"fmt"
 	"math"
+	"slices"
 	"time"
 
 	"github.com/rm-hull/fuel-prices-api/internal/models"
@@ -10,7 +11,10 @@ import (
 
 const stalenessThreshold = 14 * 24 * time.Hour
 
-func Derive(results []models.SearchResult, bucketSize int) *models.SearchStatistics {
+// Derive summarises the fresh prices in the search results. Prices flagged as
+// anomalous are skipped in favour of the station's next most recent price,
+// unless includeAnomalies is set.
+func Derive(results []models.SearchResult, bucketSize int, includeAnomalies bool) *models.SearchStatistics {
 	if bucketSize <= 0 {
 		bucketSize = 3
 	}
@@ -33,12 +37,15 @@ func Derive(results []models.SearchResult, bucketSize int) *models.SearchStatist
 	for _, result := range results {
 		hasFreshPrice := false
 		for fuelType, priceInfos := range result.FuelPrices {
-			if len(priceInfos) == 0 {
+			// Use the most recent price (first in slice)
+			index := 0
+			if !includeAnomalies {
+				index = slices.IndexFunc(priceInfos, func(p models.PriceInfo) bool { return p.Anomaly == "" })
+			}
+			if index < 0 || index >= len(priceInfos) {
 				continue
 			}
-
-			// Use the most recent price (first in slice)
-			priceInfo := priceInfos[0]
+			priceInfo := priceInfos[index]
 			if now.Sub(priceInfo.UpdatedOn) > stalenessThreshold {
 				continue
 			}
@@ -107,3 +114,21 @@ func Derive(results []models.SearchResult, bucketSize int) *models.SearchStatist
 
 	return stats
 }
+
+// Percentile returns the p-th percentile (0-100) of the sorted values, using
+// linear interpolation between the closest ranks.
+func Percentile(sorted []float64, p float64) float64 {
+	if len(sorted) == 0 {
+		return math.NaN()
+	}
+	rank := p / 100 * float64(len(sorted)-1)
+	lower := int(math.Floor(rank))
+	upper := int(math.Ceil(rank))
+	if lower < 0 {
+		return sorted[0]
+	}
+	if upper >= len(sorted) {
+		return sorted[len(sorted)-1]
+	}
+	return sorted[lower] + (rank-float64(lower))*(sorted[upper]-sorted[lower])
+}
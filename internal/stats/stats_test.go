@@ -1,6 +1,7 @@
 package stats
 
 import (
+	"math"
 	"testing"
 	"time"
 
@@ -32,10 +33,51 @@ func TestDerive_StalePriceFiltering(t *testing.T) {
 		},
 	}
 
-	stats := Derive(results, 3)
+	stats := Derive(results, 3, false)
 
 	// After filtering, the stale station should be ignored.
 	// Lowest price should be 140.0, not 130.0.
 	assert.Equal(t, 140.0, stats.LowestPrice["E10"])
 	assert.Equal(t, []string{"FreshStation"}, stats.CheapestPfs["E10"])
 }
+
+func TestDerive_AnomalyFiltering(t *testing.T) {
+	now := time.Now().UTC()
+
+	results := []models.SearchResult{
+		{
+			PetrolFillingStation: models.PetrolFillingStation{NodeId: "Station1"},
+			FuelPrices: map[string][]models.PriceInfo{
+				"E10": {
+					{Price: 14.0, UpdatedOn: now, Anomaly: models.AnomalyJump},
+					{Price: 141.0, UpdatedOn: now.Add(-time.Hour)},
+				},
+			},
+		},
+		{
+			PetrolFillingStation: models.PetrolFillingStation{NodeId: "Station2"},
+			FuelPrices: map[string][]models.PriceInfo{
+				"E10": {
+					{Price: 143.0, UpdatedOn: now},
+				},
+			},
+		},
+	}
+
+	stats := Derive(results, 3, false)
+	assert.Equal(t, 141.0, stats.LowestPrice["E10"])
+	assert.Equal(t, []string{"Station1"}, stats.CheapestPfs["E10"])
+
+	stats = Derive(results, 3, true)
+	assert.Equal(t, 14.0, stats.LowestPrice["E10"])
+}
+
+func TestPercentile(t *testing.T) {
+	sorted := []float64{100, 110, 120, 130, 140}
+
+	assert.Equal(t, 100.0, Percentile(sorted, 0))
+	assert.Equal(t, 120.0, Percentile(sorted, 50))
+	assert.Equal(t, 140.0, Percentile(sorted, 100))
+	assert.InDelta(t, 101.6, Percentile(sorted, 4), 0.001)
+	assert.True(t, math.IsNaN(Percentile(nil, 50)))
+}
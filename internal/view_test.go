@@ -17,7 +17,7 @@ func TestFuelPriceSnapshotStatsView(t *testing.T) {
 	// we'll either need to copy it or make it accessible.
 	// For now, I'll copy the minimal setup.
 	repo := setupTestDB(t)
-	sqliteRepo := repo.(*sqliteRepository)
+	sqliteRepo := repo.(*sqlRepository)
 	db := sqliteRepo.db
 
 	now := time.Now().UTC().Truncate(time.Second)
@@ -31,7 +31,7 @@ func TestFuelPriceSnapshotStatsView(t *testing.T) {
 		{NodeId: "M1", Location: models.Location{Postcode: "M1 1AA"}},
 		{NodeId: "O1", Location: models.Location{Postcode: "OX1 1AA"}}, // Oxford
 	}
-	_, _, err := repo.InsertPFS(stations)
+	_, _, err := repo.InsertPFS(t.Context(), stations)
 	require.NoError(t, err)
 
 	yesterday := now.Add(-24 * time.Hour)
@@ -41,7 +41,7 @@ func TestFuelPriceSnapshotStatsView(t *testing.T) {
 			NodeId: "L1",
 			FuelPrices: []models.FuelPrice{
 				{FuelType: "E10", Price: 140.0, PriceLastUpdated: now},
-				{FuelType: "B7", Price: 150.0, PriceLastUpdated: now},
+				{FuelType: "B7_STANDARD", Price: 150.0, PriceLastUpdated: now},
 			},
 		},
 		{
@@ -68,18 +68,19 @@ func TestFuelPriceSnapshotStatsView(t *testing.T) {
 		{
 			NodeId: "L1",
 			FuelPrices: []models.FuelPrice{
-				// Very old price, should be filtered out
-				{FuelType: "DIESEL", Price: 100.0, PriceLastUpdated: now.AddDate(0, 0, -15)},
+				// Very old price, should be filtered out. HVO is a canonical
+				// code with no other prices, so nothing else can hide it.
+				{FuelType: "HVO", Price: 100.0, PriceLastUpdated: now.AddDate(0, 0, -15)},
 			},
 		},
 	}
-	_, _, err = repo.InsertPrices(prices)
+	_, _, err = repo.InsertPrices(t.Context(), prices)
 	require.NoError(t, err)
 
-	// Expected stats (including Oxford but excluding L1 DIESEL):
+	// Expected stats (including Oxford but excluding L1 HVO):
 	// National E10: (140.0 + 144.0 + 150.0 + 146.0) / 4 = 145.0
-	// National B7: 150.0
-	// National DIESEL: should be empty or not present
+	// National B7_STANDARD: 150.0
+	// National HVO: should be empty or not present
 
 	type StatsRow struct {
 		Scope        string
@@ -127,10 +128,18 @@ func TestFuelPriceSnapshotStatsView(t *testing.T) {
 	}
 	assert.True(t, foundNationalE10)
 
-	// Verify DIESEL is NOT present
+	// Verify National B7_STANDARD, and that HVO is NOT present
+	foundNationalB7 := false
 	for _, r := range results {
-		assert.NotEqual(t, "DIESEL", r.FuelType, "DIESEL should have been filtered out")
+		assert.NotEqual(t, "HVO", r.FuelType, "HVO should have been filtered out")
+		if r.Scope == "National" && r.FuelType == "B7_STANDARD" {
+			foundNationalB7 = true
+			assert.Equal(t, 150.0, r.MinPrice)
+			assert.Equal(t, 150.0, r.MaxPrice)
+			assert.Equal(t, 1, r.SampleSize)
+		}
 	}
+	assert.True(t, foundNationalB7)
 
 	// Verify LS E10
 	foundLSE10 := false
@@ -167,7 +176,7 @@ func TestFuelPriceSnapshotStatsView(t *testing.T) {
 
 func TestFuelPriceDistributionStatsView(t *testing.T) {
 	repo := setupTestDB(t)
-	sqliteRepo := repo.(*sqliteRepository)
+	sqliteRepo := repo.(*sqlRepository)
 	db := sqliteRepo.db
 
 	now := time.Now().UTC().Truncate(time.Second)
@@ -177,7 +186,7 @@ func TestFuelPriceDistributionStatsView(t *testing.T) {
 		{NodeId: "L2", Location: models.Location{Postcode: "LS2 1BB"}},
 		{NodeId: "M1", Location: models.Location{Postcode: "M1 1AA"}},
 	}
-	_, _, err := repo.InsertPFS(stations)
+	_, _, err := repo.InsertPFS(t.Context(), stations)
 	require.NoError(t, err)
 
 	prices := []models.ForecourtPrices{
@@ -200,7 +209,7 @@ func TestFuelPriceDistributionStatsView(t *testing.T) {
 			},
 		},
 	}
-	_, _, err = repo.InsertPrices(prices)
+	_, _, err = repo.InsertPrices(t.Context(), prices)
 	require.NoError(t, err)
 
 	type DistributionRow struct {
@@ -256,7 +265,7 @@ func TestFuelPriceMetricsCollector(t *testing.T) {
 	stations := []models.PetrolFillingStation{
 		{NodeId: "L1", Location: models.Location{Postcode: "LS1 1AA"}},
 	}
-	_, _, err := repo.InsertPFS(stations)
+	_, _, err := repo.InsertPFS(t.Context(), stations)
 	require.NoError(t, err)
 
 	prices := []models.ForecourtPrices{
@@ -267,7 +276,7 @@ func TestFuelPriceMetricsCollector(t *testing.T) {
 			},
 		},
 	}
-	_, _, err = repo.InsertPrices(prices)
+	_, _, err = repo.InsertPrices(t.Context(), prices)
 	require.NoError(t, err)
 
 	registry := prometheus.NewRegistry()
@@ -296,3 +305,70 @@ func TestFuelPriceMetricsCollector(t *testing.T) {
 	}
 	assert.True(t, foundDist)
 }
+
+func TestUKStatsExcludeOtherCountries(t *testing.T) {
+	repo := setupTestDB(t)
+	sqliteRepo := repo.(*sqlRepository)
+
+	now := time.Now().UTC().Truncate(time.Second)
+
+	stations := []models.PetrolFillingStation{
+		{NodeId: "L1", Location: models.Location{Postcode: "LS1 1AA"}},
+		{NodeId: "M1", Location: models.Location{Postcode: "M1 1AA"}},
+	}
+	_, _, err := repo.InsertPFS(t.Context(), stations)
+	require.NoError(t, err)
+
+	prices := []models.ForecourtPrices{
+		{NodeId: "L1", FuelPrices: []models.FuelPrice{{FuelType: "E10", Price: 140.0, PriceLastUpdated: now}}},
+		{NodeId: "M1", FuelPrices: []models.FuelPrice{{FuelType: "E10", Price: 144.0, PriceLastUpdated: now}}},
+	}
+	_, _, err = repo.InsertPrices(t.Context(), prices)
+	require.NoError(t, err)
+
+	snapshot, err := sqliteRepo.snapshotQuery()
+	require.NoError(t, err)
+	distribution, err := sqliteRepo.distributionQuery()
+	require.NoError(t, err)
+	counts, err := sqliteRepo.fuelTypeCountsQuery()
+	require.NoError(t, err)
+	areas, err := sqliteRepo.areaPriceStatsQuery()
+	require.NoError(t, err)
+
+	// Euro cent prices, with numeric postcodes that would otherwise all fall
+	// into one bogus, empty postcode area.
+	foreign := []models.PetrolFillingStation{
+		{NodeId: "prix-carburants:1", Location: models.Location{Country: "France", Postcode: "75001"}, Source: "prix-carburants", CountryCode: "FR"},
+		{NodeId: "tankerkoenig:1", Location: models.Location{Country: "Germany", Postcode: "10115"}, Source: "tankerkoenig", CountryCode: "DE"},
+	}
+	_, _, err = repo.InsertPFS(t.Context(), foreign)
+	require.NoError(t, err)
+
+	foreignPrices := []models.ForecourtPrices{
+		{NodeId: "prix-carburants:1", Source: "prix-carburants", FuelPrices: []models.FuelPrice{
+			{FuelType: "E10", Price: 175.9, PriceLastUpdated: now},
+			{FuelType: "B7_STANDARD", Price: 165.9, PriceLastUpdated: now},
+		}},
+		{NodeId: "tankerkoenig:1", Source: "tankerkoenig", FuelPrices: []models.FuelPrice{
+			{FuelType: "E10", Price: 169.9, PriceLastUpdated: now},
+		}},
+	}
+	_, _, err = repo.InsertPrices(t.Context(), foreignPrices)
+	require.NoError(t, err)
+
+	afterSnapshot, err := sqliteRepo.snapshotQuery()
+	require.NoError(t, err)
+	assert.Equal(t, snapshot.Snapshot, afterSnapshot.Snapshot)
+
+	afterDistribution, err := sqliteRepo.distributionQuery()
+	require.NoError(t, err)
+	assert.Equal(t, distribution.Distribution, afterDistribution.Distribution)
+
+	afterCounts, err := sqliteRepo.fuelTypeCountsQuery()
+	require.NoError(t, err)
+	assert.Equal(t, counts, afterCounts)
+
+	afterAreas, err := sqliteRepo.areaPriceStatsQuery()
+	require.NoError(t, err)
+	assert.Equal(t, areas, afterAreas)
+}
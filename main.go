@@ -1,9 +1,15 @@
 package main
 
 import (
+	"context"
 	"log"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
 	"github.com/rm-hull/fuel-prices-api/cmd"
+	"github.com/rm-hull/fuel-prices-api/internal/mockupstream"
 
 	"github.com/spf13/cobra"
 )
@@ -12,9 +18,18 @@ func main() {
 	var err error
 	var dbPath string
 	var filePath string
+	var fromDir string
+	var cmaPath string
+	var postcodesPath string
 	var port int
 	var debug bool
 	var fullRefresh bool
+	var dryRun bool
+	var sourceName string
+	var fixturePath string
+	var mockPort int
+	var numStations int
+	var mockCfg mockupstream.Config
 
 	rootCmd := &cobra.Command{
 		Use:  "fuel-prices",
@@ -24,22 +39,102 @@ func main() {
 	apiServerCmd := &cobra.Command{
 		Use:   "api-server [--db <path>] [--port <port>] [--debug]",
 		Short: "Start HTTP API server",
-		Run: func(_ *cobra.Command, _ []string) {
-			if err = cmd.ApiServer(dbPath, port, fullRefresh, debug); err != nil {
+		Run: func(c *cobra.Command, _ []string) {
+			if err = cmd.ApiServer(c.Context(), dbPath, port, fullRefresh, debug); err != nil {
 				log.Fatalf("API Server failed: %v", err)
 			}
 		},
 	}
 
 	importCmd := &cobra.Command{
-		Use:   "import [--db <path>]",
-		Short: "Perform one-off import of fuel prices and filling stations from the GOV.UK API",
-		Run: func(_ *cobra.Command, _ []string) {
-			if err := cmd.Import(dbPath); err != nil {
+		Use:   "import [--db <path>] [--source <name>] [--dry-run]",
+		Short: "Perform one-off import of fuel prices and filling stations from the GOV.UK API and any other configured data sources",
+		Run: func(c *cobra.Command, _ []string) {
+			if err := cmd.Import(c.Context(), dbPath, sourceName, dryRun); err != nil {
 				log.Fatalf("Import failed: %v", err)
 			}
 		},
 	}
+	importCmd.Flags().StringVar(&sourceName, "source", "", "Only import from the named data source, e.g. fuel-finder (default: all)")
+	importCmd.Flags().BoolVar(&dryRun, "dry-run", false, "Fetch and cleanse, then print a summary of changes against the database without writing anything")
+
+	importCmaCmd := &cobra.Command{
+		Use:   "import-cma --file <path|dir> [--db <path>]",
+		Short: "Import retailer fuel price feeds in the CMA open-data JSON format from local files",
+		Run: func(c *cobra.Command, _ []string) {
+			if err := cmd.ImportCMA(c.Context(), dbPath, cmaPath); err != nil {
+				log.Fatalf("CMA import failed: %v", err)
+			}
+		},
+	}
+	importCmaCmd.Flags().StringVar(&cmaPath, "file", "", "Path to a CMA JSON feed, or a directory of them")
+	_ = importCmaCmd.MarkFlagRequired("file")
+
+	importPostcodesCmd := &cobra.Command{
+		Use:   "import-postcodes --file <path> [--db <path>]",
+		Short: "Import postcode centroids from the ONS Postcode Directory CSV, used to validate station coordinates",
+		Run: func(c *cobra.Command, _ []string) {
+			if err := cmd.ImportPostcodes(c.Context(), dbPath, postcodesPath); err != nil {
+				log.Fatalf("Postcode import failed: %v", err)
+			}
+		},
+	}
+	importPostcodesCmd.Flags().StringVar(&postcodesPath, "file", "", "Path to a CSV with postcode, latitude and longitude columns")
+	_ = importPostcodesCmd.MarkFlagRequired("file")
+
+	cleanseCmd := &cobra.Command{
+		Use:   "cleanse [--db <path>] [--dry-run]",
+		Short: "Correct or delete implausible historical fuel prices, recording every change in an audit table",
+		Run: func(c *cobra.Command, _ []string) {
+			if err := cmd.Cleanse(c.Context(), dbPath, dryRun); err != nil {
+				log.Fatalf("Cleanse failed: %v", err)
+			}
+		},
+	}
+	cleanseCmd.Flags().BoolVar(&dryRun, "dry-run", false, "Print how many prices each rule would change without writing anything")
+
+	compactCmd := &cobra.Command{
+		Use:   "compact [--db <path>] [--dry-run]",
+		Short: "Fold runs of repeated fuel prices into one row per price change, keeping when each was last confirmed",
+		Run: func(c *cobra.Command, _ []string) {
+			if err := cmd.Compact(c.Context(), dbPath, dryRun); err != nil {
+				log.Fatalf("Compact failed: %v", err)
+			}
+		},
+	}
+	compactCmd.Flags().BoolVar(&dryRun, "dry-run", false, "Report how many rows would be removed without changing anything")
+
+	replayCmd := &cobra.Command{
+		Use:   "replay --from <dir> [--db <path>]",
+		Short: "Replay archived raw GOV.UK API responses into the database",
+		Run: func(c *cobra.Command, _ []string) {
+			if err := cmd.Replay(c.Context(), dbPath, fromDir); err != nil {
+				log.Fatalf("Replay failed: %v", err)
+			}
+		},
+	}
+	replayCmd.Flags().StringVar(&fromDir, "from", "./data/archive", "Path to directory of archived raw responses")
+
+	mockUpstreamCmd := &cobra.Command{
+		Use:   "mock-upstream [--port <port>] [--fixture <path>]",
+		Short: "Start a mock GOV.UK Fuel Finder API server for local development",
+		Run: func(c *cobra.Command, _ []string) {
+			if err := cmd.MockUpstream(c.Context(), mockPort, fixturePath, numStations, mockCfg); err != nil {
+				log.Fatalf("Mock upstream failed: %v", err)
+			}
+		},
+	}
+	mockUpstreamCmd.Flags().IntVar(&mockPort, "port", 8090, "Port to run mock upstream on")
+	mockUpstreamCmd.Flags().StringVar(&fixturePath, "fixture", "", "Path to JSON fixture of stations and prices (default: generate data)")
+	mockUpstreamCmd.Flags().IntVar(&numStations, "stations", 1000, "Number of stations to generate when no fixture is given")
+	mockUpstreamCmd.Flags().Uint64Var(&mockCfg.Seed, "seed", 1, "Random seed for generated data and injected faults")
+	mockUpstreamCmd.Flags().IntVar(&mockCfg.BatchSize, "batch-size", 500, "Number of records per batch")
+	mockUpstreamCmd.Flags().BoolVar(&mockCfg.WithMetaData, "with-metadata", false, "Wrap batches in an envelope with batch metadata")
+	mockUpstreamCmd.Flags().DurationVar(&mockCfg.TokenTTL, "token-ttl", time.Hour, "Lifetime of issued access tokens")
+	mockUpstreamCmd.Flags().Float64Var(&mockCfg.ErrorRate, "error-rate", 0, "Probability (0..1) of a batch request failing with HTTP 503")
+	mockUpstreamCmd.Flags().Float64Var(&mockCfg.MalformedRate, "malformed-rate", 0, "Probability (0..1) of a batch response containing malformed JSON")
+	mockUpstreamCmd.Flags().StringVar(&mockCfg.ClientId, "client-id", "", "Only accept this client ID (default: accept any credentials)")
+	mockUpstreamCmd.Flags().StringVar(&mockCfg.ClientSecret, "client-secret", "", "Only accept this client secret (default: accept any credentials)")
 
 	updateFaviconsCmd := &cobra.Command{
 		Use:   "favicons [--file <path>]",
@@ -58,10 +153,20 @@ func main() {
 
 	rootCmd.AddCommand(apiServerCmd)
 	rootCmd.AddCommand(importCmd)
+	rootCmd.AddCommand(importCmaCmd)
+	rootCmd.AddCommand(importPostcodesCmd)
+	rootCmd.AddCommand(cleanseCmd)
+	rootCmd.AddCommand(compactCmd)
+	rootCmd.AddCommand(replayCmd)
+	rootCmd.AddCommand(mockUpstreamCmd)
 	rootCmd.AddCommand(updateFaviconsCmd)
-	rootCmd.PersistentFlags().StringVar(&dbPath, "db", "./data/fuel_prices.db", "Path to fuel-prices SQLite database")
+	rootCmd.PersistentFlags().StringVar(&dbPath, "db", "./data/fuel_prices.db", "Path to fuel-prices SQLite database, or a postgres:// DSN for PostgreSQL/PostGIS")
+
+	// Cancelled on SIGINT/SIGTERM, so that in-flight imports are aborted cleanly
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
 
-	if err = rootCmd.Execute(); err != nil {
+	if err = rootCmd.ExecuteContext(ctx); err != nil {
 		log.Fatal(err)
 	}
 }
@@ -0,0 +1,8 @@
+-- Persist the incremental fetch watermark for each upstream path, so that
+-- restarts continue from the last successful fetch rather than refetching
+-- the whole national dataset.
+CREATE TABLE IF NOT EXISTS fetch_watermarks (
+    path TEXT PRIMARY KEY,
+    last_fetched_at DATETIME NOT NULL,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
@@ -0,0 +1,5 @@
+ALTER TABLE fuel_prices
+DROP COLUMN source;
+
+ALTER TABLE petrol_filling_stations
+DROP COLUMN source;
\ No newline at end of file
@@ -0,0 +1,8 @@
+-- Record which feed each station and price came from, so that rows imported
+-- from other sources (e.g. retailers' CMA open-data feeds) can be told apart
+-- from the GOV.UK Fuel Finder API.
+ALTER TABLE petrol_filling_stations
+ADD COLUMN source TEXT NOT NULL DEFAULT 'fuel-finder';
+
+ALTER TABLE fuel_prices
+ADD COLUMN source TEXT NOT NULL DEFAULT 'fuel-finder';
\ No newline at end of file
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_price_quarantine_status;
+DROP TABLE IF EXISTS price_quarantine;
\ No newline at end of file
@@ -0,0 +1,22 @@
+-- Keep the prices which cleansing dropped or rescaled, together with where they
+-- came from, so that they can be reviewed and either promoted into fuel_prices
+-- or rejected.
+CREATE TABLE IF NOT EXISTS price_quarantine (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    node_id TEXT NOT NULL,
+    fuel_type TEXT NOT NULL,
+    price_last_updated DATETIME NOT NULL,
+    price_change_effective_timestamp DATETIME,
+    original_price REAL NOT NULL,
+    corrected_price REAL NOT NULL,
+    reason TEXT NOT NULL,
+    source TEXT NOT NULL DEFAULT 'fuel-finder',
+    batch_path TEXT,
+    batch_number INTEGER,
+    batch_fetched_at DATETIME,
+    status TEXT NOT NULL DEFAULT 'pending',
+    quarantined_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    reviewed_at DATETIME,
+    UNIQUE (node_id, fuel_type, price_last_updated, reason)
+);
+CREATE INDEX IF NOT EXISTS idx_price_quarantine_status ON price_quarantine(status, quarantined_at);
\ No newline at end of file
@@ -0,0 +1,38 @@
+-- Flag prices which look anomalous against the station's previous price or
+-- its postcode area, and keep them out of the stats views. The reference is
+-- the price the flagged one was compared with.
+ALTER TABLE fuel_prices
+ADD COLUMN anomaly TEXT;
+
+ALTER TABLE fuel_prices
+ADD COLUMN anomaly_reference REAL;
+
+CREATE INDEX IF NOT EXISTS idx_fuel_prices_anomaly ON fuel_prices(price_last_updated) WHERE anomaly IS NOT NULL;
+
+DROP VIEW IF EXISTS fuel_price_latest_with_area;
+CREATE VIEW fuel_price_latest_with_area AS
+WITH latest_prices_ranked AS (
+    SELECT
+        node_id,
+        fuel_type,
+        price,
+        ROW_NUMBER() OVER (PARTITION BY node_id, fuel_type ORDER BY price_last_updated DESC) as rn
+    FROM fuel_prices
+    WHERE price_last_updated >= datetime('now', '-14 days')
+      AND anomaly IS NULL
+),
+latest_snapshot AS (
+    SELECT
+        lpr.node_id,
+        lpr.fuel_type,
+        lpr.price,
+        pfs.postcode
+    FROM latest_prices_ranked lpr
+    JOIN petrol_filling_stations pfs ON lpr.node_id = pfs.node_id
+    WHERE lpr.rn = 1
+)
+SELECT
+    fuel_type,
+    price,
+    UPPER(SUBSTR(TRIM(postcode), 1, LENGTH(TRIM(postcode)) - LENGTH(LTRIM(TRIM(postcode), 'ABCDEFGHIJKLMNOPQRSTUVWXYZ')))) as postcode_area
+FROM latest_snapshot;
\ No newline at end of file
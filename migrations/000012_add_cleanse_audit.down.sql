@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_cleanse_audit_run;
+DROP TABLE IF EXISTS cleanse_audit;
\ No newline at end of file
@@ -0,0 +1,17 @@
+-- Record every fuel_prices row changed by the cleanse command, so that each
+-- correction can be traced and, if need be, put back by hand. A NULL
+-- price_after means the row was deleted.
+CREATE TABLE IF NOT EXISTS cleanse_audit (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    run_id TEXT NOT NULL,
+    rule TEXT NOT NULL,
+    node_id TEXT NOT NULL,
+    fuel_type TEXT NOT NULL,
+    price_last_updated DATETIME NOT NULL,
+    price_change_effective_timestamp DATETIME,
+    source TEXT,
+    price_before REAL NOT NULL,
+    price_after REAL,
+    cleansed_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX IF NOT EXISTS idx_cleanse_audit_run ON cleanse_audit(run_id);
\ No newline at end of file
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_station_changes_node;
+DROP TABLE IF EXISTS station_changes;
\ No newline at end of file
@@ -0,0 +1,13 @@
+-- Log every change to a station's attributes made by an import, so that
+-- rebrands, closures and moves can be traced after the station row has been
+-- overwritten. Values are stored as text; NULL means the attribute was unset.
+CREATE TABLE IF NOT EXISTS station_changes (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    node_id TEXT NOT NULL,
+    attribute TEXT NOT NULL,
+    old_value TEXT,
+    new_value TEXT,
+    source TEXT NOT NULL DEFAULT 'fuel-finder',
+    changed_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX IF NOT EXISTS idx_station_changes_node ON station_changes(node_id, changed_at);
\ No newline at end of file
@@ -0,0 +1,42 @@
+-- Track when each station last appeared in a full refresh of its source, so
+-- that stations which drop out of the feed can be marked inactive and left
+-- out of search and the stats views.
+ALTER TABLE petrol_filling_stations
+ADD COLUMN last_seen_at DATETIME;
+
+ALTER TABLE petrol_filling_stations
+ADD COLUMN missed_refreshes INTEGER NOT NULL DEFAULT 0;
+
+ALTER TABLE petrol_filling_stations
+ADD COLUMN inactive BOOLEAN NOT NULL DEFAULT 0;
+
+CREATE INDEX IF NOT EXISTS idx_petrol_source_last_seen ON petrol_filling_stations(source, last_seen_at);
+
+DROP VIEW IF EXISTS fuel_price_latest_with_area;
+CREATE VIEW fuel_price_latest_with_area AS
+WITH latest_prices_ranked AS (
+    SELECT
+        node_id,
+        fuel_type,
+        price,
+        ROW_NUMBER() OVER (PARTITION BY node_id, fuel_type ORDER BY price_last_updated DESC) as rn
+    FROM fuel_prices
+    WHERE price_last_updated >= datetime('now', '-14 days')
+      AND anomaly IS NULL
+),
+latest_snapshot AS (
+    SELECT
+        lpr.node_id,
+        lpr.fuel_type,
+        lpr.price,
+        pfs.postcode
+    FROM latest_prices_ranked lpr
+    JOIN petrol_filling_stations pfs ON lpr.node_id = pfs.node_id
+    WHERE lpr.rn = 1
+      AND pfs.inactive = 0
+)
+SELECT
+    fuel_type,
+    price,
+    UPPER(SUBSTR(TRIM(postcode), 1, LENGTH(TRIM(postcode)) - LENGTH(LTRIM(TRIM(postcode), 'ABCDEFGHIJKLMNOPQRSTUVWXYZ')))) as postcode_area
+FROM latest_snapshot;
\ No newline at end of file
@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS idx_petrol_coordinate_issue;
+ALTER TABLE petrol_filling_stations DROP COLUMN original_longitude;
+ALTER TABLE petrol_filling_stations DROP COLUMN original_latitude;
+ALTER TABLE petrol_filling_stations DROP COLUMN coordinate_distance_km;
+ALTER TABLE petrol_filling_stations DROP COLUMN coordinate_issue;
+DROP TABLE IF EXISTS postcode_centroids;
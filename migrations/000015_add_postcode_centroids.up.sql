@@ -0,0 +1,16 @@
+-- Postcode centroids, e.g. from the ONS Postcode Directory, keyed on the
+-- postcode with spaces removed. Station coordinates are checked against them.
+CREATE TABLE IF NOT EXISTS postcode_centroids (
+    postcode TEXT PRIMARY KEY,
+    latitude REAL NOT NULL,
+    longitude REAL NOT NULL
+);
+
+-- Stations whose coordinates failed validation. When the coordinates were
+-- replaced, the ones supplied by the source are kept in original_latitude
+-- and original_longitude.
+ALTER TABLE petrol_filling_stations ADD COLUMN coordinate_issue TEXT;
+ALTER TABLE petrol_filling_stations ADD COLUMN coordinate_distance_km REAL;
+ALTER TABLE petrol_filling_stations ADD COLUMN original_latitude REAL;
+ALTER TABLE petrol_filling_stations ADD COLUMN original_longitude REAL;
+CREATE INDEX IF NOT EXISTS idx_petrol_coordinate_issue ON petrol_filling_stations(coordinate_issue) WHERE coordinate_issue IS NOT NULL;
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS import_run_batches;
+DROP TABLE IF EXISTS import_runs;
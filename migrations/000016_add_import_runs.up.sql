@@ -0,0 +1,30 @@
+-- A ledger of every fetch of stations or prices from a data source, whether
+-- run by the CRON scheduler or the import command, with the batches applied.
+CREATE TABLE IF NOT EXISTS import_runs (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    job TEXT NOT NULL,
+    source TEXT NOT NULL,
+    triggered_by TEXT NOT NULL,
+    status TEXT NOT NULL DEFAULT 'running',
+    started_at DATETIME NOT NULL,
+    finished_at DATETIME,
+    duration_ms INTEGER,
+    batches INTEGER NOT NULL DEFAULT 0,
+    inserted INTEGER NOT NULL DEFAULT 0,
+    dropped INTEGER NOT NULL DEFAULT 0,
+    error TEXT
+);
+CREATE INDEX IF NOT EXISTS idx_import_runs_started ON import_runs(started_at);
+CREATE INDEX IF NOT EXISTS idx_import_runs_job ON import_runs(job, source, started_at);
+
+CREATE TABLE IF NOT EXISTS import_run_batches (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    run_id INTEGER NOT NULL REFERENCES import_runs(id) ON DELETE CASCADE,
+    batch_number INTEGER NOT NULL,
+    path TEXT,
+    records INTEGER NOT NULL,
+    dropped INTEGER NOT NULL,
+    fetched_at DATETIME,
+    applied_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX IF NOT EXISTS idx_import_run_batches_run ON import_run_batches(run_id);
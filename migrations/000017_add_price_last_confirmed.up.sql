@@ -0,0 +1,39 @@
+-- fuel_prices now holds a row per change of price, rather than one for every
+-- time the price was reported: price_last_updated is when the price was first
+-- seen, and last_confirmed_at the latest time it was reported unchanged.
+-- Existing history is converted by the compact command.
+ALTER TABLE fuel_prices
+ADD COLUMN last_confirmed_at DATETIME;
+
+UPDATE fuel_prices SET last_confirmed_at = price_last_updated;
+
+CREATE INDEX IF NOT EXISTS idx_fuel_prices_last_confirmed ON fuel_prices(last_confirmed_at);
+
+DROP VIEW IF EXISTS fuel_price_latest_with_area;
+CREATE VIEW fuel_price_latest_with_area AS
+WITH latest_prices_ranked AS (
+    SELECT
+        node_id,
+        fuel_type,
+        price,
+        ROW_NUMBER() OVER (PARTITION BY node_id, fuel_type ORDER BY price_last_updated DESC) as rn
+    FROM fuel_prices
+    WHERE last_confirmed_at >= datetime('now', '-14 days')
+      AND anomaly IS NULL
+),
+latest_snapshot AS (
+    SELECT
+        lpr.node_id,
+        lpr.fuel_type,
+        lpr.price,
+        pfs.postcode
+    FROM latest_prices_ranked lpr
+    JOIN petrol_filling_stations pfs ON lpr.node_id = pfs.node_id
+    WHERE lpr.rn = 1
+      AND pfs.inactive = 0
+)
+SELECT
+    fuel_type,
+    price,
+    UPPER(SUBSTR(TRIM(postcode), 1, LENGTH(TRIM(postcode)) - LENGTH(LTRIM(TRIM(postcode), 'ABCDEFGHIJKLMNOPQRSTUVWXYZ')))) as postcode_area
+FROM latest_snapshot;
\ No newline at end of file
@@ -0,0 +1,2 @@
+-- The original, non-canonical fuel types are not kept, so there is nothing to
+-- put back.
\ No newline at end of file
@@ -0,0 +1,86 @@
+-- Fuel types are now canonicalised on ingest; bring those stored before the
+-- catalogue was added into line. The aliases are a snapshot of the catalogue
+-- in internal/models/fuel_types.go. Where a renamed price clashes with one
+-- already stored under the canonical code, the canonical row is kept, and
+-- repeated prices left by the renames are folded by the compact command.
+CREATE TEMP TABLE fuel_type_aliases (
+    alias TEXT PRIMARY KEY,
+    code TEXT NOT NULL
+);
+INSERT INTO fuel_type_aliases (alias, code) VALUES
+    ('UNLEADED', 'E10'),
+    ('SP95_E10', 'E10'),
+    ('SUPER_UNLEADED', 'E5'),
+    ('SP95', 'E5'),
+    ('SP98', 'E5_PREMIUM'),
+    ('PREMIUM_UNLEADED', 'E5_PREMIUM'),
+    ('B7', 'B7_STANDARD'),
+    ('B7S', 'B7_STANDARD'),
+    ('DIESEL', 'B7_STANDARD'),
+    ('GAZOLE', 'B7_STANDARD'),
+    ('B7P', 'B7_PREMIUM'),
+    ('SDV', 'B7_PREMIUM'),
+    ('SUPER_DIESEL', 'B7_PREMIUM'),
+    ('PREMIUM_DIESEL', 'B7_PREMIUM'),
+    ('HVO100', 'HVO'),
+    ('AUTOGAS', 'LPG'),
+    ('GPL', 'LPG'),
+    ('GPLC', 'LPG');
+
+-- Every stored fuel type which is not already canonical, with its code.
+CREATE TEMP TABLE fuel_type_renames (
+    stored TEXT PRIMARY KEY,
+    code TEXT NOT NULL
+);
+INSERT INTO fuel_type_renames (stored, code)
+SELECT s.stored, COALESCE(a.code, s.normalised)
+FROM (
+    SELECT stored, UPPER(REPLACE(REPLACE(TRIM(stored), ' ', '_'), '-', '_')) AS normalised
+    FROM (
+        SELECT fuel_type AS stored FROM fuel_prices
+        UNION
+        SELECT fuel_type FROM price_quarantine
+        UNION
+        SELECT fuel_type FROM cleanse_audit
+        UNION
+        SELECT j.value
+        FROM petrol_filling_stations pfs,
+             json_each(CASE WHEN json_valid(pfs.fuel_types_json) THEN pfs.fuel_types_json END) j
+        WHERE j.type = 'text'
+    )
+) s
+LEFT JOIN fuel_type_aliases a ON a.alias = s.normalised
+WHERE s.stored <> COALESCE(a.code, s.normalised);
+
+UPDATE OR IGNORE fuel_prices
+SET fuel_type = (SELECT code FROM fuel_type_renames WHERE stored = fuel_prices.fuel_type)
+WHERE fuel_type IN (SELECT stored FROM fuel_type_renames);
+DELETE FROM fuel_prices WHERE fuel_type IN (SELECT stored FROM fuel_type_renames);
+
+UPDATE OR IGNORE price_quarantine
+SET fuel_type = (SELECT code FROM fuel_type_renames WHERE stored = price_quarantine.fuel_type)
+WHERE fuel_type IN (SELECT stored FROM fuel_type_renames);
+DELETE FROM price_quarantine WHERE fuel_type IN (SELECT stored FROM fuel_type_renames);
+
+UPDATE cleanse_audit
+SET fuel_type = (SELECT code FROM fuel_type_renames WHERE stored = cleanse_audit.fuel_type)
+WHERE fuel_type IN (SELECT stored FROM fuel_type_renames);
+
+UPDATE petrol_filling_stations
+SET fuel_types_json = (
+    SELECT json_group_array(code)
+    FROM (
+        SELECT COALESCE(r.code, j.value) AS code
+        FROM json_each(petrol_filling_stations.fuel_types_json) j
+        LEFT JOIN fuel_type_renames r ON r.stored = j.value
+        ORDER BY j.key
+    )
+)
+WHERE EXISTS (
+    SELECT 1
+    FROM json_each(CASE WHEN json_valid(petrol_filling_stations.fuel_types_json) AND json_type(petrol_filling_stations.fuel_types_json) = 'array' THEN petrol_filling_stations.fuel_types_json END) j
+    JOIN fuel_type_renames r ON r.stored = j.value
+);
+
+DROP TABLE fuel_type_renames;
+DROP TABLE fuel_type_aliases;
\ No newline at end of file
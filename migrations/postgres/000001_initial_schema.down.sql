@@ -0,0 +1,13 @@
+DROP VIEW IF EXISTS fuel_price_distribution_stats;
+DROP VIEW IF EXISTS fuel_price_snapshot_stats;
+DROP VIEW IF EXISTS fuel_price_latest_with_area;
+DROP TABLE IF EXISTS import_run_batches;
+DROP TABLE IF EXISTS import_runs;
+DROP TABLE IF EXISTS postcode_centroids;
+DROP TABLE IF EXISTS station_changes;
+DROP TABLE IF EXISTS cleanse_audit;
+DROP TABLE IF EXISTS price_quarantine;
+DROP TABLE IF EXISTS fetch_watermarks;
+DROP TABLE IF EXISTS fuel_prices;
+DROP TABLE IF EXISTS petrol_filling_stations;
+DROP FUNCTION IF EXISTS postcode_area(TEXT);
@@ -0,0 +1,257 @@
+-- PostgreSQL/PostGIS migration for the schema the SQLite migrations have
+-- built up, in one step. Later changes to the schema need a migration here as
+-- well as one for SQLite.
+
+CREATE EXTENSION IF NOT EXISTS postgis;
+
+-- The leading letters of a postcode, e.g. 'SW' for 'SW1A 1AA', which group
+-- stations into postcode areas for the stats and anomaly checks.
+CREATE OR REPLACE FUNCTION postcode_area(postcode TEXT) RETURNS TEXT
+LANGUAGE sql IMMUTABLE PARALLEL SAFE
+AS $$ SELECT substring(upper(trim(postcode)) FROM '^[A-Z]+') $$;
+
+-- Stations table (maps to PetrolFillingStation)
+CREATE TABLE IF NOT EXISTS petrol_filling_stations (
+    node_id TEXT PRIMARY KEY,
+    mft_organisation_name TEXT,
+    public_phone_number TEXT,
+    trading_name TEXT,
+    is_same_trading_and_brand_name BOOLEAN NOT NULL DEFAULT FALSE,
+    brand_name TEXT,
+    temporary_closure BOOLEAN NOT NULL DEFAULT FALSE,
+    permanent_closure BOOLEAN NOT NULL DEFAULT FALSE,
+    permanent_closure_date TIMESTAMPTZ,
+    is_motorway_service_station BOOLEAN NOT NULL DEFAULT FALSE,
+    is_supermarket_service_station BOOLEAN NOT NULL DEFAULT FALSE,
+    address_line_1 TEXT,
+    address_line_2 TEXT,
+    city TEXT,
+    country TEXT,
+    county TEXT,
+    postcode TEXT,
+    latitude DOUBLE PRECISION,
+    longitude DOUBLE PRECISION,
+    opening_times_json TEXT,
+    amenities_json TEXT,
+    fuel_types_json TEXT,
+    updated_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+    source TEXT NOT NULL DEFAULT 'fuel-finder',
+    last_seen_at TIMESTAMPTZ,
+    missed_refreshes INTEGER NOT NULL DEFAULT 0,
+    inactive BOOLEAN NOT NULL DEFAULT FALSE,
+    coordinate_issue TEXT,
+    coordinate_distance_km DOUBLE PRECISION,
+    original_latitude DOUBLE PRECISION,
+    original_longitude DOUBLE PRECISION,
+    location GEOGRAPHY(Point, 4326) GENERATED ALWAYS AS (
+        ST_SetSRID(ST_MakePoint(longitude, latitude), 4326)::geography
+    ) STORED
+);
+-- Radius and distance searches use the geography index, and bounding box
+-- searches the planar one, so that a box's edges follow lines of latitude.
+CREATE INDEX IF NOT EXISTS idx_petrol_location ON petrol_filling_stations USING GIST (location);
+CREATE INDEX IF NOT EXISTS idx_petrol_location_bbox ON petrol_filling_stations USING GIST ((location::geometry));
+CREATE INDEX IF NOT EXISTS idx_petrol_source_last_seen ON petrol_filling_stations(source, last_seen_at);
+CREATE INDEX IF NOT EXISTS idx_petrol_coordinate_issue ON petrol_filling_stations(coordinate_issue) WHERE coordinate_issue IS NOT NULL;
+
+-- Historical fuel prices (maps to FuelPrice), holding a row per change of
+-- price. As in SQLite, where foreign keys are not enforced, prices may arrive
+-- before their station so node_id has no foreign key. id stands in for
+-- SQLite's rowid.
+CREATE TABLE IF NOT EXISTS fuel_prices (
+    id BIGINT GENERATED ALWAYS AS IDENTITY UNIQUE,
+    node_id TEXT NOT NULL,
+    fuel_type TEXT NOT NULL,
+    price_last_updated TIMESTAMPTZ NOT NULL,
+    price DOUBLE PRECISION NOT NULL,
+    recorded_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+    price_change_effective_timestamp TIMESTAMPTZ,
+    source TEXT NOT NULL DEFAULT 'fuel-finder',
+    anomaly TEXT,
+    anomaly_reference DOUBLE PRECISION,
+    last_confirmed_at TIMESTAMPTZ,
+    PRIMARY KEY (node_id, fuel_type, price_last_updated)
+);
+CREATE INDEX IF NOT EXISTS idx_fuel_prices_latest ON fuel_prices(node_id, fuel_type, price_last_updated DESC, price);
+CREATE INDEX IF NOT EXISTS idx_fuel_prices_anomaly ON fuel_prices(price_last_updated) WHERE anomaly IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_fuel_prices_last_confirmed ON fuel_prices(last_confirmed_at);
+
+CREATE TABLE IF NOT EXISTS fetch_watermarks (
+    path TEXT PRIMARY KEY,
+    last_fetched_at TIMESTAMPTZ NOT NULL,
+    updated_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS price_quarantine (
+    id BIGINT GENERATED ALWAYS AS IDENTITY PRIMARY KEY,
+    node_id TEXT NOT NULL,
+    fuel_type TEXT NOT NULL,
+    price_last_updated TIMESTAMPTZ NOT NULL,
+    price_change_effective_timestamp TIMESTAMPTZ,
+    original_price DOUBLE PRECISION NOT NULL,
+    corrected_price DOUBLE PRECISION NOT NULL,
+    reason TEXT NOT NULL,
+    source TEXT NOT NULL DEFAULT 'fuel-finder',
+    batch_path TEXT,
+    batch_number INTEGER,
+    batch_fetched_at TIMESTAMPTZ,
+    status TEXT NOT NULL DEFAULT 'pending',
+    quarantined_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+    reviewed_at TIMESTAMPTZ,
+    UNIQUE (node_id, fuel_type, price_last_updated, reason)
+);
+CREATE INDEX IF NOT EXISTS idx_price_quarantine_status ON price_quarantine(status, quarantined_at);
+
+CREATE TABLE IF NOT EXISTS cleanse_audit (
+    id BIGINT GENERATED ALWAYS AS IDENTITY PRIMARY KEY,
+    run_id TEXT NOT NULL,
+    rule TEXT NOT NULL,
+    node_id TEXT NOT NULL,
+    fuel_type TEXT NOT NULL,
+    price_last_updated TIMESTAMPTZ NOT NULL,
+    price_change_effective_timestamp TIMESTAMPTZ,
+    source TEXT,
+    price_before DOUBLE PRECISION NOT NULL,
+    price_after DOUBLE PRECISION,
+    cleansed_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX IF NOT EXISTS idx_cleanse_audit_run ON cleanse_audit(run_id);
+
+CREATE TABLE IF NOT EXISTS station_changes (
+    id BIGINT GENERATED ALWAYS AS IDENTITY PRIMARY KEY,
+    node_id TEXT NOT NULL,
+    attribute TEXT NOT NULL,
+    old_value TEXT,
+    new_value TEXT,
+    source TEXT NOT NULL DEFAULT 'fuel-finder',
+    changed_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX IF NOT EXISTS idx_station_changes_node ON station_changes(node_id, changed_at);
+
+CREATE TABLE IF NOT EXISTS postcode_centroids (
+    postcode TEXT PRIMARY KEY,
+    latitude DOUBLE PRECISION NOT NULL,
+    longitude DOUBLE PRECISION NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS import_runs (
+    id BIGINT GENERATED ALWAYS AS IDENTITY PRIMARY KEY,
+    job TEXT NOT NULL,
+    source TEXT NOT NULL,
+    triggered_by TEXT NOT NULL,
+    status TEXT NOT NULL DEFAULT 'running',
+    started_at TIMESTAMPTZ NOT NULL,
+    finished_at TIMESTAMPTZ,
+    duration_ms BIGINT,
+    batches INTEGER NOT NULL DEFAULT 0,
+    inserted INTEGER NOT NULL DEFAULT 0,
+    dropped INTEGER NOT NULL DEFAULT 0,
+    error TEXT
+);
+CREATE INDEX IF NOT EXISTS idx_import_runs_started ON import_runs(started_at);
+CREATE INDEX IF NOT EXISTS idx_import_runs_job ON import_runs(job, source, started_at);
+
+CREATE TABLE IF NOT EXISTS import_run_batches (
+    id BIGINT GENERATED ALWAYS AS IDENTITY PRIMARY KEY,
+    run_id BIGINT NOT NULL REFERENCES import_runs(id) ON DELETE CASCADE,
+    batch_number INTEGER NOT NULL,
+    path TEXT,
+    records INTEGER NOT NULL,
+    dropped INTEGER NOT NULL,
+    fetched_at TIMESTAMPTZ,
+    applied_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX IF NOT EXISTS idx_import_run_batches_run ON import_run_batches(run_id);
+
+CREATE OR REPLACE VIEW fuel_price_latest_with_area AS
+WITH latest_prices_ranked AS (
+    SELECT
+        node_id,
+        fuel_type,
+        price,
+        ROW_NUMBER() OVER (PARTITION BY node_id, fuel_type ORDER BY price_last_updated DESC) as rn
+    FROM fuel_prices
+    WHERE last_confirmed_at >= now() - interval '14 days'
+      AND anomaly IS NULL
+),
+latest_snapshot AS (
+    SELECT
+        lpr.node_id,
+        lpr.fuel_type,
+        lpr.price,
+        pfs.postcode
+    FROM latest_prices_ranked lpr
+    JOIN petrol_filling_stations pfs ON lpr.node_id = pfs.node_id
+    WHERE lpr.rn = 1
+      AND NOT pfs.inactive
+)
+SELECT
+    fuel_type,
+    price,
+    postcode_area(postcode) as postcode_area
+FROM latest_snapshot;
+
+CREATE OR REPLACE VIEW fuel_price_snapshot_stats AS
+WITH latest_data AS MATERIALIZED (
+    SELECT * FROM fuel_price_latest_with_area
+),
+national_stats AS (
+    SELECT
+        'National' as scope,
+        NULL::text as postcode_area,
+        fuel_type,
+        MIN(price) as min_price,
+        ROUND(AVG(price)::numeric, 1)::double precision as avg_price,
+        MAX(price) as max_price,
+        SQRT(GREATEST(0, AVG(price * price) - AVG(price) * AVG(price))) as stddev_price,
+        COUNT(*) as sample_size
+    FROM latest_data
+    GROUP BY fuel_type
+),
+postcode_area_stats AS (
+    SELECT
+        'Postcode Area' as scope,
+        postcode_area,
+        fuel_type,
+        MIN(price) as min_price,
+        ROUND(AVG(price)::numeric, 1)::double precision as avg_price,
+        MAX(price) as max_price,
+        SQRT(GREATEST(0, AVG(price * price) - AVG(price) * AVG(price))) as stddev_price,
+        COUNT(*) as sample_size
+    FROM latest_data
+    WHERE postcode_area IS NOT NULL AND postcode_area <> ''
+    GROUP BY postcode_area, fuel_type
+)
+SELECT * FROM national_stats
+UNION ALL
+SELECT * FROM postcode_area_stats;
+
+-- Buckets are floored rather than cast, as a cast to INTEGER rounds.
+CREATE OR REPLACE VIEW fuel_price_distribution_stats AS
+WITH latest_data AS MATERIALIZED (
+    SELECT * FROM fuel_price_latest_with_area
+),
+national_distribution AS (
+    SELECT
+        'National' as scope,
+        NULL::text as postcode_area,
+        fuel_type,
+        (FLOOR(price / 2)::integer * 2) as price_bucket,
+        COUNT(*) as sample_size
+    FROM latest_data
+    GROUP BY fuel_type, price_bucket
+),
+postcode_area_distribution AS (
+    SELECT
+        'Postcode Area' as scope,
+        postcode_area,
+        fuel_type,
+        (FLOOR(price / 2)::integer * 2) as price_bucket,
+        COUNT(*) as sample_size
+    FROM latest_data
+    WHERE postcode_area IS NOT NULL AND postcode_area <> ''
+    GROUP BY postcode_area, fuel_type, price_bucket
+)
+SELECT * FROM national_distribution
+UNION ALL
+SELECT * FROM postcode_area_distribution;
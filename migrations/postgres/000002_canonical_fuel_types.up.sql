@@ -0,0 +1,110 @@
+-- Fuel types are now canonicalised on ingest; bring those stored before the
+-- catalogue was added into line. The aliases are a snapshot of the catalogue
+-- in internal/models/fuel_types.go. Where a renamed price clashes with one
+-- already stored under the canonical code, the canonical row is kept, and
+-- repeated prices left by the renames are folded by the compact command.
+CREATE TEMP TABLE fuel_type_aliases (
+    alias TEXT PRIMARY KEY,
+    code TEXT NOT NULL
+);
+INSERT INTO fuel_type_aliases (alias, code) VALUES
+    ('UNLEADED', 'E10'),
+    ('SP95_E10', 'E10'),
+    ('SUPER_UNLEADED', 'E5'),
+    ('SP95', 'E5'),
+    ('SP98', 'E5_PREMIUM'),
+    ('PREMIUM_UNLEADED', 'E5_PREMIUM'),
+    ('B7', 'B7_STANDARD'),
+    ('B7S', 'B7_STANDARD'),
+    ('DIESEL', 'B7_STANDARD'),
+    ('GAZOLE', 'B7_STANDARD'),
+    ('B7P', 'B7_PREMIUM'),
+    ('SDV', 'B7_PREMIUM'),
+    ('SUPER_DIESEL', 'B7_PREMIUM'),
+    ('PREMIUM_DIESEL', 'B7_PREMIUM'),
+    ('HVO100', 'HVO'),
+    ('AUTOGAS', 'LPG'),
+    ('GPL', 'LPG'),
+    ('GPLC', 'LPG');
+
+-- Every stored fuel type which is not already canonical, with its code.
+CREATE TEMP TABLE fuel_type_renames (
+    stored TEXT PRIMARY KEY,
+    code TEXT NOT NULL
+);
+INSERT INTO fuel_type_renames (stored, code)
+SELECT s.stored, COALESCE(a.code, s.normalised)
+FROM (
+    SELECT stored, UPPER(REPLACE(REPLACE(TRIM(stored), ' ', '_'), '-', '_')) AS normalised
+    FROM (
+        SELECT fuel_type AS stored FROM fuel_prices
+        UNION
+        SELECT fuel_type FROM price_quarantine
+        UNION
+        SELECT fuel_type FROM cleanse_audit
+        UNION
+        SELECT j.value
+        FROM petrol_filling_stations pfs,
+             json_array_elements_text(CASE WHEN json_typeof(pfs.fuel_types_json::json) = 'array' THEN pfs.fuel_types_json::json END) j(value)
+    ) stored_types
+) s
+LEFT JOIN fuel_type_aliases a ON a.alias = s.normalised
+WHERE s.stored <> COALESCE(a.code, s.normalised);
+
+-- Drop the renamed prices which would clash with one stored under the
+-- canonical code, or with another alias of it stored first, before renaming.
+DELETE FROM fuel_prices fp
+USING fuel_type_renames r
+WHERE fp.fuel_type = r.stored
+  AND EXISTS (
+      SELECT 1
+      FROM fuel_prices other
+      LEFT JOIN fuel_type_renames other_r ON other_r.stored = other.fuel_type
+      WHERE other.node_id = fp.node_id
+        AND other.price_last_updated = fp.price_last_updated
+        AND COALESCE(other_r.code, other.fuel_type) = r.code
+        AND (other_r.stored IS NULL OR other.id < fp.id)
+  );
+UPDATE fuel_prices fp
+SET fuel_type = r.code
+FROM fuel_type_renames r
+WHERE fp.fuel_type = r.stored;
+
+DELETE FROM price_quarantine pq
+USING fuel_type_renames r
+WHERE pq.fuel_type = r.stored
+  AND EXISTS (
+      SELECT 1
+      FROM price_quarantine other
+      LEFT JOIN fuel_type_renames other_r ON other_r.stored = other.fuel_type
+      WHERE other.node_id = pq.node_id
+        AND other.price_last_updated = pq.price_last_updated
+        AND other.reason = pq.reason
+        AND COALESCE(other_r.code, other.fuel_type) = r.code
+        AND (other_r.stored IS NULL OR other.id < pq.id)
+  );
+UPDATE price_quarantine pq
+SET fuel_type = r.code
+FROM fuel_type_renames r
+WHERE pq.fuel_type = r.stored;
+
+UPDATE cleanse_audit ca
+SET fuel_type = r.code
+FROM fuel_type_renames r
+WHERE ca.fuel_type = r.stored;
+
+-- Rebuilt in the compact form written by encoding/json.
+UPDATE petrol_filling_stations pfs
+SET fuel_types_json = (
+    SELECT '[' || string_agg(to_json(COALESCE(r.code, j.value))::text, ',' ORDER BY j.n) || ']'
+    FROM json_array_elements_text(pfs.fuel_types_json::json) WITH ORDINALITY j(value, n)
+    LEFT JOIN fuel_type_renames r ON r.stored = j.value
+)
+WHERE EXISTS (
+    SELECT 1
+    FROM json_array_elements_text(CASE WHEN json_typeof(pfs.fuel_types_json::json) = 'array' THEN pfs.fuel_types_json::json END) j(value)
+    JOIN fuel_type_renames r ON r.stored = j.value
+);
+
+DROP TABLE fuel_type_renames;
+DROP TABLE fuel_type_aliases;
\ No newline at end of file
@@ -0,0 +1,30 @@
+CREATE OR REPLACE VIEW fuel_price_latest_with_area AS
+WITH latest_prices_ranked AS (
+    SELECT
+        node_id,
+        fuel_type,
+        price,
+        ROW_NUMBER() OVER (PARTITION BY node_id, fuel_type ORDER BY price_last_updated DESC) as rn
+    FROM fuel_prices
+    WHERE last_confirmed_at >= now() - interval '14 days'
+      AND anomaly IS NULL
+),
+latest_snapshot AS (
+    SELECT
+        lpr.node_id,
+        lpr.fuel_type,
+        lpr.price,
+        pfs.postcode
+    FROM latest_prices_ranked lpr
+    JOIN petrol_filling_stations pfs ON lpr.node_id = pfs.node_id
+    WHERE lpr.rn = 1
+      AND NOT pfs.inactive
+)
+SELECT
+    fuel_type,
+    price,
+    postcode_area(postcode) as postcode_area
+FROM latest_snapshot;
+
+ALTER TABLE petrol_filling_stations
+DROP COLUMN country_code;
\ No newline at end of file
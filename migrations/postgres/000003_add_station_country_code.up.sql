@@ -0,0 +1,37 @@
+-- Stations from the French and German feeds are priced in euro cents, not
+-- pence, and their postcodes don't carry a UK postcode area, so record the
+-- country of each station and keep the UK stats to stations in GB.
+ALTER TABLE petrol_filling_stations
+ADD COLUMN country_code TEXT NOT NULL DEFAULT 'GB';
+
+UPDATE petrol_filling_stations SET country_code = 'FR' WHERE country = 'France';
+UPDATE petrol_filling_stations SET country_code = 'DE' WHERE country = 'Germany';
+
+CREATE OR REPLACE VIEW fuel_price_latest_with_area AS
+WITH latest_prices_ranked AS (
+    SELECT
+        node_id,
+        fuel_type,
+        price,
+        ROW_NUMBER() OVER (PARTITION BY node_id, fuel_type ORDER BY price_last_updated DESC) as rn
+    FROM fuel_prices
+    WHERE last_confirmed_at >= now() - interval '14 days'
+      AND anomaly IS NULL
+),
+latest_snapshot AS (
+    SELECT
+        lpr.node_id,
+        lpr.fuel_type,
+        lpr.price,
+        pfs.postcode
+    FROM latest_prices_ranked lpr
+    JOIN petrol_filling_stations pfs ON lpr.node_id = pfs.node_id
+    WHERE lpr.rn = 1
+      AND NOT pfs.inactive
+      AND pfs.country_code = 'GB'
+)
+SELECT
+    fuel_type,
+    price,
+    postcode_area(postcode) as postcode_area
+FROM latest_snapshot;
\ No newline at end of file